@@ -6,85 +6,319 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
-
 	_ "github.com/lib/pq"
 
+	"reviewer_service/internal/buildinfo"
+	"reviewer_service/internal/config"
 	"reviewer_service/internal/handlers"
+	"reviewer_service/internal/health"
+	"reviewer_service/internal/jobs"
+	"reviewer_service/internal/migrator"
+	"reviewer_service/internal/notify"
+	"reviewer_service/internal/outbox"
 	"reviewer_service/internal/repository"
 	"reviewer_service/internal/service"
 )
 
 func main() {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatal("DATABASE_URL is required")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(args[1:])
+		return
 	}
 
-	db, err := sql.Open("postgres", dbURL)
+	cfg, _, err := config.Load(args)
 	if err != nil {
-		log.Fatal("Failed to connect to DB:", err)
+		log.Fatal("Failed to load config:", err)
 	}
-	defer db.Close()
+	info := buildinfo.Get()
+	log.Printf("reviewer_service %s (commit %s, built %s)", info.Version, info.Commit, info.BuildTime)
+	log.Printf("Effective config:\n%s", cfg)
 
-	if err := waitForDB(db); err != nil {
+	db, err := openDB(cfg.Database)
+	if err != nil {
 		log.Fatal("Database is not ready:", err)
 	}
+	defer db.Close()
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatal("Failed to create PostgreSQL driver:", err)
+	migrations := migrator.New(db, cfg.Migrations.LockTimeout.Duration)
+	if cfg.Migrations.Auto {
+		if err := migrations.Up(context.Background()); err != nil {
+			log.Fatal("Migration failed:", err)
+		}
+		log.Println("Migrations applied successfully")
+	} else {
+		log.Println("Automatic migrations are disabled")
 	}
 
-	sourceDriver, err := iofs.New(os.DirFS("."), "migrations")
-	if err != nil {
-		log.Fatal("Failed to open migrations folder:", err)
-	}
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	prRepo := repository.NewPullRequestRepository(db)
+	codeOwnersRepo := repository.NewCodeOwnersRepository(db)
+	exclusionRepo := repository.NewExclusionRepository(db)
+	txManager := repository.NewTxManager(db)
+	// Background jobs are stopped first on shutdown and drained. Queue drains
+	// and emails are sent as jobs, so the scheduler comes before the services.
+	scheduler := jobs.New(repository.NewJobRepository(db), jobs.Options{
+		PollInterval: cfg.Jobs.PollInterval.Duration,
+		DrainTimeout: cfg.Jobs.DrainTimeout.Duration,
+		Retry: jobs.RetryPolicy{
+			MaxAttempts: cfg.Jobs.Retry.MaxAttempts,
+			Backoff:     cfg.Jobs.Retry.Backoff.Duration,
+			MaxBackoff:  cfg.Jobs.Retry.MaxBackoff.Duration,
+		},
+	})
+	prService := service.NewPullRequestService(prRepo, userRepo, teamRepo, codeOwnersRepo, repository.NewReviewQueueRepository(db), exclusionRepo, txManager, scheduler, service.AssignmentOptions{
+		DefaultReviewers: cfg.Assignment.DefaultReviewers,
+		Strategy:         cfg.Assignment.Strategy,
+		EscalationDepth:  cfg.Assignment.EscalationDepth,
+		Rules: service.AssignmentRules{
+			RequireSenior: cfg.Assignment.Rules.RequireSenior,
+			MaxJuniors:    cfg.Assignment.Rules.MaxJuniors,
+			OnViolation:   cfg.Assignment.Rules.OnViolation,
+		},
+		PreferWorkingHours:    cfg.Assignment.WorkingHours.Prefer,
+		WorkingHoursLookahead: cfg.Assignment.WorkingHours.Lookahead.Duration,
+		ReviewSLA:             cfg.SLA.ReviewTime.Duration,
+		MaxOpenReviews:        cfg.Assignment.Capacity.MaxOpenReviews,
+		QueueOrder:            cfg.Assignment.Capacity.QueueOrder,
+		Affinity:              cfg.Assignment.Affinity.Enabled,
+		RotationLimit:         cfg.Assignment.Rotation.MaxConsecutive,
+		RotationWindow:        cfg.Assignment.Rotation.Window.Duration,
+		RotationMode:          cfg.Assignment.Rotation.Mode,
+		Shadow:                cfg.Assignment.Shadow.Enabled,
+		Bots:                  cfg.Assignment.Bots,
+	})
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, prService, txManager)
+	userService := service.NewUserService(userRepo, teamRepo, prRepo, prService, txManager)
+	codeOwnersService := service.NewCodeOwnersService(codeOwnersRepo, teamRepo, userRepo)
+	exclusionService := service.NewExclusionService(exclusionRepo, userRepo)
 
-	m, err := migrate.NewWithInstance("iofs", sourceDriver, "postgres", driver)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notifier, err := newNotifier(cfg, notificationRepo, scheduler)
 	if err != nil {
-		log.Fatal("Failed to create migrate instance:", err)
+		log.Fatal("Failed to load notification templates:", err)
 	}
+	notificationService := service.NewNotificationService(notificationRepo, prRepo, userRepo, notifier, cfg.Notify.DefaultMode, cfg.Notify.Slack.WebhookHosts)
+	slaService := service.NewSLAService(prRepo, userRepo, teamRepo, prService, notifier, txManager, service.SLAOptions{
+		ReviewTime:       cfg.SLA.ReviewTime.Duration,
+		EscalationAfter:  cfg.SLA.Escalation.After.Duration,
+		EscalationAction: cfg.SLA.Escalation.Action,
+	})
+	absenceService := service.NewAbsenceService(repository.NewAbsenceRepository(db), userRepo, prRepo, prService, txManager)
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal("Migration failed:", err)
+	checker := health.NewChecker(cfg.Health.CheckTimeout.Duration)
+	checker.Register("database", db.PingContext)
+	checker.Register("migrations", migrations.CheckSchema)
+
+	checker.Register("jobs", scheduler.Check)
+	if handover := cfg.Absences.Handover; handover.Enabled {
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "absence_handover",
+			Interval: handover.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				res, err := absenceService.HandOverUpcoming(ctx, handover.LeadTime.Duration)
+				if res != nil && res.Absences > 0 {
+					log.Printf("Absence handover: %d absences, %d reassigned, %d flagged", res.Absences, len(res.Reassigned), len(res.Flagged))
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("absence_handover", scheduler.JobCheck("absence_handover"))
+	}
+	if tracking := cfg.SLA.Tracking; tracking.Enabled {
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "review_sla",
+			Interval: tracking.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				res, err := slaService.CheckReviewSLAs(ctx)
+				if res != nil && res.Breached+res.Escalated > 0 {
+					log.Printf("Review SLA: %d breached, %d escalated", res.Breached, res.Escalated)
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("review_sla", scheduler.JobCheck("review_sla"))
+	}
+	scheduler.Handle(service.QueueDrainJob, jobs.RetryPolicy{}, func(ctx context.Context, _ []byte) error {
+		n, err := prService.DrainQueue(ctx)
+		if n > 0 {
+			log.Printf("Review queue drain assigned %d reviewers", n)
+		}
+		return err
+	})
+	if drain := cfg.Jobs.QueueDrain; drain.Enabled {
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "review_queue_drain",
+			Interval: drain.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				n, err := prService.DrainQueue(ctx)
+				if n > 0 {
+					log.Printf("Review queue drain assigned %d reviewers", n)
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("review_queue_drain", scheduler.JobCheck("review_queue_drain"))
+	}
+	if digest := cfg.Notify.Digest; digest.Enabled {
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "review_digest",
+			Interval: digest.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				res, err := notificationService.SendDigests(ctx)
+				if res != nil && res.Sent > 0 {
+					log.Printf("Review digest: %d sent", res.Sent)
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("review_digest", scheduler.JobCheck("review_digest"))
 	}
-	log.Println("Migrations applied successfully")
+	if summary := cfg.Notify.WeeklySummary; summary.Enabled {
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "review_weekly_summary",
+			Interval: summary.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				res, err := notificationService.SendWeeklySummaries(ctx, summary.Interval.Duration)
+				if res != nil && res.Sent > 0 {
+					log.Printf("Weekly summary: %d sent", res.Sent)
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("review_weekly_summary", scheduler.JobCheck("review_weekly_summary"))
+	}
+	if relay := cfg.Outbox.Relay; relay.Enabled {
+		sinks, closeSinks, err := newSinks(cfg.Outbox)
+		if err != nil {
+			log.Fatal("Failed to set up event sinks:", err)
+		}
+		defer closeSinks()
+		if cfg.Notify.Assignments {
+			sinks = append(sinks, outbox.Handler{Label: "notifications", Handle: notificationService.HandleEvent})
+		}
+		r := outbox.NewRelay(repository.NewOutboxRepository(db), sinks, outbox.Options{
+			BatchSize:  relay.BatchSize,
+			Backoff:    cfg.Outbox.Backoff.Duration,
+			MaxBackoff: cfg.Outbox.MaxBackoff.Duration,
+			Retention:  cfg.Outbox.Retention.Duration,
+		})
+		scheduler.AddPeriodic(jobs.Periodic{
+			Name:     "outbox_relay",
+			Interval: relay.Interval.Duration,
+			Run: func(ctx context.Context) error {
+				res, err := r.Run(ctx)
+				if res != nil && res.Failed > 0 {
+					log.Printf("Outbox relay: %d published, %d failed", res.Published, res.Failed)
+				}
+				return err
+			},
+		})
+		checker.RegisterInfo("outbox_relay", scheduler.JobCheck("outbox_relay"))
+	}
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobsDone := scheduler.Start(jobsCtx)
 
-	teamRepo := repository.NewTeamRepository(db)
-	userRepo := repository.NewUserRepository(db)
-	prRepo := repository.NewPullRequestRepository(db)
-	prService := service.NewPullRequestService(prRepo, userRepo, teamRepo)
-	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, prService)
-	userService := service.NewUserService(userRepo, teamRepo)
+	limiter := newRateLimiter(cfg.RateLimit)
 
 	mux := http.NewServeMux()
+	route := func(pattern string, h http.HandlerFunc) {
+		var handler http.Handler = handlers.TimeoutMiddleware(cfg.Timeouts.ForRoute(pattern), h)
+		handler = handlers.BodyLimitMiddleware(cfg.HTTP.MaxBodyBytes, cfg.HTTP.StrictJSON, handler)
+		if limiter != nil {
+			handler = limiter.Middleware(pattern, handler)
+		}
+		mux.Handle(pattern, handler)
+	}
+
+	route("GET /health", handlers.HealthHandler)
+	route("POST /team/add", handlers.AddTeamHandler(teamService))
+	route("POST /pullRequest/create", handlers.CreatePullRequestHandler(prService))
+	route("POST /pullRequest/merge", handlers.MergePullRequestHandler(prService))
+	route("POST /pullRequest/reassign", handlers.ReassignReviewerHandler(prService))
+	route("GET /users/getReview", handlers.GetReviewPRsHandler(prService))
+	route("GET /stats/reviews", handlers.GetReviewStatsHandler(prService))
+	route("GET /stats/teams", handlers.GetTeamStatsHandler(prService))
+	route("POST /team/deactivateUsers", handlers.DeactivateUsersHandler(teamService))
+	route("POST /users/setIsActive", handlers.SetIsActiveHandler(userService))
+	route("POST /users/move", handlers.MoveUserHandler(userService))
+	route("GET /users/teamHistory", handlers.GetTeamHistoryHandler(userService))
+	route("GET /team/get", handlers.GetTeamHandler(teamService))
+	route("POST /team/rename", handlers.RenameTeamHandler(teamService))
+	route("POST /team/archive", handlers.ArchiveTeamHandler(teamService))
+	route("POST /team/unarchive", handlers.UnarchiveTeamHandler(teamService))
+	route("POST /team/delete", handlers.DeleteTeamHandler(teamService))
+	route("POST /team/addMember", handlers.AddTeamMemberHandler(teamService))
+	route("POST /team/removeMember", handlers.RemoveTeamMemberHandler(teamService))
+	route("POST /team/setParent", handlers.SetParentTeamHandler(teamService))
+	route("POST /team/setRole", handlers.SetTeamRoleHandler(teamService))
+	route("POST /team/codeOwners", handlers.SetCodeOwnersHandler(codeOwnersService))
+	route("POST /team/codeOwners/upload", handlers.UploadCodeOwnersHandler(codeOwnersService))
+	route("GET /team/codeOwners", handlers.GetCodeOwnersHandler(codeOwnersService))
+	route("POST /users/absences/add", handlers.AddAbsenceHandler(absenceService))
+	route("GET /users/absences", handlers.ListAbsencesHandler(absenceService))
+	route("POST /users/absences/delete", handlers.DeleteAbsenceHandler(absenceService))
+	route("POST /users/absences/import", handlers.ImportAbsencesHandler(absenceService))
+	route("POST /users/setWorkingHours", handlers.SetWorkingHoursHandler(userService))
+	route("GET /users/workingHours", handlers.GetWorkingHoursHandler(userService))
+	route("POST /users/setNotificationPreferences", handlers.SetNotificationPreferencesHandler(notificationService))
+	route("GET /users/notificationPreferences", handlers.GetNotificationPreferencesHandler(notificationService))
+	route("POST /users/setEmail", handlers.SetEmailHandler(notificationService))
+	route("GET /users/unsubscribe", handlers.UnsubscribeConfirmHandler())
+	route("POST /users/unsubscribe", handlers.UnsubscribeHandler(notificationService))
+	route("POST /users/setSkills", handlers.SetSkillsHandler(userService))
+	route("POST /users/setShadowOptIn", handlers.SetShadowOptInHandler(userService))
+	route("POST /users/exclusions/add", handlers.AddExclusionHandler(exclusionService))
+	route("POST /users/exclusions/delete", handlers.DeleteExclusionHandler(exclusionService))
+	route("GET /users/exclusions", handlers.ListExclusionsHandler(exclusionService))
+	route("POST /users/setCapacity", handlers.SetUserCapacityHandler(userService))
+	route("POST /team/setCapacity", handlers.SetTeamCapacityHandler(teamService))
+	route("POST /pullRequest/addReviewer", handlers.AddReviewerHandler(prService))
+	route("POST /pullRequest/removeReviewer", handlers.RemoveReviewerHandler(prService))
+	route("POST /pullRequest/reassignTo", handlers.ReassignToHandler(prService))
+	route("GET /pullRequest/reviewerEvents", handlers.GetReviewerEventsHandler(prService))
+	route("GET /pullRequest/overdue", handlers.ListOverdueHandler(slaService))
+	route("POST /team/setReviewSLA", handlers.SetTeamSLAHandler(slaService))
+	route("GET /pullRequest/queue", handlers.GetReviewQueueHandler(prService))
+	route("GET /pullRequest/reviewClocks", handlers.GetReviewClocksHandler(prService))
+
+	// Probes must keep answering while clients are throttled.
+	probe := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, handlers.TimeoutMiddleware(cfg.Timeouts.ForRoute(pattern), h))
+	}
+	probe("GET /livez", handlers.LivezHandler)
+	probe("GET /readyz", handlers.ReadyzHandler(checker))
 
-	mux.HandleFunc("GET /health", handlers.HealthHandler)
-	mux.HandleFunc("POST /team/add", handlers.AddTeamHandler(teamService))
-	mux.HandleFunc("POST /pullRequest/create", handlers.CreatePullRequestHandler(prService))
-	mux.HandleFunc("POST /pullRequest/merge", handlers.MergePullRequestHandler(prService))
-	mux.HandleFunc("POST /pullRequest/reassign", handlers.ReassignReviewerHandler(prService))
-	mux.HandleFunc("GET /users/getReview", handlers.GetReviewPRsHandler(prService))
-	mux.HandleFunc("GET /stats/reviews", handlers.GetReviewStatsHandler(prService))
-	mux.HandleFunc("POST /team/deactivateUsers", handlers.DeactivateUsersHandler(teamService))
-	mux.HandleFunc("POST /users/setIsActive", handlers.SetIsActiveHandler(userService))
-	mux.HandleFunc("GET /team/get", handlers.GetTeamHandler(teamService))
+	// Requests inherit baseCtx, so cancelling it aborts in-flight queries
+	// once the graceful shutdown window has passed.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
 
 	handler := handlers.LoggingMiddleware(mux)
-	server := &http.Server{Addr: ":8080", Handler: handler}
+	server := &http.Server{
+		Addr:        cfg.HTTP.Addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
 
 	go func() {
-		log.Println("Server starting on :8080")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Server starting on %s (tls=%t)", cfg.HTTP.Addr, cfg.TLS.Enabled)
+		var err error
+		if cfg.TLS.Enabled {
+			err = server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
@@ -94,21 +328,141 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stopJobs()
+	<-jobsDone
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown.Duration)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
+		cancelBase()
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 	log.Println("Server exited gracefully")
 }
 
-func waitForDB(db *sql.DB) error {
-	for i := 0; i < 30; i++ {
-		if err := db.Ping(); err == nil {
+// newNotifier renders messages from the templates, drops recipients who do
+// not want them and sends the rest over the configured channels. Emails are
+// queued on the scheduler, which must not have been started yet.
+func newNotifier(fullCfg *config.Config, prefs notify.PreferenceStore, scheduler *jobs.Scheduler) (notify.Notifier, error) {
+	cfg := fullCfg.Notify
+	templates, err := notify.LoadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	var channels notify.Multi
+	for _, channel := range cfg.Channels {
+		switch channel {
+		case config.NotifyLog:
+			channels = append(channels, notify.Log{})
+		case config.NotifySlack:
+			channels = append(channels, &notify.Slack{
+				WebhookURL: cfg.Slack.WebhookURL,
+				Store:      prefs,
+				Client:     &http.Client{Timeout: cfg.Slack.Timeout.Duration},
+			})
+		case config.NotifyEmail:
+			sender := &notify.SMTP{
+				Addr:     cfg.Email.SMTPAddr,
+				Username: cfg.Email.Username,
+				Password: cfg.Email.Password,
+				From:     cfg.Email.From,
+				Timeout:  cfg.Email.Timeout.Duration,
+			}
+			scheduler.Handle(notify.EmailJob, jobs.RetryPolicy{
+				MaxAttempts: cfg.Email.MaxAttempts,
+				Backoff:     fullCfg.Jobs.Retry.Backoff.Duration,
+				MaxBackoff:  fullCfg.Jobs.Retry.MaxBackoff.Duration,
+			}, sender.Send)
+			channels = append(channels, &notify.Email{Store: prefs, Queue: scheduler, UnsubscribeURL: cfg.Email.UnsubscribeURL})
+		}
+	}
+	return notify.Templated{
+		Templates: templates,
+		Next:      notify.Preferences{Store: prefs, DefaultMode: cfg.DefaultMode, Next: channels},
+	}, nil
+}
+
+// newSinks builds the configured event sinks; the returned func closes
+// broker connections once the relay has stopped.
+func newSinks(cfg config.OutboxConfig) ([]outbox.Sink, func(), error) {
+	var sinks []outbox.Sink
+	var publishers []outbox.Publisher
+	closeAll := func() {
+		for _, p := range publishers {
+			if err := p.Close(); err != nil {
+				log.Printf("Failed to close event publisher: %v", err)
+			}
+		}
+	}
+
+	for _, name := range cfg.Sinks {
+		switch name {
+		case config.SinkLog:
+			sinks = append(sinks, outbox.Log{})
+		case config.SinkWebhook:
+			client := &http.Client{Timeout: cfg.Webhook.Timeout.Duration}
+			for _, url := range cfg.Webhook.URLs {
+				sinks = append(sinks, &outbox.Webhook{URL: url, Secret: cfg.Webhook.Secret, Client: client})
+			}
+		case config.SinkKafka:
+			p := outbox.NewKafka(cfg.Kafka.Brokers)
+			publishers = append(publishers, p)
+			sinks = append(sinks, &outbox.Broker{Kind: name, Topic: cfg.Kafka.Topic, Key: cfg.PartitionKey, Publisher: p})
+		case config.SinkNATS:
+			p, err := outbox.NewNATS(cfg.NATS.URL, cfg.NATS.JetStream)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			publishers = append(publishers, p)
+			sinks = append(sinks, &outbox.Broker{Kind: name, Topic: cfg.NATS.Subject, SubjectPerType: true, Key: cfg.PartitionKey, Publisher: p})
+		}
+	}
+	return sinks, closeAll, nil
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *handlers.RateLimiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	routes := make(map[string]handlers.RateLimit, len(cfg.Routes))
+	for pattern, rr := range cfg.Routes {
+		routes[pattern] = handlers.RateLimit{RequestsPerSecond: rr.RequestsPerSecond, Burst: rr.Burst}
+	}
+	return handlers.NewRateLimiter(handlers.RateLimiterOptions{
+		Default:      handlers.RateLimit{RequestsPerSecond: cfg.RequestsPerSecond, Burst: cfg.Burst},
+		Routes:       routes,
+		APIKeyHeader: cfg.APIKeyHeader,
+		APIKeys:      cfg.APIKeys,
+		TrustProxy:   cfg.TrustProxy,
+	})
+}
+
+func openDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
+
+	if err := waitForDB(context.Background(), db, cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func waitForDB(ctx context.Context, db *sql.DB, cfg config.DatabaseConfig) error {
+	for i := 0; i < cfg.WaitAttempts; i++ {
+		if err := db.PingContext(ctx); err == nil {
 			return nil
 		}
 		log.Println("Waiting for DB...")
-		time.Sleep(1 * time.Second)
+		time.Sleep(cfg.WaitInterval.Duration)
 	}
-	return fmt.Errorf("database not ready after 30 seconds")
+	return fmt.Errorf("database not ready after %d attempts", cfg.WaitAttempts)
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"reviewer_service/internal/config"
+	"reviewer_service/internal/migrator"
+)
+
+const migrateUsage = `usage: server migrate [flags] <command>
+
+commands:
+  up           apply all pending migrations
+  down [N]     roll back N migrations (default 1)
+  version      print the applied schema version
+  force V      set the schema version to V and clear the dirty flag`
+
+func runMigrate(args []string) {
+	cfg, rest, err := config.Load(args)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if len(rest) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	db, err := openDB(cfg.Database)
+	if err != nil {
+		log.Fatal("Database is not ready:", err)
+	}
+	defer db.Close()
+
+	m := migrator.New(db, cfg.Migrations.LockTimeout.Duration)
+	ctx := context.Background()
+
+	switch cmd, params := rest[0], rest[1:]; cmd {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(params) > 0 {
+			if steps, err = strconv.Atoi(params[0]); err != nil {
+				log.Fatalf("Invalid step count %q", params[0])
+			}
+		}
+		err = m.Down(ctx, steps)
+	case "force":
+		if len(params) != 1 {
+			log.Fatal("force requires a version")
+		}
+		version, convErr := strconv.Atoi(params[0])
+		if convErr != nil {
+			log.Fatalf("Invalid version %q", params[0])
+		}
+		err = m.Force(ctx, version)
+	case "version":
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("migrate %s failed: %v", rest[0], err)
+	}
+
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	latest, err := migrator.Latest()
+	if err != nil {
+		log.Fatal("Failed to read embedded migrations:", err)
+	}
+	fmt.Printf("version=%d dirty=%t latest=%d\n", version, dirty, latest)
+}
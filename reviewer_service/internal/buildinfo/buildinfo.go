@@ -0,0 +1,50 @@
+// Package buildinfo holds version metadata injected at build time:
+//
+//	go build -ldflags "-X reviewer_service/internal/buildinfo.Version=1.4.0 \
+//	  -X reviewer_service/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X reviewer_service/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// Get returns the injected values, falling back to the VCS stamp that the Go
+// toolchain embeds when the binary is built from a git checkout.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
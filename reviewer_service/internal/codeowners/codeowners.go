@@ -0,0 +1,120 @@
+// Package codeowners parses CODEOWNERS files and matches their
+// gitignore-style patterns against repository paths.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type Rule struct {
+	Pattern string
+	// Owners is empty for a pattern that explicitly has no owners.
+	Owners []string
+	Line   int
+}
+
+// Parse reads CODEOWNERS rules in file order. Comments, blank lines and
+// GitLab-style [Section] headers are skipped.
+func Parse(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripComment(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		if _, err := Compile(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, Rule{Pattern: pattern, Owners: fields[1:], Line: n})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// Compile turns a CODEOWNERS pattern into a regexp over slash-separated
+// paths without a leading slash. Patterns containing a slash other than a
+// trailing one are anchored at the repository root, others match at any
+// depth. A match on a directory covers everything below it.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSpace(pattern)
+	if p == "" || p == "/" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.Trim(p, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				i++
+				if i+1 < len(p) && p[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	return regexp.Compile(b.String())
+}
+
+// Matcher resolves the owners of paths; the last matching rule wins.
+type Matcher struct {
+	rules    []Rule
+	patterns []*regexp.Regexp
+}
+
+func NewMatcher(rules []Rule) (*Matcher, error) {
+	m := &Matcher{rules: rules, patterns: make([]*regexp.Regexp, len(rules))}
+	for i, rule := range rules {
+		re, err := Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", rule.Pattern, err)
+		}
+		m.patterns[i] = re
+	}
+	return m, nil
+}
+
+func (m *Matcher) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		if m.patterns[i].MatchString(path) {
+			return m.rules[i].Owners
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package codeowners
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		// Without a slash the pattern matches at any depth.
+		{"*.go", []string{"main.go", "cmd/server/main.go"}, []string{"main.go.txt", "gopher"}},
+		{"Makefile", []string{"Makefile", "tools/Makefile", "Makefile/part"}, []string{"Makefile.old", "xMakefile"}},
+		// A trailing slash alone does not anchor.
+		{"docs/", []string{"docs/index.md", "api/docs/index.md"}, []string{"docsite/index.md"}},
+		// A leading or inner slash anchors at the root.
+		{"/docs/", []string{"docs/index.md", "docs/api/v1.md"}, []string{"api/docs/index.md"}},
+		{"src/*.go", []string{"src/main.go"}, []string{"src/pkg/main.go", "lib/src/main.go"}},
+		{"/build", []string{"build", "build/out.bin"}, []string{"tools/build"}},
+		// ** crosses directories.
+		{"/db/**", []string{"db/schema.sql", "db/migrations/1.sql"}, []string{"db", "app/db/schema.sql"}},
+		{"**/migrations", []string{"migrations/1.sql", "app/db/migrations/1.sql"}, []string{"migrations2/1.sql"}},
+		{"a/**/b", []string{"a/b", "a/x/b", "a/x/y/b/c.txt"}, []string{"a/xb", "c/a/b"}},
+		{"/internal/**/*.sql", []string{"internal/q.sql", "internal/repo/pg/q.sql"}, []string{"internal/q.go"}},
+		// ? is one character other than a slash; dots are literal.
+		{"v?.txt", []string{"v1.txt", "doc/v2.txt"}, []string{"v10.txt", "v/.txt", "v1xtxt"}},
+	}
+	for _, tc := range tests {
+		re, err := Compile(tc.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tc.pattern, err)
+		}
+		for _, path := range tc.match {
+			if !re.MatchString(path) {
+				t.Errorf("%q does not match %q (%s)", tc.pattern, path, re)
+			}
+		}
+		for _, path := range tc.noMatch {
+			if re.MatchString(path) {
+				t.Errorf("%q matches %q (%s)", tc.pattern, path, re)
+			}
+		}
+	}
+}
+
+func TestCompileRejectsEmptyPattern(t *testing.T) {
+	for _, pattern := range []string{"", " ", "/"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestMatcherLastMatchWins(t *testing.T) {
+	m, err := NewMatcher([]Rule{
+		{Pattern: "*", Owners: []string{"@all"}},
+		{Pattern: "*.go", Owners: []string{"@gophers"}},
+		{Pattern: "/db/**", Owners: []string{"@dba", "@lead"}},
+		{Pattern: "/docs/", Owners: []string{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@all"}},
+		{"cmd/main.go", []string{"@gophers"}},
+		{"/cmd/main.go", []string{"@gophers"}},
+		{"db/migrate.go", []string{"@dba", "@lead"}},
+		{"docs/main.go", []string{}},
+	}
+	for _, tc := range tests {
+		if got := m.Owners(tc.path); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Owners(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	empty, _ := NewMatcher(nil)
+	if got := empty.Owners("main.go"); got != nil {
+		t.Errorf("Owners without rules = %q, want nil", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	file := `# Code owners
+*        @all
+
+[Database]
+/db/**   @dba @lead   # schema changes
+\#notes  @writer
+/docs/
+`
+	rules, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{
+		{Pattern: "*", Owners: []string{"@all"}, Line: 2},
+		{Pattern: "/db/**", Owners: []string{"@dba", "@lead"}, Line: 5},
+		{Pattern: "#notes", Owners: []string{"@writer"}, Line: 6},
+		{Pattern: "/docs/", Owners: []string{}, Line: 7},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", rules, want)
+	}
+}
+
+func TestParseReportsLine(t *testing.T) {
+	_, err := Parse(strings.NewReader("*.go @gophers\n/ @root\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("Parse error %v, want one for line 2", err)
+	}
+}
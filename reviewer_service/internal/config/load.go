@@ -0,0 +1,507 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the effective configuration. Sources are applied in order of
+// increasing precedence: built-in defaults, the config file (-config flag or
+// CONFIG_FILE), environment variables, then command-line flags. Arguments
+// left after the flags are returned unchanged.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	overrides := newFlagOverrides(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, nil, err
+	}
+	if err := overrides.apply(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// Unknown keys are errors, so a misspelled setting is not silently
+	// left at its default.
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(cfg); err == io.EOF {
+			err = nil
+		}
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), cfg)
+		if keys := md.Undecoded(); err == nil && len(keys) > 0 {
+			names := make([]string, len(keys))
+			for i, key := range keys {
+				names[i] = key.String()
+			}
+			err = fmt.Errorf("unknown keys %s", strings.Join(names, ", "))
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+type envBinding struct {
+	name  string
+	apply func(cfg *Config, value string) error
+}
+
+var envBindings = []envBinding{
+	{"HTTP_ADDR", setString(func(c *Config) *string { return &c.HTTP.Addr })},
+	{"HTTP_MAX_BODY_BYTES", setInt64(func(c *Config) *int64 { return &c.HTTP.MaxBodyBytes })},
+	{"HTTP_STRICT_JSON", setBool(func(c *Config) *bool { return &c.HTTP.StrictJSON })},
+	{"TLS_ENABLED", setBool(func(c *Config) *bool { return &c.TLS.Enabled })},
+	{"TLS_CERT_FILE", setString(func(c *Config) *string { return &c.TLS.CertFile })},
+	{"TLS_KEY_FILE", setString(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{"DATABASE_URL", setString(func(c *Config) *string { return &c.Database.URL })},
+	{"DB_MAX_OPEN_CONNS", setInt(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", setInt(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", setDuration(func(c *Config) *Duration { return &c.Database.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", setDuration(func(c *Config) *Duration { return &c.Database.ConnMaxIdleTime })},
+	{"DB_WAIT_ATTEMPTS", setInt(func(c *Config) *int { return &c.Database.WaitAttempts })},
+	{"DB_WAIT_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Database.WaitInterval })},
+	{"SHUTDOWN_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Timeouts.Shutdown })},
+	{"REQUEST_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Timeouts.Request })},
+	{"ROUTE_TIMEOUTS", setRouteTimeouts},
+	{"MIGRATE_ON_START", setBool(func(c *Config) *bool { return &c.Migrations.Auto })},
+	{"MIGRATION_LOCK_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Migrations.LockTimeout })},
+	{"DEFAULT_REVIEWERS", setInt(func(c *Config) *int { return &c.Assignment.DefaultReviewers })},
+	{"ASSIGNMENT_STRATEGY", setString(func(c *Config) *string { return &c.Assignment.Strategy })},
+	{"ASSIGNMENT_ESCALATION_DEPTH", setInt(func(c *Config) *int { return &c.Assignment.EscalationDepth })},
+	{"ASSIGNMENT_REQUIRE_SENIOR", setBool(func(c *Config) *bool { return &c.Assignment.Rules.RequireSenior })},
+	{"ASSIGNMENT_MAX_JUNIORS", setInt(func(c *Config) *int { return &c.Assignment.Rules.MaxJuniors })},
+	{"ASSIGNMENT_ON_VIOLATION", setString(func(c *Config) *string { return &c.Assignment.Rules.OnViolation })},
+	{"ASSIGNMENT_PREFER_WORKING_HOURS", setBool(func(c *Config) *bool { return &c.Assignment.WorkingHours.Prefer })},
+	{"ASSIGNMENT_WORKING_HOURS_LOOKAHEAD", setDuration(func(c *Config) *Duration { return &c.Assignment.WorkingHours.Lookahead })},
+	{"ASSIGNMENT_MAX_OPEN_REVIEWS", setInt(func(c *Config) *int { return &c.Assignment.Capacity.MaxOpenReviews })},
+	{"ASSIGNMENT_QUEUE_ORDER", setString(func(c *Config) *string { return &c.Assignment.Capacity.QueueOrder })},
+	{"ASSIGNMENT_AFFINITY", setBool(func(c *Config) *bool { return &c.Assignment.Affinity.Enabled })},
+	{"ASSIGNMENT_ROTATION_MAX_CONSECUTIVE", setInt(func(c *Config) *int { return &c.Assignment.Rotation.MaxConsecutive })},
+	{"ASSIGNMENT_ROTATION_WINDOW", setDuration(func(c *Config) *Duration { return &c.Assignment.Rotation.Window })},
+	{"ASSIGNMENT_ROTATION_MODE", setString(func(c *Config) *string { return &c.Assignment.Rotation.Mode })},
+	{"ASSIGNMENT_SHADOW_REVIEWERS", setBool(func(c *Config) *bool { return &c.Assignment.Shadow.Enabled })},
+	{"ASSIGNMENT_BOTS", setStringList(func(c *Config) *[]string { return &c.Assignment.Bots })},
+	{"SLA_REVIEW_TIME", setDuration(func(c *Config) *Duration { return &c.SLA.ReviewTime })},
+	{"SLA_TRACKING_ENABLED", setBool(func(c *Config) *bool { return &c.SLA.Tracking.Enabled })},
+	{"SLA_TRACKING_INTERVAL", setDuration(func(c *Config) *Duration { return &c.SLA.Tracking.Interval })},
+	{"SLA_ESCALATION_AFTER", setDuration(func(c *Config) *Duration { return &c.SLA.Escalation.After })},
+	{"SLA_ESCALATION_ACTION", setString(func(c *Config) *string { return &c.SLA.Escalation.Action })},
+	{"NOTIFY_CHANNELS", setStringList(func(c *Config) *[]string { return &c.Notify.Channels })},
+	{"NOTIFY_DEFAULT_MODE", setString(func(c *Config) *string { return &c.Notify.DefaultMode })},
+	{"NOTIFY_TEMPLATES_DIR", setString(func(c *Config) *string { return &c.Notify.TemplatesDir })},
+	{"NOTIFY_SLACK_WEBHOOK_URL", setString(func(c *Config) *string { return &c.Notify.Slack.WebhookURL })},
+	{"NOTIFY_SLACK_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Notify.Slack.Timeout })},
+	{"NOTIFY_SLACK_WEBHOOK_HOSTS", setStringList(func(c *Config) *[]string { return &c.Notify.Slack.WebhookHosts })},
+	{"NOTIFY_EMAIL_SMTP_ADDR", setString(func(c *Config) *string { return &c.Notify.Email.SMTPAddr })},
+	{"NOTIFY_EMAIL_USERNAME", setString(func(c *Config) *string { return &c.Notify.Email.Username })},
+	{"NOTIFY_EMAIL_PASSWORD", setString(func(c *Config) *string { return &c.Notify.Email.Password })},
+	{"NOTIFY_EMAIL_FROM", setString(func(c *Config) *string { return &c.Notify.Email.From })},
+	{"NOTIFY_EMAIL_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Notify.Email.Timeout })},
+	{"NOTIFY_EMAIL_UNSUBSCRIBE_URL", setString(func(c *Config) *string { return &c.Notify.Email.UnsubscribeURL })},
+	{"NOTIFY_EMAIL_MAX_ATTEMPTS", setInt(func(c *Config) *int { return &c.Notify.Email.MaxAttempts })},
+	{"NOTIFY_ASSIGNMENTS", setBool(func(c *Config) *bool { return &c.Notify.Assignments })},
+	{"NOTIFY_DIGEST_ENABLED", setBool(func(c *Config) *bool { return &c.Notify.Digest.Enabled })},
+	{"NOTIFY_DIGEST_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Notify.Digest.Interval })},
+	{"NOTIFY_WEEKLY_SUMMARY_ENABLED", setBool(func(c *Config) *bool { return &c.Notify.WeeklySummary.Enabled })},
+	{"NOTIFY_WEEKLY_SUMMARY_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Notify.WeeklySummary.Interval })},
+	{"HEALTH_CHECK_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Health.CheckTimeout })},
+	{"ABSENCE_HANDOVER_ENABLED", setBool(func(c *Config) *bool { return &c.Absences.Handover.Enabled })},
+	{"ABSENCE_HANDOVER_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Absences.Handover.Interval })},
+	{"ABSENCE_HANDOVER_LEAD_TIME", setDuration(func(c *Config) *Duration { return &c.Absences.Handover.LeadTime })},
+	{"JOBS_POLL_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Jobs.PollInterval })},
+	{"JOBS_DRAIN_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Jobs.DrainTimeout })},
+	{"JOBS_RETRY_MAX_ATTEMPTS", setInt(func(c *Config) *int { return &c.Jobs.Retry.MaxAttempts })},
+	{"JOBS_RETRY_BACKOFF", setDuration(func(c *Config) *Duration { return &c.Jobs.Retry.Backoff })},
+	{"JOBS_RETRY_MAX_BACKOFF", setDuration(func(c *Config) *Duration { return &c.Jobs.Retry.MaxBackoff })},
+	{"JOBS_QUEUE_DRAIN_ENABLED", setBool(func(c *Config) *bool { return &c.Jobs.QueueDrain.Enabled })},
+	{"JOBS_QUEUE_DRAIN_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Jobs.QueueDrain.Interval })},
+	{"OUTBOX_RELAY_ENABLED", setBool(func(c *Config) *bool { return &c.Outbox.Relay.Enabled })},
+	{"OUTBOX_RELAY_INTERVAL", setDuration(func(c *Config) *Duration { return &c.Outbox.Relay.Interval })},
+	{"OUTBOX_BATCH_SIZE", setInt(func(c *Config) *int { return &c.Outbox.Relay.BatchSize })},
+	{"OUTBOX_SINKS", setStringList(func(c *Config) *[]string { return &c.Outbox.Sinks })},
+	{"OUTBOX_WEBHOOK_URLS", setStringList(func(c *Config) *[]string { return &c.Outbox.Webhook.URLs })},
+	{"OUTBOX_WEBHOOK_SECRET", setString(func(c *Config) *string { return &c.Outbox.Webhook.Secret })},
+	{"OUTBOX_WEBHOOK_TIMEOUT", setDuration(func(c *Config) *Duration { return &c.Outbox.Webhook.Timeout })},
+	{"OUTBOX_KAFKA_BROKERS", setStringList(func(c *Config) *[]string { return &c.Outbox.Kafka.Brokers })},
+	{"OUTBOX_KAFKA_TOPIC", setString(func(c *Config) *string { return &c.Outbox.Kafka.Topic })},
+	{"OUTBOX_NATS_URL", setString(func(c *Config) *string { return &c.Outbox.NATS.URL })},
+	{"OUTBOX_NATS_SUBJECT", setString(func(c *Config) *string { return &c.Outbox.NATS.Subject })},
+	{"OUTBOX_NATS_JETSTREAM", setBool(func(c *Config) *bool { return &c.Outbox.NATS.JetStream })},
+	{"OUTBOX_PARTITION_KEY", setString(func(c *Config) *string { return &c.Outbox.PartitionKey })},
+	{"OUTBOX_BACKOFF", setDuration(func(c *Config) *Duration { return &c.Outbox.Backoff })},
+	{"OUTBOX_MAX_BACKOFF", setDuration(func(c *Config) *Duration { return &c.Outbox.MaxBackoff })},
+	{"OUTBOX_RETENTION", setDuration(func(c *Config) *Duration { return &c.Outbox.Retention })},
+	{"RATE_LIMIT_ENABLED", setBool(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_RPS", setFloat(func(c *Config) *float64 { return &c.RateLimit.RequestsPerSecond })},
+	{"RATE_LIMIT_BURST", setInt(func(c *Config) *int { return &c.RateLimit.Burst })},
+	{"RATE_LIMIT_ROUTES", setRouteRates},
+	{"RATE_LIMIT_API_KEY_HEADER", setString(func(c *Config) *string { return &c.RateLimit.APIKeyHeader })},
+	{"RATE_LIMIT_API_KEYS", setStringList(func(c *Config) *[]string { return &c.RateLimit.APIKeys })},
+	{"RATE_LIMIT_TRUST_PROXY", setBool(func(c *Config) *bool { return &c.RateLimit.TrustProxy })},
+}
+
+// applyEnv applies every variable that is set, even to an empty value: an
+// empty value clears string and list settings, while numbers, booleans and
+// durations have no empty value and keep what they had.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	for _, b := range envBindings {
+		value, ok := lookup(b.name)
+		if !ok {
+			continue
+		}
+		if err := b.apply(cfg, value); err != nil {
+			return fmt.Errorf("env %s: %w", b.name, err)
+		}
+	}
+	return nil
+}
+
+// flagOverrides registers one flag per setting and applies only the flags
+// that were actually passed, so unset flags never clobber file or env values.
+type flagOverrides struct {
+	fs     *flag.FlagSet
+	values map[string]*string
+	setter map[string]func(*Config, string) error
+}
+
+var flagBindings = []struct {
+	name    string
+	env     string
+	usage   string
+	boolean bool
+}{
+	{"addr", "HTTP_ADDR", "listen address", false},
+	{"max-body-bytes", "HTTP_MAX_BODY_BYTES", "maximum request body size", false},
+	{"strict-json", "HTTP_STRICT_JSON", "reject unknown JSON fields", true},
+	{"tls", "TLS_ENABLED", "serve HTTPS", true},
+	{"tls-cert", "TLS_CERT_FILE", "TLS certificate file", false},
+	{"tls-key", "TLS_KEY_FILE", "TLS private key file", false},
+	{"database-url", "DATABASE_URL", "PostgreSQL connection URL", false},
+	{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "maximum open DB connections", false},
+	{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maximum idle DB connections", false},
+	{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "maximum DB connection lifetime", false},
+	{"db-conn-max-idle-time", "DB_CONN_MAX_IDLE_TIME", "maximum DB connection idle time", false},
+	{"db-wait-attempts", "DB_WAIT_ATTEMPTS", "DB readiness attempts at startup", false},
+	{"db-wait-interval", "DB_WAIT_INTERVAL", "delay between DB readiness attempts", false},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "graceful shutdown timeout", false},
+	{"request-timeout", "REQUEST_TIMEOUT", "default per-request deadline", false},
+	{"route-timeouts", "ROUTE_TIMEOUTS", `per-route deadlines, e.g. "POST /team/deactivateUsers=30s"`, false},
+	{"migrate-on-start", "MIGRATE_ON_START", "apply pending migrations on server start", true},
+	{"migration-lock-timeout", "MIGRATION_LOCK_TIMEOUT", "how long to wait for the migration lock", false},
+	{"default-reviewers", "DEFAULT_REVIEWERS", "reviewers assigned to a new PR", false},
+	{"assignment-strategy", "ASSIGNMENT_STRATEGY", "reviewer selection strategy: random, first or least_loaded", false},
+	{"escalation-depth", "ASSIGNMENT_ESCALATION_DEPTH", "team hierarchy levels to escalate through for reviewers", false},
+	{"require-senior", "ASSIGNMENT_REQUIRE_SENIOR", "require a senior or lead reviewer on every PR", true},
+	{"max-juniors", "ASSIGNMENT_MAX_JUNIORS", "maximum junior reviewers per PR, 0 for no cap", false},
+	{"on-rule-violation", "ASSIGNMENT_ON_VIOLATION", "reject or warn when assignment rules cannot be met", false},
+	{"prefer-working-hours", "ASSIGNMENT_PREFER_WORKING_HOURS", "prefer reviewers who are within their working hours", true},
+	{"working-hours-lookahead", "ASSIGNMENT_WORKING_HOURS_LOOKAHEAD", "count reviewers starting work within this time as available", false},
+	{"max-open-reviews", "ASSIGNMENT_MAX_OPEN_REVIEWS", "default cap on open reviews per user, 0 for no cap", false},
+	{"queue-order", "ASSIGNMENT_QUEUE_ORDER", "order of PRs waiting for reviewers: fifo or priority", false},
+	{"affinity", "ASSIGNMENT_AFFINITY", "prefer earlier reviewers of the same feature or branch", true},
+	{"rotation-max-consecutive", "ASSIGNMENT_ROTATION_MAX_CONSECUTIVE", "PRs in a row one reviewer may get from the same author, 0 for no limit", false},
+	{"rotation-window", "ASSIGNMENT_ROTATION_WINDOW", "how far back the rotation limit looks", false},
+	{"rotation-mode", "ASSIGNMENT_ROTATION_MODE", "hard to never pick reviewers over the rotation limit, soft to pick them last", false},
+	{"shadow-reviewers", "ASSIGNMENT_SHADOW_REVIEWERS", "add an opted-in junior as a shadow reviewer to new PRs", true},
+	{"assignment-bots", "ASSIGNMENT_BOTS", "comma-separated bot names accepted as actor of manual reviewer changes", false},
+	{"sla-review-time", "SLA_REVIEW_TIME", "review SLA in working hours, 0 to disable", false},
+	{"sla-tracking", "SLA_TRACKING_ENABLED", "mark overdue reviews and escalate them in the background", true},
+	{"sla-tracking-interval", "SLA_TRACKING_INTERVAL", "how often the SLA job runs", false},
+	{"sla-escalation-after", "SLA_ESCALATION_AFTER", "working hours after assignment before escalating, 0 to disable", false},
+	{"sla-escalation-action", "SLA_ESCALATION_ACTION", "escalation action: reassign or add_lead", false},
+	{"notify-channels", "NOTIFY_CHANNELS", "comma-separated notification channels: log, slack, email", false},
+	{"notify-default-mode", "NOTIFY_DEFAULT_MODE", "notification mode of users without preferences: instant, digest or off", false},
+	{"notify-templates-dir", "NOTIFY_TEMPLATES_DIR", "directory of *.tmpl files overriding message templates", false},
+	{"notify-slack-webhook-url", "NOTIFY_SLACK_WEBHOOK_URL", "shared Slack incoming webhook", false},
+	{"notify-slack-timeout", "NOTIFY_SLACK_TIMEOUT", "timeout of one Slack webhook request", false},
+	{"notify-slack-webhook-hosts", "NOTIFY_SLACK_WEBHOOK_HOSTS", "comma-separated hosts allowed in personal Slack webhooks", false},
+	{"notify-email-smtp-addr", "NOTIFY_EMAIL_SMTP_ADDR", "SMTP server host:port", false},
+	{"notify-email-username", "NOTIFY_EMAIL_USERNAME", "SMTP username", false},
+	{"notify-email-password", "NOTIFY_EMAIL_PASSWORD", "SMTP password", false},
+	{"notify-email-from", "NOTIFY_EMAIL_FROM", "sender address of emails", false},
+	{"notify-email-timeout", "NOTIFY_EMAIL_TIMEOUT", "timeout of one SMTP delivery", false},
+	{"notify-email-unsubscribe-url", "NOTIFY_EMAIL_UNSUBSCRIBE_URL", "public URL of /users/unsubscribe for email links", false},
+	{"notify-email-max-attempts", "NOTIFY_EMAIL_MAX_ATTEMPTS", "delivery attempts of one email", false},
+	{"notify-assignments", "NOTIFY_ASSIGNMENTS", "notify reviewers when they are assigned", true},
+	{"notify-digest", "NOTIFY_DIGEST_ENABLED", "send digest-mode users their pending reviews each morning", true},
+	{"notify-digest-interval", "NOTIFY_DIGEST_INTERVAL", "how often the digest job runs", false},
+	{"notify-weekly-summary", "NOTIFY_WEEKLY_SUMMARY_ENABLED", "send users a summary of their reviews", true},
+	{"notify-weekly-summary-interval", "NOTIFY_WEEKLY_SUMMARY_INTERVAL", "period covered by and between summaries", false},
+	{"health-check-timeout", "HEALTH_CHECK_TIMEOUT", "timeout for each readiness check", false},
+	{"absence-handover", "ABSENCE_HANDOVER_ENABLED", "reassign reviews of users before their absence starts", true},
+	{"absence-handover-interval", "ABSENCE_HANDOVER_INTERVAL", "how often the absence handover job runs", false},
+	{"absence-handover-lead-time", "ABSENCE_HANDOVER_LEAD_TIME", "how long before an absence its reviews are handed over", false},
+	{"jobs-poll-interval", "JOBS_POLL_INTERVAL", "how often the job scheduler looks for due jobs", false},
+	{"jobs-drain-timeout", "JOBS_DRAIN_TIMEOUT", "how long running jobs may finish on shutdown", false},
+	{"jobs-retry-max-attempts", "JOBS_RETRY_MAX_ATTEMPTS", "attempts of a failing job run before giving up", false},
+	{"jobs-retry-backoff", "JOBS_RETRY_BACKOFF", "delay before the first retry, doubled per attempt", false},
+	{"jobs-retry-max-backoff", "JOBS_RETRY_MAX_BACKOFF", "upper bound of the retry delay", false},
+	{"queue-drain", "JOBS_QUEUE_DRAIN_ENABLED", "assign reviewers to queued PRs in the background", true},
+	{"queue-drain-interval", "JOBS_QUEUE_DRAIN_INTERVAL", "how often the queue drain job runs", false},
+	{"outbox-relay", "OUTBOX_RELAY_ENABLED", "publish PR events from the outbox", true},
+	{"outbox-relay-interval", "OUTBOX_RELAY_INTERVAL", "how often the outbox relay runs", false},
+	{"outbox-batch-size", "OUTBOX_BATCH_SIZE", "events the relay reads per query", false},
+	{"outbox-sinks", "OUTBOX_SINKS", "comma-separated event sinks: log, webhook, kafka, nats", false},
+	{"outbox-webhook-urls", "OUTBOX_WEBHOOK_URLS", "comma-separated webhook URLs", false},
+	{"outbox-webhook-secret", "OUTBOX_WEBHOOK_SECRET", "HMAC secret for webhook signatures", false},
+	{"outbox-webhook-timeout", "OUTBOX_WEBHOOK_TIMEOUT", "timeout of one webhook request", false},
+	{"outbox-kafka-brokers", "OUTBOX_KAFKA_BROKERS", "comma-separated Kafka broker addresses", false},
+	{"outbox-kafka-topic", "OUTBOX_KAFKA_TOPIC", "Kafka topic for events", false},
+	{"outbox-nats-url", "OUTBOX_NATS_URL", "NATS server URL", false},
+	{"outbox-nats-subject", "OUTBOX_NATS_SUBJECT", "NATS subject prefix; the event type is appended", false},
+	{"outbox-nats-jetstream", "OUTBOX_NATS_JETSTREAM", "publish to NATS through JetStream", true},
+	{"outbox-partition-key", "OUTBOX_PARTITION_KEY", "broker message key: aggregate, type or none", false},
+	{"outbox-backoff", "OUTBOX_BACKOFF", "delay before retrying a failed event, doubled per attempt", false},
+	{"outbox-max-backoff", "OUTBOX_MAX_BACKOFF", "upper bound of the event retry delay", false},
+	{"outbox-retention", "OUTBOX_RETENTION", "how long published events are kept, 0 to keep them", false},
+	{"rate-limit", "RATE_LIMIT_ENABLED", "enable per-client rate limiting", true},
+	{"rate-limit-rps", "RATE_LIMIT_RPS", "default requests per second per client", false},
+	{"rate-limit-burst", "RATE_LIMIT_BURST", "default burst per client", false},
+	{"rate-limit-routes", "RATE_LIMIT_ROUTES", `per-route limits, e.g. "POST /team/deactivateUsers=0.2:3"`, false},
+	{"rate-limit-api-key-header", "RATE_LIMIT_API_KEY_HEADER", "header identifying API clients", false},
+	{"rate-limit-api-keys", "RATE_LIMIT_API_KEYS", "comma-separated API keys limited per key; others are limited by IP", false},
+	{"rate-limit-trust-proxy", "RATE_LIMIT_TRUST_PROXY", "take client IP from X-Forwarded-For", true},
+}
+
+func newFlagOverrides(fs *flag.FlagSet) *flagOverrides {
+	o := &flagOverrides{fs: fs, values: map[string]*string{}, setter: map[string]func(*Config, string) error{}}
+	for _, fb := range flagBindings {
+		for _, b := range envBindings {
+			if b.name == fb.env {
+				o.setter[fb.name] = b.apply
+			}
+		}
+		if fb.boolean {
+			v := new(string)
+			fs.Var(&boolFlag{target: v}, fb.name, fb.usage)
+			o.values[fb.name] = v
+			continue
+		}
+		o.values[fb.name] = fs.String(fb.name, "", fb.usage)
+	}
+	return o
+}
+
+func (o *flagOverrides) apply(cfg *Config) error {
+	var err error
+	o.fs.Visit(func(f *flag.Flag) {
+		set, ok := o.setter[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if applyErr := set(cfg, *o.values[f.Name]); applyErr != nil {
+			err = fmt.Errorf("flag -%s: %w", f.Name, applyErr)
+		}
+	})
+	return err
+}
+
+// boolFlag keeps the raw value like the string flags do, while still
+// accepting the bare "-tls" form.
+type boolFlag struct {
+	target *string
+}
+
+func (b *boolFlag) String() string {
+	if b.target == nil {
+		return ""
+	}
+	return *b.target
+}
+
+func (b *boolFlag) Set(s string) error {
+	if _, err := strconv.ParseBool(s); err != nil {
+		return err
+	}
+	*b.target = s
+	return nil
+}
+
+func (b *boolFlag) IsBoolFlag() bool { return true }
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setInt64(field func(*Config) *int64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setFloat(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+// setStringList parses a comma-separated list; an empty value clears it.
+func setStringList(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		field(c).Duration = d
+		return nil
+	}
+}
+
+// setRouteTimeouts parses "METHOD /path=duration" pairs separated by commas
+// and merges them over routes from the config file.
+func setRouteTimeouts(c *Config, v string) error {
+	if c.Timeouts.Routes == nil {
+		c.Timeouts.Routes = map[string]Duration{}
+	}
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("malformed entry %q", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q: %w", entry, err)
+		}
+		c.Timeouts.Routes[strings.TrimSpace(pattern)] = Duration{d}
+	}
+	return nil
+}
+
+// setRouteRates parses "METHOD /path=rps:burst" pairs separated by commas
+// and merges them over the configured route limits.
+func setRouteRates(c *Config, v string) error {
+	if c.RateLimit.Routes == nil {
+		c.RateLimit.Routes = map[string]RouteRate{}
+	}
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("malformed entry %q", entry)
+		}
+		rps, burst, ok := strings.Cut(strings.TrimSpace(value), ":")
+		if !ok {
+			return fmt.Errorf("%q: expected rps:burst", entry)
+		}
+		var rr RouteRate
+		var err error
+		if rr.RequestsPerSecond, err = strconv.ParseFloat(rps, 64); err != nil {
+			return fmt.Errorf("%q: %w", entry, err)
+		}
+		if rr.Burst, err = strconv.Atoi(burst); err != nil {
+			return fmt.Errorf("%q: %w", entry, err)
+		}
+		c.RateLimit.Routes[strings.TrimSpace(pattern)] = rr
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+database:
+  url: postgres://file@db/reviews
+http:
+  addr: ":9000"
+timeouts:
+  request: 20s
+assignment:
+  default_reviewers: 3
+  strategy: least_loaded
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("REQUEST_TIMEOUT", "30s")
+	t.Setenv("DEFAULT_REVIEWERS", "4")
+
+	cfg, rest, err := Load([]string{"-default-reviewers", "5", "extra"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// default < file
+	if cfg.Database.MaxOpenConns != 25 {
+		t.Errorf("max_open_conns = %d, want the default 25", cfg.Database.MaxOpenConns)
+	}
+	if cfg.HTTP.Addr != ":9000" || cfg.Assignment.Strategy != "least_loaded" {
+		t.Errorf("file values not applied: addr %q, strategy %q", cfg.HTTP.Addr, cfg.Assignment.Strategy)
+	}
+	// file < env
+	if cfg.Timeouts.Request.Duration != 30*time.Second {
+		t.Errorf("request timeout = %s, want 30s from env", cfg.Timeouts.Request)
+	}
+	// env < flags
+	if cfg.Assignment.DefaultReviewers != 5 {
+		t.Errorf("default_reviewers = %d, want 5 from the flag", cfg.Assignment.DefaultReviewers)
+	}
+	if !reflect.DeepEqual(rest, []string{"extra"}) {
+		t.Errorf("remaining args %q, want [extra]", rest)
+	}
+}
+
+func TestLoadConfigFlagOverridesEnvFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "env.yaml", "database:\n  url: postgres://env@db/reviews\n"))
+	flagFile := writeFile(t, "flag.toml", "[database]\nurl = \"postgres://flag@db/reviews\"\n")
+
+	cfg, _, err := Load([]string{"-config", flagFile})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Database.URL != "postgres://flag@db/reviews" {
+		t.Errorf("database.url = %q, want the one from the -config file", cfg.Database.URL)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	for name, content := range map[string]string{
+		"typo.yaml":   "database:\n  url: postgres://db/reviews\n  max_open_conn: 5\n",
+		"nested.yaml": "rate_limit:\n  burts: 10\n",
+		"typo.toml":   "[database]\nurl = \"postgres://db/reviews\"\nmax_open_conn = 5\n",
+		"top.toml":    "[databse]\nurl = \"postgres://db/reviews\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("DATABASE_URL", "postgres://db/reviews")
+			_, _, err := Load([]string{"-config", writeFile(t, name, content)})
+			if err == nil {
+				t.Fatal("Load succeeded, want an unknown key error")
+			}
+			if !strings.Contains(err.Error(), "parse config file") {
+				t.Errorf("error %v, want a parse error", err)
+			}
+		})
+	}
+}
+
+func TestLoadAcceptsEmptyFile(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://db/reviews")
+	if _, _, err := Load([]string{"-config", writeFile(t, "empty.yaml", "")}); err != nil {
+		t.Errorf("Load: %v", err)
+	}
+}
+
+func TestLoadExampleConfig(t *testing.T) {
+	if _, _, err := Load([]string{"-config", "../../config.example.yaml"}); err != nil {
+		t.Errorf("config.example.yaml: %v", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"HTTP_STRICT_JSON":    "true",
+		"DB_MAX_OPEN_CONNS":   "7",
+		"HTTP_MAX_BODY_BYTES": "2048",
+		"SHUTDOWN_TIMEOUT":    "45s",
+		"ROUTE_TIMEOUTS":      "POST /team/add=2s, GET /stats=500ms",
+		"RATE_LIMIT_ROUTES":   "POST /team/deactivateUsers=0.5:4",
+		"RATE_LIMIT_API_KEYS": " a , ,b",
+		"ASSIGNMENT_BOTS":     "",
+	}
+	cfg := Default()
+	cfg.Assignment.Bots = []string{"ci"}
+	err := applyEnv(cfg, func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatalf("applyEnv: %v", err)
+	}
+
+	if !cfg.HTTP.StrictJSON || cfg.Database.MaxOpenConns != 7 || cfg.HTTP.MaxBodyBytes != 2048 {
+		t.Errorf("strict %v, max_open_conns %d, max_body_bytes %d", cfg.HTTP.StrictJSON, cfg.Database.MaxOpenConns, cfg.HTTP.MaxBodyBytes)
+	}
+	if cfg.Timeouts.Shutdown.Duration != 45*time.Second {
+		t.Errorf("shutdown = %s", cfg.Timeouts.Shutdown)
+	}
+	wantRoutes := map[string]Duration{"POST /team/add": {2 * time.Second}, "GET /stats": {500 * time.Millisecond}}
+	for route, want := range wantRoutes {
+		if got := cfg.Timeouts.Routes[route]; got != want {
+			t.Errorf("route timeout %s = %s, want %s", route, got, want)
+		}
+	}
+	if got := cfg.RateLimit.Routes["POST /team/deactivateUsers"]; got != (RouteRate{RequestsPerSecond: 0.5, Burst: 4}) {
+		t.Errorf("route rate = %+v", got)
+	}
+	if !reflect.DeepEqual(cfg.RateLimit.APIKeys, []string{"a", "b"}) {
+		t.Errorf("api keys = %q, want [a b]", cfg.RateLimit.APIKeys)
+	}
+	if cfg.Assignment.Bots != nil {
+		t.Errorf("bots = %q, want an empty value to clear them", cfg.Assignment.Bots)
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	for name, value := range map[string]string{
+		"DB_MAX_OPEN_CONNS": "many",
+		"HTTP_STRICT_JSON":  "sometimes",
+		"SHUTDOWN_TIMEOUT":  "45",
+		"ROUTE_TIMEOUTS":    "POST /team/add",
+		"RATE_LIMIT_ROUTES": "POST /x=1",
+	} {
+		err := applyEnv(Default(), func(n string) (string, bool) {
+			return value, n == name
+		})
+		if err == nil || !strings.HasPrefix(err.Error(), "env "+name+":") {
+			t.Errorf("%s=%q: error %v, want one naming the variable", name, value, err)
+		}
+	}
+}
+
+func TestApplyEnvEmptyKeepsValue(t *testing.T) {
+	cfg := Default()
+	err := applyEnv(cfg, func(name string) (string, bool) {
+		return "", name == "DB_MAX_OPEN_CONNS" || name == "SHUTDOWN_TIMEOUT"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def := Default(); cfg.Database.MaxOpenConns != def.Database.MaxOpenConns || cfg.Timeouts.Shutdown != def.Timeouts.Shutdown {
+		t.Errorf("empty env values changed the defaults")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Database.URL = "postgres://app:s3cret@db/reviews"
+	cfg.Outbox.Webhook.URLs = []string{"https://hooks.example.com/services/T0/tok3n", "https://ci.example.com/hook?token=tok3n", "https://plain.example.com"}
+	cfg.Outbox.Webhook.Secret = "tok3n"
+	cfg.Outbox.NATS.URL = "nats://tok3n@nats:4222"
+	cfg.Notify.Slack.WebhookURL = "https://hooks.slack.com/services/tok3n"
+	cfg.Notify.Email.Password = "s3cret"
+	cfg.RateLimit.APIKeys = []string{"tok3n"}
+
+	out := cfg.String()
+	for _, secret := range []string{"s3cret", "tok3n"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("redacted config contains %q:\n%s", secret, out)
+		}
+	}
+	want := []string{"https://hooks.example.com/xxxxx", "https://ci.example.com/xxxxx", "https://plain.example.com"}
+	if got := cfg.Redacted().Outbox.Webhook.URLs; !reflect.DeepEqual(got, want) {
+		t.Errorf("webhook urls = %q, want %q", got, want)
+	}
+	if cfg.Outbox.Webhook.URLs[0] != "https://hooks.example.com/services/T0/tok3n" {
+		t.Error("Redacted changed the original config")
+	}
+}
+
+func TestValidateEscalationAfterSLA(t *testing.T) {
+	for _, tc := range []struct {
+		review, after time.Duration
+		ok            bool
+	}{
+		{8 * time.Hour, 0, true},
+		{8 * time.Hour, 8 * time.Hour, true},
+		{8 * time.Hour, 16 * time.Hour, true},
+		{8 * time.Hour, 4 * time.Hour, false},
+		{0, 4 * time.Hour, false},
+	} {
+		cfg := Default()
+		cfg.Database.URL = "postgres://db/reviews"
+		cfg.SLA.ReviewTime.Duration = tc.review
+		cfg.SLA.Escalation.After.Duration = tc.after
+		if err := cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("review %s, escalation %s: %v, want ok %v", tc.review, tc.after, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+const (
+	AbsenceVacation  = "vacation"
+	AbsenceSickLeave = "sick_leave"
+	AbsencePartTime  = "part_time"
+)
+
+// Absence is a window in which the user must not be picked as a reviewer.
+// With Weekdays set only those days inside the window count, which is how
+// part-time schedules are expressed. A nil EndsAt leaves the window open.
+type Absence struct {
+	ID           int64
+	UserID       string
+	Kind         string
+	StartsAt     time.Time
+	EndsAt       *time.Time
+	Weekdays     []time.Weekday
+	Note         string
+	Source       string
+	ExternalUID  string
+	HandedOverAt *time.Time
+	CreatedAt    time.Time
+}
+
+// ActiveAt reports whether the absence covers t.
+func (a *Absence) ActiveAt(t time.Time) bool {
+	if t.Before(a.StartsAt) || (a.EndsAt != nil && !t.Before(*a.EndsAt)) {
+		return false
+	}
+	if len(a.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range a.Weekdays {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+package domain
+
+// CodeOwnerRule maps a CODEOWNERS-style path pattern to the users who own
+// matching files. Rules of a team are ordered; the last match wins.
+type CodeOwnerRule struct {
+	Pattern  string
+	OwnerIDs []string
+}
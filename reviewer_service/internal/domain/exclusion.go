@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// ReviewerExclusion keeps ExcludedUserID off PRs authored or co-authored by
+// UserID, e.g. a manager and their report. A mutual exclusion also keeps
+// UserID off ExcludedUserID's PRs.
+type ReviewerExclusion struct {
+	UserID         string
+	ExcludedUserID string
+	Mutual         bool
+	Reason         string
+	CreatedAt      time.Time
+}
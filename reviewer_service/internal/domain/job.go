@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+const (
+	JobPending = "pending"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// JobState is the shared state of a periodic background job. Attempts counts
+// consecutive failures of the current run.
+type JobState struct {
+	Name           string
+	NextRunAt      time.Time
+	Attempts       int
+	LastStartedAt  *time.Time
+	LastFinishedAt *time.Time
+	LastSuccessAt  *time.Time
+	LastError      string
+}
+
+// DelayedJob is a one-off job to run once RunAt has passed.
+type DelayedJob struct {
+	ID         int64
+	Name       string
+	Payload    []byte
+	RunAt      time.Time
+	Status     string
+	Attempts   int
+	LastError  string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Notification modes: instant sends every notification as it happens,
+// digest only the daily digest of pending reviews, off nothing.
+const (
+	NotifyInstant = "instant"
+	NotifyDigest  = "digest"
+	NotifyOff     = "off"
+)
+
+// NotificationPreferences is how a user wants to be notified and where.
+// SlackWebhookURL is a personal incoming webhook, e.g. one posting to the
+// user's DM; SlackMemberID is used to mention them on the shared webhook.
+// Emails go to Email unless the user followed the unsubscribe link, which
+// carries UnsubscribeToken.
+type NotificationPreferences struct {
+	UserID            string
+	Username          string
+	Mode              string
+	SlackWebhookURL   string
+	SlackMemberID     string
+	Email             string
+	EmailUnsubscribed bool
+	UnsubscribeToken  string
+}
+
+// DigestRecipient is a digest-mode user with the working hours their
+// digest is timed by.
+type DigestRecipient struct {
+	NotificationPreferences
+	Schedule     WorkSchedule
+	LastDigestAt *time.Time
+}
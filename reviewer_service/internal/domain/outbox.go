@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Aggregates an outbox event can belong to. Events of one aggregate are
+// published in the order they were written.
+const (
+	AggregatePullRequest = "pull_request"
+	AggregateUser        = "user"
+	AggregateTeam        = "team"
+)
+
+// Outbox event types.
+const (
+	EventPRCreated         = "pull_request.created"
+	EventPRMerged          = "pull_request.merged"
+	EventPRDeleted         = "pull_request.deleted"
+	EventPRNeedsAttention  = "pull_request.needs_attention"
+	EventReviewersAssigned = "pull_request.reviewers_assigned"
+	EventReviewerReplaced  = "pull_request.reviewer_replaced"
+	EventReviewerRemoved   = "pull_request.reviewer_removed"
+	EventShadowAssigned    = "pull_request.shadow_assigned"
+
+	EventUserDeactivated = "user.deactivated"
+	EventUserActivated   = "user.activated"
+
+	EventTeamCreated = "team.created"
+	EventTeamChanged = "team.changed"
+	EventTeamDeleted = "team.deleted"
+)
+
+// OutboxEvent is a change waiting to be published. AggregateID is the PR ID,
+// user ID or team ID; Payload is a JSON object with the event's details.
+type OutboxEvent struct {
+	ID            int64
+	Type          string
+	AggregateType string
+	AggregateID   string
+	Payload       []byte
+	CreatedAt     time.Time
+	Attempts      int
+	LastError     string
+	// DeliveredTo names the sinks that have accepted the event.
+	DeliveredTo []string
+	PublishedAt *time.Time
+}
@@ -3,11 +3,91 @@ package domain
 import "time"
 
 type PullRequest struct {
-	ID                string
-	Title             string
-	AuthorID          string
+	ID       string
+	Title    string
+	AuthorID string
+	// CoAuthors wrote the PR together with the author and, like the
+	// author, never review it.
+	CoAuthors []string
+	// TeamID is the team reviewers are drawn from; 0 when the team was deleted.
+	TeamID            int64
+	TeamName          string
 	Status            string
 	AssignedReviewers []string
-	CreatedAt         *time.Time
-	MergedAt          *time.Time
+	// Shadow is the junior following the review, if any. Shadows are not
+	// part of AssignedReviewers and never block a merge.
+	Shadow *ShadowReviewer
+	// Feature is a branch name or feature tag; follow-up PRs with the same
+	// feature prefer the reviewers of earlier ones.
+	Feature string
+	// ChangedFiles are repository paths touched by the PR, used to prefer
+	// code owners as reviewers.
+	ChangedFiles []string
+	// RequiredSkills should each be covered by at least one reviewer;
+	// UnmetSkills lists those that were not when reviewers were picked and,
+	// like Warnings, is not persisted.
+	RequiredSkills []string
+	UnmetSkills    []string
+	CreatedAt      *time.Time
+	MergedAt       *time.Time
+	// NeedsAttention marks PRs whose reviewers could not be maintained
+	// automatically, e.g. after their team was archived or deleted.
+	NeedsAttention  bool
+	AttentionReason string
+	// PendingReviewers is how many reviewers the PR is still queued for.
+	PendingReviewers int
+	// Warnings lists assignment rules that could not be met when the
+	// reviewers were picked. It is not persisted.
+	Warnings []string
+}
+
+// ShadowReviewer pairs a junior with one of the PR's reviewers, the
+// mentor. MentorID is empty when the mentor's account was deleted.
+type ShadowReviewer struct {
+	ReviewerID string
+	MentorID   string
+}
+
+// ReviewClock measures how long a reviewer has had a PR, counting only
+// their working hours.
+type ReviewClock struct {
+	ReviewerID     string
+	StartedAt      time.Time
+	Elapsed        time.Duration
+	DueAt          *time.Time
+	Overdue        bool
+	InWorkingHours bool
+}
+
+const (
+	ReviewerAdded      = "add"
+	ReviewerRemoved    = "remove"
+	ReviewerReassigned = "reassign"
+)
+
+// ReviewerEvent records a manual change of a PR's reviewers. For a
+// reassignment ReviewerID is the new reviewer and PreviousReviewerID the
+// one replaced.
+type ReviewerEvent struct {
+	ID                 int64
+	PullRequestID      string
+	Action             string
+	ReviewerID         string
+	PreviousReviewerID string
+	Actor              string
+	CreatedAt          time.Time
+}
+
+// ReviewAssignment is one reviewer's assignment to an open PR.
+type ReviewAssignment struct {
+	PullRequestID string
+	Title         string
+	AuthorID      string
+	TeamID        int64
+	ReviewerID    string
+	AssignedAt    time.Time
+	// BreachedAt is when the SLA job found the review overdue and
+	// EscalatedAt when it escalated it.
+	BreachedAt  *time.Time
+	EscalatedAt *time.Time
 }
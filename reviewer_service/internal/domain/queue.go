@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// QueueEntry is a PR waiting for Slots more reviewers because every
+// candidate was at capacity.
+type QueueEntry struct {
+	PullRequestID string
+	Slots         int
+	Priority      int
+	EnqueuedAt    time.Time
+}
@@ -0,0 +1,148 @@
+package domain
+
+import "time"
+
+// WorkSchedule is a user's working hours: every listed weekday from
+// StartMinute to EndMinute (minutes after local midnight) in Timezone.
+// Shifts crossing midnight are not supported.
+type WorkSchedule struct {
+	Timezone    string
+	StartMinute int
+	EndMinute   int
+	Weekdays    []time.Weekday
+}
+
+func DefaultWorkSchedule() WorkSchedule {
+	return WorkSchedule{
+		Timezone:    "UTC",
+		StartMinute: 9 * 60,
+		EndMinute:   18 * 60,
+		Weekdays:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+}
+
+// Location falls back to UTC for an empty or unknown timezone.
+func (s WorkSchedule) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (s WorkSchedule) worksOn(d time.Weekday) bool {
+	for _, wd := range s.Weekdays {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// window returns the working interval on the local calendar day of t, if
+// that day is a working day.
+func (s WorkSchedule) window(t time.Time) (time.Time, time.Time, bool) {
+	local := t.In(s.Location())
+	if !s.worksOn(local.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := local.Date()
+	start := time.Date(y, m, d, s.StartMinute/60, s.StartMinute%60, 0, 0, local.Location())
+	end := time.Date(y, m, d, s.EndMinute/60, s.EndMinute%60, 0, 0, local.Location())
+	return start, end, true
+}
+
+// nextDay returns noon of the following local day; noon keeps the step
+// clear of DST transitions.
+func (s WorkSchedule) nextDay(t time.Time) time.Time {
+	local := t.In(s.Location())
+	y, m, d := local.Date()
+	return time.Date(y, m, d+1, 12, 0, 0, 0, local.Location())
+}
+
+// InHours reports whether t falls inside working hours.
+func (s WorkSchedule) InHours(t time.Time) bool {
+	start, end, ok := s.window(t)
+	return ok && !t.Before(start) && t.Before(end)
+}
+
+// DayStart returns the start of working hours on the local calendar day of
+// t, or false if that day is not a working day.
+func (s WorkSchedule) DayStart(t time.Time) (time.Time, bool) {
+	start, _, ok := s.window(t)
+	return start, ok
+}
+
+// NextStart returns t if it is inside working hours, otherwise the start of
+// the next working interval. A schedule without working days returns t.
+func (s WorkSchedule) NextStart(t time.Time) time.Time {
+	if len(s.Weekdays) == 0 || s.EndMinute <= s.StartMinute {
+		return t
+	}
+	day := t
+	for i := 0; i < 8; i++ {
+		start, end, ok := s.window(day)
+		if ok && t.Before(end) {
+			if t.Before(start) {
+				return start
+			}
+			return t
+		}
+		day = s.nextDay(day)
+	}
+	return t
+}
+
+// BusinessDuration is the working time between from and to. A schedule
+// without working days counts wall-clock time.
+func (s WorkSchedule) BusinessDuration(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if len(s.Weekdays) == 0 || s.EndMinute <= s.StartMinute {
+		return to.Sub(from)
+	}
+
+	var total time.Duration
+	for day := from; ; day = s.nextDay(day) {
+		start, end, ok := s.window(day)
+		if ok && !start.After(to) {
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+		if (ok && !end.Before(to)) || day.After(to) {
+			return total
+		}
+	}
+}
+
+// AddBusiness returns the moment d of working time after from.
+func (s WorkSchedule) AddBusiness(from time.Time, d time.Duration) time.Time {
+	if len(s.Weekdays) == 0 || s.EndMinute <= s.StartMinute {
+		return from.Add(d)
+	}
+
+	t := from
+	for day := from; ; day = s.nextDay(day) {
+		start, end, ok := s.window(day)
+		if !ok || !end.After(t) {
+			continue
+		}
+		if start.After(t) {
+			t = start
+		}
+		left := end.Sub(t)
+		if d <= left {
+			return t.Add(d)
+		}
+		d -= left
+		t = end
+	}
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func scheduleIn(timezone string, weekdays ...time.Weekday) WorkSchedule {
+	s := DefaultWorkSchedule()
+	s.Timezone = timezone
+	if len(weekdays) > 0 {
+		s.Weekdays = weekdays
+	}
+	return s
+}
+
+var everyDay = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+func TestBusinessDuration(t *testing.T) {
+	msk := mustLoad(t, "Europe/Moscow")
+	lis := mustLoad(t, "Europe/Lisbon")
+	moscow := scheduleIn("Europe/Moscow")
+	lisbon := scheduleIn("Europe/Lisbon")
+
+	tests := []struct {
+		name     string
+		schedule WorkSchedule
+		from, to time.Time
+		want     time.Duration
+	}{
+		// The same UTC span is a full day in Moscow but starts before
+		// working hours in Lisbon.
+		{"moscow working day", moscow,
+			time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC), 9 * time.Hour},
+		{"lisbon same span", lisbon,
+			time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC), 6 * time.Hour},
+		{"inside one day", moscow,
+			time.Date(2026, 3, 3, 11, 0, 0, 0, msk), time.Date(2026, 3, 3, 13, 30, 0, 0, msk), 150 * time.Minute},
+		{"over a weekend", moscow,
+			time.Date(2026, 3, 6, 16, 0, 0, 0, msk), time.Date(2026, 3, 9, 10, 0, 0, 0, msk), 3 * time.Hour},
+		{"within a weekend", moscow,
+			time.Date(2026, 3, 7, 10, 0, 0, 0, msk), time.Date(2026, 3, 8, 17, 0, 0, 0, msk), 0},
+		{"from after hours", moscow,
+			time.Date(2026, 3, 3, 19, 0, 0, 0, msk), time.Date(2026, 3, 4, 10, 0, 0, 0, msk), time.Hour},
+		{"from after hours on friday", moscow,
+			time.Date(2026, 3, 6, 20, 0, 0, 0, msk), time.Date(2026, 3, 9, 9, 30, 0, 0, msk), 30 * time.Minute},
+		{"to before hours", moscow,
+			time.Date(2026, 3, 3, 17, 0, 0, 0, msk), time.Date(2026, 3, 4, 8, 0, 0, 0, msk), time.Hour},
+		// Lisbon moves to summer time early on Sunday 29 March 2026.
+		{"weekend with dst change", lisbon,
+			time.Date(2026, 3, 27, 17, 0, 0, 0, lis), time.Date(2026, 3, 30, 10, 0, 0, 0, lis), 2 * time.Hour},
+		{"working day after dst change", scheduleIn("Europe/Lisbon", everyDay...),
+			time.Date(2026, 3, 28, 12, 0, 0, 0, lis), time.Date(2026, 3, 29, 12, 0, 0, 0, lis), 9 * time.Hour},
+		{"working day after dst ends", scheduleIn("Europe/Lisbon", everyDay...),
+			time.Date(2026, 10, 24, 12, 0, 0, 0, lis), time.Date(2026, 10, 25, 12, 0, 0, 0, lis), 9 * time.Hour},
+		{"to before from", moscow,
+			time.Date(2026, 3, 4, 10, 0, 0, 0, msk), time.Date(2026, 3, 3, 10, 0, 0, 0, msk), 0},
+		{"no working days", WorkSchedule{Timezone: "UTC"},
+			time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC), 24 * time.Hour},
+	}
+	for _, tc := range tests {
+		if got := tc.schedule.BusinessDuration(tc.from, tc.to); got != tc.want {
+			t.Errorf("%s: BusinessDuration = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAddBusiness(t *testing.T) {
+	msk := mustLoad(t, "Europe/Moscow")
+	lis := mustLoad(t, "Europe/Lisbon")
+	moscow := scheduleIn("Europe/Moscow")
+	lisbon := scheduleIn("Europe/Lisbon")
+
+	tests := []struct {
+		name     string
+		schedule WorkSchedule
+		from     time.Time
+		d        time.Duration
+		want     time.Time
+	}{
+		{"same day", moscow, time.Date(2026, 3, 3, 10, 0, 0, 0, msk), 3 * time.Hour, time.Date(2026, 3, 3, 13, 0, 0, 0, msk)},
+		{"up to the end of the day", moscow, time.Date(2026, 3, 3, 9, 0, 0, 0, msk), 9 * time.Hour, time.Date(2026, 3, 3, 18, 0, 0, 0, msk)},
+		{"into the next day", moscow, time.Date(2026, 3, 3, 17, 0, 0, 0, msk), 2 * time.Hour, time.Date(2026, 3, 4, 10, 0, 0, 0, msk)},
+		{"over a weekend", moscow, time.Date(2026, 3, 6, 17, 0, 0, 0, msk), 2 * time.Hour, time.Date(2026, 3, 9, 10, 0, 0, 0, msk)},
+		{"from after hours", moscow, time.Date(2026, 3, 3, 20, 0, 0, 0, msk), time.Hour, time.Date(2026, 3, 4, 10, 0, 0, 0, msk)},
+		{"from a weekend", moscow, time.Date(2026, 3, 7, 12, 0, 0, 0, msk), time.Hour, time.Date(2026, 3, 9, 10, 0, 0, 0, msk)},
+		// 10:00 summer time on Monday is 09:00 UTC, an hour earlier in UTC
+		// than the 10:00 of the Friday before.
+		{"over dst change", lisbon, time.Date(2026, 3, 27, 17, 0, 0, 0, lis), 2 * time.Hour, time.Date(2026, 3, 30, 9, 0, 0, 0, time.UTC)},
+		// The same moment and SLA end at different instants for each zone.
+		{"moscow pair", moscow, time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC), 2 * time.Hour, time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC)},
+		{"lisbon pair", lisbon, time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC), 2 * time.Hour, time.Date(2026, 3, 3, 16, 0, 0, 0, time.UTC)},
+		{"no working days", WorkSchedule{}, time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), time.Hour, time.Date(2026, 3, 7, 13, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		got := tc.schedule.AddBusiness(tc.from, tc.d)
+		if !got.Equal(tc.want) {
+			t.Errorf("%s: AddBusiness = %s, want %s", tc.name, got, tc.want)
+		}
+		if back := tc.schedule.BusinessDuration(tc.from, got); len(tc.schedule.Weekdays) > 0 && back != tc.d {
+			t.Errorf("%s: BusinessDuration back = %s, want %s", tc.name, back, tc.d)
+		}
+	}
+}
+
+func TestNextStart(t *testing.T) {
+	msk := mustLoad(t, "Europe/Moscow")
+	lis := mustLoad(t, "Europe/Lisbon")
+	moscow := scheduleIn("Europe/Moscow")
+
+	tests := []struct {
+		name     string
+		schedule WorkSchedule
+		t        time.Time
+		want     time.Time
+	}{
+		{"inside hours", moscow, time.Date(2026, 3, 3, 12, 0, 0, 0, msk), time.Date(2026, 3, 3, 12, 0, 0, 0, msk)},
+		{"before hours", moscow, time.Date(2026, 3, 3, 7, 0, 0, 0, msk), time.Date(2026, 3, 3, 9, 0, 0, 0, msk)},
+		{"at the end of the day", moscow, time.Date(2026, 3, 3, 18, 0, 0, 0, msk), time.Date(2026, 3, 4, 9, 0, 0, 0, msk)},
+		{"after hours on friday", moscow, time.Date(2026, 3, 6, 19, 0, 0, 0, msk), time.Date(2026, 3, 9, 9, 0, 0, 0, msk)},
+		{"weekend", moscow, time.Date(2026, 3, 8, 12, 0, 0, 0, msk), time.Date(2026, 3, 9, 9, 0, 0, 0, msk)},
+		{"weekend with dst change", scheduleIn("Europe/Lisbon"), time.Date(2026, 3, 28, 20, 0, 0, 0, lis), time.Date(2026, 3, 30, 8, 0, 0, 0, time.UTC)},
+		// 23:00 UTC on Tuesday is already Wednesday 02:00 in Moscow.
+		{"next local day", moscow, time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC), time.Date(2026, 3, 4, 9, 0, 0, 0, msk)},
+		{"no working days", WorkSchedule{Timezone: "UTC"}, time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		if got := tc.schedule.NextStart(tc.t); !got.Equal(tc.want) {
+			t.Errorf("%s: NextStart = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
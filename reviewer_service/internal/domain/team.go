@@ -1,7 +1,39 @@
 package domain
 
+import "time"
+
 type Team struct {
-	ID      int64
-	Name    string
-	Members []User
+	ID         int64
+	Name       string
+	Members    []User
+	ArchivedAt *time.Time
+	// ParentID is nil for top-level teams.
+	ParentID   *int64
+	ParentName string
+	// SubTeams is only populated when the hierarchy is loaded as a tree.
+	SubTeams []*Team
+	// MaxOpenReviews caps open reviews per member unless the member has a
+	// cap of their own; nil uses the configured default.
+	MaxOpenReviews *int
+	// ReviewSLA and EscalationAfter override the configured review SLA and
+	// escalation threshold for the team's PRs; nil uses the defaults.
+	ReviewSLA       *time.Duration
+	EscalationAfter *time.Duration
+}
+
+func (t *Team) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
+// TeamStats counts PRs that belong to a team and the review assignments on them.
+type TeamStats struct {
+	PullRequests      int
+	OpenPullRequests  int
+	ReviewAssignments int
+}
+
+func (s *TeamStats) Add(other TeamStats) {
+	s.PullRequests += other.PullRequests
+	s.OpenPullRequests += other.OpenPullRequests
+	s.ReviewAssignments += other.ReviewAssignments
 }
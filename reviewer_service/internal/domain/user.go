@@ -1,9 +1,47 @@
 package domain
 
+import "time"
+
+// User carries the user's primary team in TeamID and TeamName. Other team
+// memberships are loaded separately as Membership values.
 type User struct {
 	ID       string
 	Username string
 	IsActive bool
 	TeamID   int64
 	TeamName string
+	// Role is the user's role in the team they were loaded through.
+	Role     string
+	Schedule WorkSchedule
+	// Skills are lower-case tags such as "go" or "postgres".
+	Skills []string
+	// MaxOpenReviews is the user's cap, else that of the team they were
+	// loaded through; nil falls back to the configured default, 0 is
+	// unlimited.
+	MaxOpenReviews *int
+	// ShadowOptIn makes a junior eligible for shadow reviews.
+	ShadowOptIn bool
+}
+
+type Membership struct {
+	UserID    string
+	TeamID    int64
+	TeamName  string
+	IsPrimary bool
+	Role      string
+	JoinedAt  time.Time
+}
+
+// TeamMove is a history record of a user changing teams.
+type TeamMove struct {
+	ID                int64
+	UserID            string
+	FromTeamID        *int64
+	FromTeamName      string
+	ToTeamID          int64
+	ToTeamName        string
+	Source            string
+	OpenReviewsPolicy string
+	ReassignedReviews int
+	MovedAt           time.Time
 }
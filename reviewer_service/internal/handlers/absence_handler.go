@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/service"
+	"strings"
+	"time"
+)
+
+type AddAbsenceRequest struct {
+	UserID string `json:"user_id"`
+	Kind   string `json:"kind"`
+	// StartsAt and EndsAt accept RFC 3339 timestamps or YYYY-MM-DD dates.
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+	// Weekdays lists days off for part_time, e.g. ["fri"].
+	Weekdays []string `json:"weekdays"`
+	Note     string   `json:"note"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseWeekdays(names []string) ([]time.Weekday, bool) {
+	var days []time.Weekday
+	for _, name := range names {
+		day, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, false
+		}
+		days = append(days, day)
+	}
+	return days, true
+}
+
+func weekdayList(days []time.Weekday) []string {
+	names := make([]string, 0, len(days))
+	for _, d := range days {
+		names = append(names, strings.ToLower(d.String()[:3]))
+	}
+	return names
+}
+
+func parseDateTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func AddAbsenceHandler(absenceService *service.AbsenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddAbsenceRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.Kind == "" || req.StartsAt == "" {
+			http.Error(w, "user_id, kind and starts_at are required", http.StatusBadRequest)
+			return
+		}
+
+		absence := &domain.Absence{UserID: req.UserID, Kind: req.Kind, Note: req.Note}
+		var err error
+		if absence.StartsAt, err = parseDateTime(req.StartsAt); err != nil {
+			http.Error(w, "starts_at must be RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if req.EndsAt != "" {
+			endsAt, err := parseDateTime(req.EndsAt)
+			if err != nil {
+				http.Error(w, "ends_at must be RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			absence.EndsAt = &endsAt
+		}
+		days, ok := parseWeekdays(req.Weekdays)
+		if !ok {
+			http.Error(w, "weekdays must be sun, mon, tue, wed, thu, fri or sat", http.StatusBadRequest)
+			return
+		}
+		absence.Weekdays = days
+
+		if err := absenceService.AddAbsence(r.Context(), absence); err != nil {
+			writeAbsenceError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"absence": absenceResponse(*absence),
+		})
+	}
+}
+
+func ListAbsencesHandler(absenceService *service.AbsenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		absences, available, err := absenceService.ListAbsences(r.Context(), userID)
+		if err != nil {
+			writeAbsenceError(w, err)
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(absences))
+		for _, a := range absences {
+			list = append(list, absenceResponse(a))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":      userID,
+			"is_available": available,
+			"absences":     list,
+		})
+	}
+}
+
+type DeleteAbsenceRequest struct {
+	UserID    string `json:"user_id"`
+	AbsenceID int64  `json:"absence_id"`
+}
+
+func DeleteAbsenceHandler(absenceService *service.AbsenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeleteAbsenceRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.AbsenceID == 0 {
+			http.Error(w, "user_id and absence_id are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := absenceService.DeleteAbsence(r.Context(), req.UserID, req.AbsenceID); err != nil {
+			writeAbsenceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	}
+}
+
+// ImportAbsencesHandler takes a raw iCalendar body (text/calendar) and the
+// user in the user_id query parameter.
+func ImportAbsencesHandler(absenceService *service.AbsenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		result, err := absenceService.ImportICS(r.Context(), userID, bytes.NewReader(body))
+		if err != nil {
+			writeAbsenceError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id": userID,
+			"created": result.Created,
+			"updated": result.Updated,
+			"removed": result.Removed,
+			"skipped": result.Skipped,
+		})
+	}
+}
+
+func absenceResponse(a domain.Absence) map[string]interface{} {
+	resp := map[string]interface{}{
+		"absence_id": a.ID,
+		"kind":       a.Kind,
+		"starts_at":  a.StartsAt,
+		"ends_at":    a.EndsAt,
+		"weekdays":   weekdayList(a.Weekdays),
+		"note":       a.Note,
+		"source":     a.Source,
+	}
+	if a.HandedOverAt != nil {
+		resp["handed_over_at"] = a.HandedOverAt
+	}
+	return resp
+}
+
+func writeAbsenceError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.AbsenceNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "absence not found")
+	case service.InvalidAbsenceError:
+		writeError(w, http.StatusBadRequest, "INVALID_ABSENCE", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+)
+
+type SetUserCapacityRequest struct {
+	UserID string `json:"user_id"`
+	// MaxOpenReviews is null to inherit the team's cap; 0 means unlimited.
+	MaxOpenReviews *int `json:"max_open_reviews"`
+}
+
+func SetUserCapacityHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetUserCapacityRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.SetMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+		if err != nil {
+			writeCapacityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id": user.ID,
+			// The effective cap after falling back to the primary team's.
+			"max_open_reviews": user.MaxOpenReviews,
+		})
+	}
+}
+
+type SetTeamCapacityRequest struct {
+	TeamName string `json:"team_name"`
+	// MaxOpenReviews is null to use the configured default; 0 means unlimited.
+	MaxOpenReviews *int `json:"max_open_reviews"`
+}
+
+func SetTeamCapacityHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetTeamCapacityRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		team, err := teamService.SetMaxOpenReviews(r.Context(), req.TeamName, req.MaxOpenReviews)
+		if err != nil {
+			writeCapacityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team_name":        team.Name,
+			"max_open_reviews": team.MaxOpenReviews,
+		})
+	}
+}
+
+func GetReviewQueueHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := prService.ListQueue(r.Context())
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(entries))
+		for _, e := range entries {
+			list = append(list, map[string]interface{}{
+				"pull_request_id":   e.PullRequestID,
+				"pending_reviewers": e.Slots,
+				"priority":          e.Priority,
+				"enqueued_at":       e.EnqueuedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"queue": list})
+	}
+}
+
+func writeCapacityError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.TeamNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+	case service.InvalidCapacityError:
+		writeError(w, http.StatusBadRequest, "INVALID_CAPACITY", err.Error())
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"reviewer_service/internal/codeowners"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/service"
+)
+
+type CodeOwnerRuleDTO struct {
+	Pattern string `json:"pattern"`
+	// Owners are user IDs or usernames, optionally prefixed with "@".
+	Owners []string `json:"owners"`
+}
+
+type SetCodeOwnersRequest struct {
+	TeamName string             `json:"team_name"`
+	Rules    []CodeOwnerRuleDTO `json:"rules"`
+}
+
+func SetCodeOwnersHandler(codeOwnersService *service.CodeOwnersService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetCodeOwnersRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		rules := make([]codeowners.Rule, 0, len(req.Rules))
+		for _, rule := range req.Rules {
+			rules = append(rules, codeowners.Rule{Pattern: rule.Pattern, Owners: rule.Owners})
+		}
+
+		result, err := codeOwnersService.SetRules(r.Context(), req.TeamName, rules)
+		if err != nil {
+			writeCodeOwnersError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, codeOwnersResponse(result.TeamName, result.Rules, result.UnknownOwners, result.SkippedPatterns))
+	}
+}
+
+// UploadCodeOwnersHandler takes a raw CODEOWNERS file and the team in the
+// team_name query parameter.
+func UploadCodeOwnersHandler(codeOwnersService *service.CodeOwnersService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamName := r.URL.Query().Get("team_name")
+		if teamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		result, err := codeOwnersService.ImportFile(r.Context(), teamName, bytes.NewReader(body))
+		if err != nil {
+			writeCodeOwnersError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, codeOwnersResponse(result.TeamName, result.Rules, result.UnknownOwners, result.SkippedPatterns))
+	}
+}
+
+func GetCodeOwnersHandler(codeOwnersService *service.CodeOwnersService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamName := r.URL.Query().Get("team_name")
+		if teamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		rules, err := codeOwnersService.GetRules(r.Context(), teamName)
+		if err != nil {
+			writeCodeOwnersError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, codeOwnersResponse(teamName, rules, nil, nil))
+	}
+}
+
+func codeOwnersResponse(teamName string, rules []domain.CodeOwnerRule, unknown, skipped []string) map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, map[string]interface{}{
+			"pattern": rule.Pattern,
+			"owners":  nonNil(rule.OwnerIDs),
+		})
+	}
+	resp := map[string]interface{}{
+		"team_name": teamName,
+		"rules":     list,
+	}
+	if len(unknown) > 0 {
+		resp["unknown_owners"] = unknown
+	}
+	if len(skipped) > 0 {
+		resp["skipped_patterns"] = skipped
+	}
+	return resp
+}
+
+func writeCodeOwnersError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.TeamNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+	case service.InvalidCodeOwnersError:
+		writeError(w, http.StatusBadRequest, "INVALID_CODEOWNERS", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
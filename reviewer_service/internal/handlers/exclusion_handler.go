@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/service"
+)
+
+type AddExclusionRequest struct {
+	// UserID is the author whose PRs ExcludedUserID must not review.
+	UserID         string `json:"user_id"`
+	ExcludedUserID string `json:"excluded_user_id"`
+	// Mutual also keeps UserID off ExcludedUserID's PRs.
+	Mutual bool   `json:"mutual"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func AddExclusionHandler(exclusionService *service.ExclusionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddExclusionRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.ExcludedUserID == "" {
+			http.Error(w, "user_id and excluded_user_id are required", http.StatusBadRequest)
+			return
+		}
+
+		exclusion := &domain.ReviewerExclusion{
+			UserID:         req.UserID,
+			ExcludedUserID: req.ExcludedUserID,
+			Mutual:         req.Mutual,
+			Reason:         req.Reason,
+		}
+		if err := exclusionService.AddExclusion(r.Context(), exclusion); err != nil {
+			writeExclusionError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"exclusion": map[string]interface{}{
+				"user_id":          exclusion.UserID,
+				"excluded_user_id": exclusion.ExcludedUserID,
+				"mutual":           exclusion.Mutual,
+				"reason":           exclusion.Reason,
+			},
+		})
+	}
+}
+
+type DeleteExclusionRequest struct {
+	UserID         string `json:"user_id"`
+	ExcludedUserID string `json:"excluded_user_id"`
+}
+
+func DeleteExclusionHandler(exclusionService *service.ExclusionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeleteExclusionRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.ExcludedUserID == "" {
+			http.Error(w, "user_id and excluded_user_id are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := exclusionService.RemoveExclusion(r.Context(), req.UserID, req.ExcludedUserID); err != nil {
+			writeExclusionError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	}
+}
+
+func ListExclusionsHandler(exclusionService *service.ExclusionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		exclusions, err := exclusionService.ListExclusions(r.Context(), userID)
+		if err != nil {
+			writeExclusionError(w, err)
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(exclusions))
+		for _, e := range exclusions {
+			list = append(list, map[string]interface{}{
+				"user_id":          e.UserID,
+				"excluded_user_id": e.ExcludedUserID,
+				"mutual":           e.Mutual,
+				"reason":           e.Reason,
+				"created_at":       e.CreatedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":    userID,
+			"exclusions": list,
+		})
+	}
+}
+
+func writeExclusionError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.ExclusionNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "exclusion not found")
+	case service.InvalidExclusionError:
+		writeError(w, http.StatusBadRequest, "INVALID_EXCLUSION", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
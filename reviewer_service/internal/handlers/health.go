@@ -3,18 +3,59 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"reviewer_service/internal/buildinfo"
+	"reviewer_service/internal/health"
 	"time"
 )
 
 func HealthHandler(w http.ResponseWriter, _ *http.Request) {
+	info := buildinfo.Get()
 	response := map[string]interface{}{
-		"status":    "ok",
+		"status":     "ok",
+		"timestamp":  time.Now().UTC(),
+		"service":    "reviewer_service",
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"build_time": info.BuildTime,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// LivezHandler only reports that the process is serving requests; it never
+// touches dependencies, so a database outage does not get the pod restarted.
+func LivezHandler(w http.ResponseWriter, _ *http.Request) {
+	response := map[string]interface{}{
+		"status":    health.StatusUp,
 		"timestamp": time.Now().UTC(),
-		"service":   "reviewer_service",
-		"version":   "1.0.0",
+		"build":     buildinfo.Get(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
 }
+
+func ReadyzHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Run(r.Context())
+
+		response := map[string]interface{}{
+			"status":    report.Status,
+			"timestamp": time.Now().UTC(),
+			"build":     buildinfo.Get(),
+			"checks":    report.Checks,
+		}
+
+		code := http.StatusOK
+		if report.Status != health.StatusUp {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+)
+
+type ReviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	// Actor identifies who made the change: a user ID or a configured bot
+	// name. It is reported by the caller, not authenticated.
+	Actor string `json:"actor"`
+}
+
+func AddReviewerHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewerChangeRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.PullRequestID == "" || req.UserID == "" || req.Actor == "" {
+			http.Error(w, "pull_request_id, user_id and actor are required", http.StatusBadRequest)
+			return
+		}
+
+		pr, err := prService.AddReviewer(r.Context(), req.PullRequestID, req.UserID, req.Actor)
+		if err != nil {
+			writeReviewerChangeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"pr": prResponse(pr)})
+	}
+}
+
+func RemoveReviewerHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewerChangeRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.PullRequestID == "" || req.UserID == "" || req.Actor == "" {
+			http.Error(w, "pull_request_id, user_id and actor are required", http.StatusBadRequest)
+			return
+		}
+
+		pr, err := prService.RemoveReviewer(r.Context(), req.PullRequestID, req.UserID, req.Actor)
+		if err != nil {
+			writeReviewerChangeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"pr": prResponse(pr)})
+	}
+}
+
+type ReassignToRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_user_id"`
+	NewReviewerID string `json:"new_user_id"`
+	Actor         string `json:"actor"`
+}
+
+func ReassignToHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReassignToRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.PullRequestID == "" || req.OldReviewerID == "" || req.NewReviewerID == "" || req.Actor == "" {
+			http.Error(w, "pull_request_id, old_user_id, new_user_id and actor are required", http.StatusBadRequest)
+			return
+		}
+
+		pr, err := prService.ReassignTo(r.Context(), req.PullRequestID, req.OldReviewerID, req.NewReviewerID, req.Actor)
+		if err != nil {
+			writeReviewerChangeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"pr":          prResponse(pr),
+			"replaced_by": req.NewReviewerID,
+		})
+	}
+}
+
+func GetReviewerEventsHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prID := r.URL.Query().Get("pull_request_id")
+		if prID == "" {
+			http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+			return
+		}
+
+		events, err := prService.GetReviewerEvents(r.Context(), prID)
+		if err != nil {
+			writeReviewerChangeError(w, err)
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(events))
+		for _, e := range events {
+			event := map[string]interface{}{
+				"action":     e.Action,
+				"user_id":    e.ReviewerID,
+				"actor":      e.Actor,
+				"created_at": e.CreatedAt,
+			}
+			if e.PreviousReviewerID != "" {
+				event["old_user_id"] = e.PreviousReviewerID
+			}
+			list = append(list, event)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"pull_request_id": prID,
+			"events":          list,
+		})
+	}
+}
+
+func writeReviewerChangeError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.AuthorNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.PRMergedError:
+		writeError(w, http.StatusConflict, "PR_MERGED", "cannot change reviewers on merged PR")
+	case service.NotAssignedError:
+		writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+	case service.InvalidActorError:
+		writeError(w, http.StatusBadRequest, "INVALID_ACTOR", e.Error())
+	case service.InvalidReviewerError:
+		writeError(w, http.StatusConflict, e.Code, e.Error())
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
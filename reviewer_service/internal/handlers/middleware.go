@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
@@ -16,3 +17,31 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		log.Printf("← %s %s (%v)", r.Method, r.URL.Path, time.Since(start))
 	})
 }
+
+func TimeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type strictJSONKey struct{}
+
+// BodyLimitMiddleware caps request bodies at maxBytes and, in strict mode,
+// makes decodeJSON reject fields the request type does not declare.
+func BodyLimitMiddleware(maxBytes int64, strict bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		}
+		if strict {
+			r = r.WithContext(context.WithValue(r.Context(), strictJSONKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
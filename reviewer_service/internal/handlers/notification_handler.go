@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/service"
+)
+
+type SetNotificationPreferencesRequest struct {
+	UserID string `json:"user_id"`
+	// Mode is instant, digest or off.
+	Mode            string `json:"mode"`
+	SlackWebhookURL string `json:"slack_webhook_url"`
+	SlackMemberID   string `json:"slack_member_id"`
+}
+
+func SetNotificationPreferencesHandler(notificationService *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetNotificationPreferencesRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.Mode == "" {
+			http.Error(w, "user_id and mode are required", http.StatusBadRequest)
+			return
+		}
+
+		prefs, err := notificationService.SetPreferences(r.Context(), domain.NotificationPreferences{
+			UserID:          req.UserID,
+			Mode:            req.Mode,
+			SlackWebhookURL: req.SlackWebhookURL,
+			SlackMemberID:   req.SlackMemberID,
+		})
+		if err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, notificationPreferencesResponse(prefs))
+	}
+}
+
+func GetNotificationPreferencesHandler(notificationService *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		prefs, err := notificationService.GetPreferences(r.Context(), userID)
+		if err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, notificationPreferencesResponse(prefs))
+	}
+}
+
+// notificationPreferencesResponse leaves out the personal webhook URL, as
+// it is a credential; slack_webhook_set tells whether one is stored.
+func notificationPreferencesResponse(prefs *domain.NotificationPreferences) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":            prefs.UserID,
+		"mode":               prefs.Mode,
+		"slack_webhook_set":  prefs.SlackWebhookURL != "",
+		"slack_member_id":    prefs.SlackMemberID,
+		"email":              prefs.Email,
+		"email_unsubscribed": prefs.EmailUnsubscribed,
+	}
+}
+
+type SetEmailRequest struct {
+	UserID string `json:"user_id"`
+	// Email is empty to stop emails.
+	Email string `json:"email"`
+}
+
+func SetEmailHandler(notificationService *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetEmailRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		prefs, err := notificationService.SetEmail(r.Context(), req.UserID, req.Email)
+		if err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, notificationPreferencesResponse(prefs))
+	}
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<p>Stop receiving review emails?</p>
+<form method="post" action="?token={{.}}">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// UnsubscribeConfirmHandler serves the unsubscribe link of emails when it
+// is opened. It only asks for confirmation: link scanners and prefetching
+// mail clients open links too, so a GET must not unsubscribe.
+func UnsubscribeConfirmHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "token is required", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		unsubscribePage.Execute(w, token)
+	}
+}
+
+// UnsubscribeHandler unsubscribes the holder of the link's token, posted
+// from the confirmation page or by a mail client as an RFC 8058 one-click
+// unsubscribe.
+func UnsubscribeHandler(notificationService *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "token is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := notificationService.Unsubscribe(r.Context(), token); err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"unsubscribed": true})
+	}
+}
+
+func writeNotificationError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.UnsubscribeTokenNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unsubscribe link is invalid")
+	case service.InvalidNotificationPreferencesError:
+		writeError(w, http.StatusBadRequest, "INVALID_PREFERENCES", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
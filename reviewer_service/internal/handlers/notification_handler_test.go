@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"reviewer_service/internal/domain"
+)
+
+func TestUnsubscribeConfirmPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	UnsubscribeConfirmHandler()(w, httptest.NewRequest(http.MethodGet, "/users/unsubscribe?token=abc123", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("content type %q, want HTML", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `method="post"`) || !strings.Contains(body, `action="?token=abc123"`) {
+		t.Errorf("page does not post the token back:\n%s", body)
+	}
+}
+
+func TestUnsubscribeConfirmPageEscapesToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	UnsubscribeConfirmHandler()(w, httptest.NewRequest(http.MethodGet, `/users/unsubscribe?token=%22%3E%3Cscript%3E`, nil))
+
+	if strings.Contains(w.Body.String(), "<script>") {
+		t.Errorf("token is not escaped:\n%s", w.Body.String())
+	}
+}
+
+func TestUnsubscribeConfirmPageRequiresToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	UnsubscribeConfirmHandler()(w, httptest.NewRequest(http.MethodGet, "/users/unsubscribe", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNotificationPreferencesResponseHidesWebhook(t *testing.T) {
+	resp := notificationPreferencesResponse(&domain.NotificationPreferences{UserID: "u1", Mode: domain.NotifyInstant, SlackWebhookURL: "https://hooks.slack.com/services/T0/B0/secret"})
+
+	body, _ := json.Marshal(resp)
+	if strings.Contains(string(body), "secret") {
+		t.Errorf("response leaks the webhook URL: %s", body)
+	}
+	if resp["slack_webhook_set"] != true {
+		t.Errorf("slack_webhook_set = %v, want true", resp["slack_webhook_set"])
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"reviewer_service/internal/domain"
 	"reviewer_service/internal/service"
 )
 
@@ -10,20 +11,43 @@ type CreatePRRequest struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// CoAuthors are user IDs excluded from review like the author.
+	CoAuthors []string `json:"co_authors,omitempty"`
+	// TeamName defaults to the author's primary team.
+	TeamName string `json:"team_name,omitempty"`
+	// Feature is a branch name or feature tag; follow-up PRs with the same
+	// feature go back to earlier reviewers.
+	Feature string `json:"feature,omitempty"`
+	// ChangedFiles are repository paths; code owners of them are preferred.
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	// RequiredSkills are tags such as "go" or "postgres" that at least one
+	// reviewer should have.
+	RequiredSkills []string `json:"required_skills,omitempty"`
+	// Priority orders the review queue when reviewers are at capacity.
+	Priority int `json:"priority,omitempty"`
 }
 
 func CreatePullRequestHandler(prService *service.PullRequestService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreatePRRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
-		pr, err := prService.CreatePullRequest(req.PullRequestID, req.PullRequestName, req.AuthorID)
+		pr, err := prService.CreatePullRequest(r.Context(), service.NewPullRequest{
+			ID:             req.PullRequestID,
+			Name:           req.PullRequestName,
+			AuthorID:       req.AuthorID,
+			CoAuthors:      req.CoAuthors,
+			TeamName:       req.TeamName,
+			Feature:        req.Feature,
+			ChangedFiles:   req.ChangedFiles,
+			RequiredSkills: req.RequiredSkills,
+			Priority:       req.Priority,
+		})
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
-			switch err.(type) {
+			switch e := err.(type) {
 			case service.PullRequestExistsError:
 				w.WriteHeader(http.StatusConflict)
 				if encodeErr := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -48,6 +72,21 @@ func CreatePullRequestHandler(prService *service.PullRequestService) http.Handle
 					return
 				}
 				return
+			case service.TeamNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+				return
+			case service.CoAuthorNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", e.Error())
+				return
+			case service.AuthorNotInTeamError:
+				writeError(w, http.StatusBadRequest, "NOT_TEAM_MEMBER", "author is not a member of team_name")
+				return
+			case service.AssignmentRuleError:
+				writeError(w, http.StatusConflict, e.Code, e.Error())
+				return
+			case service.InvalidSkillError:
+				writeError(w, http.StatusBadRequest, "INVALID_SKILL", e.Error())
+				return
 			default:
 				http.Error(w, "Internal error", http.StatusInternalServerError)
 				return
@@ -55,15 +94,7 @@ func CreatePullRequestHandler(prService *service.PullRequestService) http.Handle
 		}
 
 		response := map[string]interface{}{
-			"pr": map[string]interface{}{
-				"pull_request_id":    pr.ID,
-				"pull_request_name":  pr.Title,
-				"author_id":          pr.AuthorID,
-				"status":             pr.Status,
-				"assigned_reviewers": pr.AssignedReviewers,
-				"createdAt":          pr.CreatedAt,
-				"mergedAt":           pr.MergedAt,
-			},
+			"pr": prResponse(pr),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -82,12 +113,11 @@ type MergePRRequest struct {
 func MergePullRequestHandler(prService *service.PullRequestService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req MergePRRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
-		pr, err := prService.MergePullRequest(req.PullRequestID)
+		pr, err := prService.MergePullRequest(r.Context(), req.PullRequestID)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusNotFound)
@@ -104,15 +134,7 @@ func MergePullRequestHandler(prService *service.PullRequestService) http.Handler
 		}
 
 		response := map[string]interface{}{
-			"pr": map[string]interface{}{
-				"pull_request_id":    pr.ID,
-				"pull_request_name":  pr.Title,
-				"author_id":          pr.AuthorID,
-				"status":             pr.Status,
-				"assigned_reviewers": pr.AssignedReviewers,
-				"createdAt":          pr.CreatedAt,
-				"mergedAt":           pr.MergedAt,
-			},
+			"pr": prResponse(pr),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -132,17 +154,24 @@ type ReassignRequest struct {
 func ReassignReviewerHandler(prService *service.PullRequestService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req ReassignRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
-		newID, pr, err := prService.ReassignReviewer(req.PullRequestID, req.OldReviewerID)
+		newID, pr, err := prService.ReassignReviewer(r.Context(), req.PullRequestID, req.OldReviewerID)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			var code int
 			var errBody map[string]interface{}
-			switch err.(type) {
+			switch e := err.(type) {
+			case service.AssignmentRuleError:
+				code = http.StatusConflict
+				errBody = map[string]interface{}{
+					"error": map[string]string{
+						"code":    e.Code,
+						"message": e.Error(),
+					},
+				}
 			case service.PRMergedError:
 				code = http.StatusConflict
 				errBody = map[string]interface{}{
@@ -188,15 +217,7 @@ func ReassignReviewerHandler(prService *service.PullRequestService) http.Handler
 		}
 
 		response := map[string]interface{}{
-			"pr": map[string]interface{}{
-				"pull_request_id":    pr.ID,
-				"pull_request_name":  pr.Title,
-				"author_id":          pr.AuthorID,
-				"status":             pr.Status,
-				"assigned_reviewers": pr.AssignedReviewers,
-				"createdAt":          pr.CreatedAt,
-				"mergedAt":           pr.MergedAt,
-			},
+			"pr":          prResponse(pr),
 			"replaced_by": newID,
 		}
 
@@ -208,3 +229,51 @@ func ReassignReviewerHandler(prService *service.PullRequestService) http.Handler
 		}
 	}
 }
+
+func prResponse(pr *domain.PullRequest) map[string]interface{} {
+	resp := map[string]interface{}{
+		"pull_request_id":    pr.ID,
+		"pull_request_name":  pr.Title,
+		"author_id":          pr.AuthorID,
+		"team_name":          pr.TeamName,
+		"status":             pr.Status,
+		"assigned_reviewers": pr.AssignedReviewers,
+		"createdAt":          pr.CreatedAt,
+		"mergedAt":           pr.MergedAt,
+	}
+	if pr.Shadow != nil {
+		// Shadows are listed with the reviewers; reviewer_roles tells them apart.
+		roles := make(map[string]string, len(pr.AssignedReviewers)+1)
+		for _, id := range pr.AssignedReviewers {
+			roles[id] = "primary"
+		}
+		roles[pr.Shadow.ReviewerID] = "shadow"
+		resp["assigned_reviewers"] = append(append([]string{}, pr.AssignedReviewers...), pr.Shadow.ReviewerID)
+		resp["reviewer_roles"] = roles
+		resp["shadow_mentor_id"] = pr.Shadow.MentorID
+	}
+	if pr.NeedsAttention {
+		resp["needs_attention"] = true
+		resp["attention_reason"] = pr.AttentionReason
+	}
+	if pr.Feature != "" {
+		resp["feature"] = pr.Feature
+	}
+	if len(pr.CoAuthors) > 0 {
+		resp["co_authors"] = pr.CoAuthors
+	}
+	if len(pr.ChangedFiles) > 0 {
+		resp["changed_files"] = pr.ChangedFiles
+	}
+	if len(pr.RequiredSkills) > 0 {
+		resp["required_skills"] = pr.RequiredSkills
+		resp["unmet_skills"] = nonNil(pr.UnmetSkills)
+	}
+	if pr.PendingReviewers > 0 {
+		resp["pending_reviewers"] = pr.PendingReviewers
+	}
+	if len(pr.Warnings) > 0 {
+		resp["warnings"] = pr.Warnings
+	}
+	return resp
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+type RateLimiterOptions struct {
+	Default RateLimit
+	// Routes overrides the default for individual mux patterns such as
+	// "POST /team/deactivateUsers".
+	Routes       map[string]RateLimit
+	APIKeyHeader string
+	// APIKeys are the keys that identify a client; any other key is
+	// ignored so a client cannot get a fresh bucket by changing it.
+	APIKeys    []string
+	TrustProxy bool
+	// IdleTTL is how long an unused bucket is kept before being evicted.
+	IdleTTL time.Duration
+}
+
+// RateLimiter keeps one token bucket per route and client. A client is
+// identified by its API key when the request carries a known one,
+// otherwise by IP.
+type RateLimiter struct {
+	opts      RateLimiterOptions
+	apiKeys   map[string]bool
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+func NewRateLimiter(opts RateLimiterOptions) *RateLimiter {
+	if opts.APIKeyHeader == "" {
+		opts.APIKeyHeader = "X-API-Key"
+	}
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = 10 * time.Minute
+	}
+	apiKeys := make(map[string]bool, len(opts.APIKeys))
+	for _, key := range opts.APIKeys {
+		apiKeys[key] = true
+	}
+	return &RateLimiter{opts: opts, apiKeys: apiKeys, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (l *RateLimiter) limitFor(pattern string) RateLimit {
+	if rl, ok := l.opts.Routes[pattern]; ok {
+		return rl
+	}
+	return l.opts.Default
+}
+
+// Middleware limits requests to the route registered under pattern.
+func (l *RateLimiter) Middleware(pattern string, next http.Handler) http.Handler {
+	limit := l.limitFor(pattern)
+	if limit.RequestsPerSecond <= 0 || limit.Burst <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, retryAfter := l.take(pattern+"|"+l.clientKey(r), limit)
+
+		resetSeconds := math.Ceil(float64(limit.Burst-remaining) / limit.RequestsPerSecond)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(resetSeconds)))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "RATE_LIMITED", "too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *RateLimiter) take(key string, limit RateLimit) (allowed bool, remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.RequestsPerSecond)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / limit.RequestsPerSecond
+		return false, 0, time.Duration(wait * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// sweep drops idle buckets at most once per IdleTTL so memory stays bounded
+// by the number of recently active clients.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.opts.IdleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.opts.IdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if key := r.Header.Get(l.opts.APIKeyHeader); key != "" && l.apiKeys[key] {
+		return "key:" + key
+	}
+	return "ip:" + clientIP(r, l.opts.TrustProxy)
+}
+
+// clientIP takes the rightmost X-Forwarded-For entry when the proxy is
+// trusted: that is the address the proxy itself appended, while entries to
+// its left come from the client and can be forged.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			last := fwd[len(fwd)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+		if real := r.Header.Get("X-Real-IP"); real != "" {
+			return real
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
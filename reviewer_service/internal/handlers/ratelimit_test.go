@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(keys ...string) (*RateLimiter, http.Handler) {
+	l := NewRateLimiter(RateLimiterOptions{
+		Default: RateLimit{RequestsPerSecond: 1, Burst: 2},
+		APIKeys: keys,
+	})
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	h := l.Middleware("GET /x", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return l, h
+}
+
+func doRequest(h http.Handler, remoteAddr, key string) int {
+	r := httptest.NewRequest(http.MethodGet, "/x", nil)
+	r.RemoteAddr = remoteAddr
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRateLimiterUnknownKeysDoNotResetLimit(t *testing.T) {
+	_, h := newTestLimiter("known")
+
+	for i := 0; i < 2; i++ {
+		if code := doRequest(h, "10.0.0.1:1234", "random-"+strconv.Itoa(i)); code != http.StatusNoContent {
+			t.Fatalf("request %d: status %d, want %d", i, code, http.StatusNoContent)
+		}
+	}
+	if code := doRequest(h, "10.0.0.1:1234", "random-2"); code != http.StatusTooManyRequests {
+		t.Fatalf("new unknown key: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := doRequest(h, "10.0.0.1:1234", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("no key: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiterKnownKeyHasOwnBucket(t *testing.T) {
+	_, h := newTestLimiter("known")
+
+	for i := 0; i < 2; i++ {
+		doRequest(h, "10.0.0.1:1234", "")
+	}
+	if code := doRequest(h, "10.0.0.1:1234", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("IP bucket: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := doRequest(h, "10.0.0.1:1234", "known"); code != http.StatusNoContent {
+		t.Fatalf("known key: status %d, want %d", code, http.StatusNoContent)
+	}
+}
+
+func TestRateLimiterClientKey(t *testing.T) {
+	l, _ := newTestLimiter("known")
+
+	for _, tc := range []struct {
+		key  string
+		want string
+	}{
+		{"known", "key:known"},
+		{"unknown", "ip:10.0.0.1"},
+		{"", "ip:10.0.0.1"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/x", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		if tc.key != "" {
+			r.Header.Set("X-API-Key", tc.key)
+		}
+		if got := l.clientKey(r); got != tc.want {
+			t.Errorf("clientKey with %q = %q, want %q", tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestRateLimiterIgnoresSpoofedForwardedFor(t *testing.T) {
+	l := NewRateLimiter(RateLimiterOptions{TrustProxy: true})
+
+	for _, tc := range []struct {
+		fwd  []string
+		want string
+	}{
+		{[]string{"203.0.113.7"}, "ip:203.0.113.7"},
+		{[]string{"1.2.3.4, 203.0.113.7"}, "ip:203.0.113.7"},
+		{[]string{"5.6.7.8", "203.0.113.7"}, "ip:203.0.113.7"},
+		{nil, "ip:10.0.0.1"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/x", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		for _, v := range tc.fwd {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := l.clientKey(r); got != tc.want {
+			t.Errorf("clientKey with X-Forwarded-For %q = %q, want %q", tc.fwd, got, tc.want)
+		}
+	}
+}
+
+func TestRateLimiterSpoofedForwardedForSharesBucket(t *testing.T) {
+	l := NewRateLimiter(RateLimiterOptions{Default: RateLimit{RequestsPerSecond: 1, Burst: 2}, TrustProxy: true})
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	h := l.Middleware("GET /x", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/x", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i)+", 203.0.113.7")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuses %v, want the third request limited", codes)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+	"time"
+)
+
+type SetTeamSLARequest struct {
+	TeamName string `json:"team_name"`
+	// ReviewSLA and EscalationAfter are durations such as "24h" in working
+	// hours; null uses the configured value and "0s" disables it.
+	ReviewSLA       *string `json:"review_sla"`
+	EscalationAfter *string `json:"escalation_after"`
+}
+
+func parseOptionalDuration(s *string) (*time.Duration, bool) {
+	if s == nil {
+		return nil, true
+	}
+	d, err := time.ParseDuration(*s)
+	if err != nil {
+		return nil, false
+	}
+	return &d, true
+}
+
+func formatOptionalDuration(d *time.Duration) interface{} {
+	if d == nil {
+		return nil
+	}
+	return d.String()
+}
+
+func SetTeamSLAHandler(slaService *service.SLAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetTeamSLARequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+		sla, ok := parseOptionalDuration(req.ReviewSLA)
+		if !ok {
+			http.Error(w, `review_sla must be a duration such as "24h"`, http.StatusBadRequest)
+			return
+		}
+		after, ok := parseOptionalDuration(req.EscalationAfter)
+		if !ok {
+			http.Error(w, `escalation_after must be a duration such as "48h"`, http.StatusBadRequest)
+			return
+		}
+
+		team, err := slaService.SetTeamSLA(r.Context(), req.TeamName, sla, after)
+		if err != nil {
+			writeSLAError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team_name":        team.Name,
+			"review_sla":       formatOptionalDuration(team.ReviewSLA),
+			"escalation_after": formatOptionalDuration(team.EscalationAfter),
+		})
+	}
+}
+
+// ListOverdueHandler lists open reviews past their SLA, optionally of the
+// team in the team_name query parameter.
+func ListOverdueHandler(slaService *service.SLAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		overdue, err := slaService.ListOverdue(r.Context(), r.URL.Query().Get("team_name"))
+		if err != nil {
+			writeSLAError(w, err)
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(overdue))
+		for _, o := range overdue {
+			list = append(list, overdueResponse(o))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"overdue": list})
+	}
+}
+
+func overdueResponse(o service.OverdueReview) map[string]interface{} {
+	a := o.Assignment
+	return map[string]interface{}{
+		"pull_request_id":          a.PullRequestID,
+		"pull_request_name":        a.Title,
+		"author_id":                a.AuthorID,
+		"user_id":                  a.ReviewerID,
+		"assigned_at":              a.AssignedAt,
+		"business_elapsed_seconds": int64(o.Elapsed / time.Second),
+		"due_at":                   o.DueAt,
+		"breached_at":              a.BreachedAt,
+		"escalated_at":             a.EscalatedAt,
+	}
+}
+
+func writeSLAError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case service.TeamNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+	case service.InvalidSLAError:
+		writeError(w, http.StatusBadRequest, "INVALID_SLA", err.Error())
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
@@ -3,12 +3,18 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"reviewer_service/internal/domain"
 	"reviewer_service/internal/service"
 )
 
 func GetReviewStatsHandler(prService *service.PullRequestService) http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
-		stats, err := prService.GetReviewStats()
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := prService.GetReviewStats(r.Context())
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		shadowStats, err := prService.GetShadowStats(r.Context())
 		if err != nil {
 			http.Error(w, "Internal error", http.StatusInternalServerError)
 			return
@@ -16,6 +22,7 @@ func GetReviewStatsHandler(prService *service.PullRequestService) http.HandlerFu
 
 		response := map[string]interface{}{
 			"review_assignments": stats,
+			"shadow_assignments": shadowStats,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -23,3 +30,37 @@ func GetReviewStatsHandler(prService *service.PullRequestService) http.HandlerFu
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+func statsResponse(st domain.TeamStats) map[string]int {
+	return map[string]int{
+		"pull_requests":      st.PullRequests,
+		"open_pull_requests": st.OpenPullRequests,
+		"review_assignments": st.ReviewAssignments,
+	}
+}
+
+// GetTeamStatsHandler reports per-team PR statistics; "total" rolls up the
+// team's sub-teams.
+func GetTeamStatsHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := prService.GetTeamStats(r.Context())
+		if err != nil {
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		teams := make([]map[string]interface{}, 0, len(entries))
+		for _, e := range entries {
+			teams = append(teams, map[string]interface{}{
+				"team_name":        e.Team.Name,
+				"parent_team_name": nullableString(e.Team.ParentName),
+				"own":              statsResponse(e.Own),
+				"total":            statsResponse(e.Total),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"teams": teams,
+		})
+	}
+}
@@ -8,21 +8,23 @@ import (
 )
 
 type AddTeamRequest struct {
-	TeamName string          `json:"team_name"`
-	Members  []TeamMemberDTO `json:"members"`
+	TeamName       string          `json:"team_name"`
+	ParentTeamName string          `json:"parent_team_name,omitempty"`
+	Members        []TeamMemberDTO `json:"members"`
 }
 
 type TeamMemberDTO struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	// Role is lead, senior, member (default) or junior.
+	Role string `json:"role,omitempty"`
 }
 
 func AddTeamHandler(teamService *service.TeamService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req AddTeamRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -32,12 +34,14 @@ func AddTeamHandler(teamService *service.TeamService) http.HandlerFunc {
 				ID:       m.UserID,
 				Username: m.Username,
 				IsActive: m.IsActive,
+				Role:     m.Role,
 			})
 		}
 
-		team, err := teamService.AddTeam(req.TeamName, members)
+		team, err := teamService.AddTeam(r.Context(), req.TeamName, req.ParentTeamName, members)
 		if err != nil {
-			if _, ok := err.(service.TeamExistsError); ok {
+			switch err.(type) {
+			case service.TeamExistsError:
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusBadRequest)
 				json.NewEncoder(w).Encode(map[string]interface{}{
@@ -46,16 +50,23 @@ func AddTeamHandler(teamService *service.TeamService) http.HandlerFunc {
 						"message": "team_name already exists",
 					},
 				})
-				return
+			case service.TeamNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "parent team not found")
+			case service.TeamCycleError:
+				writeError(w, http.StatusBadRequest, "TEAM_CYCLE", "parent team would create a cycle")
+			case service.InvalidRoleError:
+				writeError(w, http.StatusBadRequest, "INVALID_ROLE", err.Error())
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
 			}
-			http.Error(w, "Internal error", http.StatusInternalServerError)
 			return
 		}
 
 		response := map[string]interface{}{
 			"team": map[string]interface{}{
-				"team_name": team.Name,
-				"members":   team.Members,
+				"team_name":        team.Name,
+				"parent_team_name": nullableString(team.ParentName),
+				"members":          team.Members,
 			},
 		}
 
@@ -73,8 +84,7 @@ type DeactivateUsersRequest struct {
 func DeactivateUsersHandler(teamService *service.TeamService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req DeactivateUsersRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -83,7 +93,7 @@ func DeactivateUsersHandler(teamService *service.TeamService) http.HandlerFunc {
 			return
 		}
 
-		if err := teamService.DeactivateUsersAndReassign(req.TeamName, req.UserIDs); err != nil {
+		if err := teamService.DeactivateUsersAndReassign(r.Context(), req.TeamName, req.UserIDs); err != nil {
 			if _, ok := err.(service.TeamNotFoundError); ok {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusNotFound)
@@ -116,7 +126,7 @@ func GetTeamHandler(teamService *service.TeamService) http.HandlerFunc {
 			return
 		}
 
-		team, err := teamService.GetTeam(teamName)
+		team, err := teamService.GetTeamTree(r.Context(), teamName)
 		if err != nil {
 			switch err.(type) {
 			case service.TeamNotFoundError:
@@ -134,22 +144,84 @@ func GetTeamHandler(teamService *service.TeamService) http.HandlerFunc {
 			return
 		}
 
-		var members []map[string]interface{}
-		for _, m := range team.Members {
-			members = append(members, map[string]interface{}{
-				"user_id":   m.ID,
-				"username":  m.Username,
-				"is_active": m.IsActive,
-			})
-		}
-
-		response := map[string]interface{}{
-			"team_name": team.Name,
-			"members":   members,
-		}
+		response := teamTreeResponse(team)
+		response["parent_team_name"] = nullableString(team.ParentName)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+// teamTreeResponse renders the team and, recursively, its sub-teams.
+func teamTreeResponse(team *domain.Team) map[string]interface{} {
+	var members []map[string]interface{}
+	for _, m := range team.Members {
+		members = append(members, map[string]interface{}{
+			"user_id":    m.ID,
+			"username":   m.Username,
+			"is_active":  m.IsActive,
+			"is_primary": m.TeamID == team.ID,
+			"role":       m.Role,
+		})
+	}
+
+	subTeams := make([]map[string]interface{}, 0, len(team.SubTeams))
+	for _, sub := range team.SubTeams {
+		subTeams = append(subTeams, teamTreeResponse(sub))
+	}
+
+	return map[string]interface{}{
+		"team_name":   team.Name,
+		"members":     members,
+		"is_archived": team.IsArchived(),
+		"archived_at": team.ArchivedAt,
+		"sub_teams":   subTeams,
+	}
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+type SetParentTeamRequest struct {
+	TeamName string `json:"team_name"`
+	// ParentTeamName is empty to make the team top-level.
+	ParentTeamName string `json:"parent_team_name"`
+}
+
+func SetParentTeamHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetParentTeamRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		team, err := teamService.SetParent(r.Context(), req.TeamName, req.ParentTeamName)
+		if err != nil {
+			switch err.(type) {
+			case service.TeamNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+			case service.TeamCycleError:
+				writeError(w, http.StatusBadRequest, "TEAM_CYCLE", "parent team would create a cycle")
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team": map[string]interface{}{
+				"team_name":        team.Name,
+				"parent_team_name": nullableString(team.ParentName),
+			},
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+)
+
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+func RenameTeamHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RenameTeamRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" || req.NewTeamName == "" {
+			http.Error(w, "team_name and new_team_name are required", http.StatusBadRequest)
+			return
+		}
+
+		team, err := teamService.RenameTeam(r.Context(), req.TeamName, req.NewTeamName)
+		if err != nil {
+			writeTeamLifecycleError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team": map[string]interface{}{
+				"team_name":   team.Name,
+				"is_archived": team.IsArchived(),
+			},
+		})
+	}
+}
+
+type ArchiveTeamRequest struct {
+	TeamName string `json:"team_name"`
+	// OpenPRs is "reassign" (default) or "flag".
+	OpenPRs string `json:"open_prs"`
+}
+
+func ArchiveTeamHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ArchiveTeamRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		result, err := teamService.ArchiveTeam(r.Context(), req.TeamName, req.OpenPRs)
+		if err != nil {
+			writeTeamLifecycleError(w, err)
+			return
+		}
+
+		reassigned := make([]map[string]string, 0, len(result.Reassigned))
+		for _, c := range result.Reassigned {
+			reassigned = append(reassigned, map[string]string{
+				"pull_request_id": c.PullRequestID,
+				"old_user_id":     c.OldReviewerID,
+				"new_user_id":     c.NewReviewerID,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team": map[string]interface{}{
+				"team_name":   result.Team.Name,
+				"is_archived": true,
+				"archived_at": result.Team.ArchivedAt,
+			},
+			"reassigned":  reassigned,
+			"flagged_prs": nonNil(result.Flagged),
+		})
+	}
+}
+
+type UnarchiveTeamRequest struct {
+	TeamName string `json:"team_name"`
+}
+
+func UnarchiveTeamHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UnarchiveTeamRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		team, err := teamService.UnarchiveTeam(r.Context(), req.TeamName)
+		if err != nil {
+			writeTeamLifecycleError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team": map[string]interface{}{
+				"team_name":   team.Name,
+				"is_archived": false,
+			},
+		})
+	}
+}
+
+type DeleteTeamRequest struct {
+	TeamName string `json:"team_name"`
+	// Members is "reject" (default), "move" or "delete".
+	Members        string `json:"members"`
+	TargetTeamName string `json:"target_team_name"`
+	// PullRequests applies to PRs authored by deleted members: "reject"
+	// (default) or "delete".
+	PullRequests string `json:"pull_requests"`
+}
+
+func DeleteTeamHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeleteTeamRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" {
+			http.Error(w, "team_name is required", http.StatusBadRequest)
+			return
+		}
+
+		result, err := teamService.DeleteTeam(r.Context(), req.TeamName, service.TeamDeletePolicy{
+			Members:        req.Members,
+			TargetTeamName: req.TargetTeamName,
+			PullRequests:   req.PullRequests,
+		})
+		if err != nil {
+			writeTeamLifecycleError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"team_name":       req.TeamName,
+			"moved_members":   result.MovedMembers,
+			"deleted_members": result.DeletedMembers,
+			"flagged_prs":     nonNil(result.Flagged),
+		})
+	}
+}
+
+func writeTeamLifecycleError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.TeamNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+	case service.TeamExistsError:
+		writeError(w, http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
+	case service.TeamArchivedError:
+		writeError(w, http.StatusConflict, "TEAM_ARCHIVED", "target team is archived")
+	case service.TeamNotEmptyError:
+		writeError(w, http.StatusConflict, "TEAM_NOT_EMPTY", "team has members; choose members policy move or delete")
+	case service.TeamHasPullRequestsError:
+		writeError(w, http.StatusConflict, "TEAM_HAS_PRS", "team members authored pull requests; choose pull_requests policy delete")
+	case service.PullRequestsInOtherTeamsError:
+		writeError(w, http.StatusConflict, "PRS_IN_OTHER_TEAMS", "team members authored pull requests of other teams; move the members instead")
+	case service.InvalidPolicyError:
+		writeError(w, http.StatusBadRequest, "INVALID_POLICY", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
+
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+)
+
+type TeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Role     string `json:"role,omitempty"`
+}
+
+func AddTeamMemberHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TeamMemberRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" || req.UserID == "" {
+			http.Error(w, "team_name and user_id are required", http.StatusBadRequest)
+			return
+		}
+
+		membership, err := teamService.AddMember(r.Context(), req.TeamName, req.UserID, req.Role)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"membership": map[string]interface{}{
+				"user_id":    membership.UserID,
+				"team_name":  membership.TeamName,
+				"is_primary": membership.IsPrimary,
+				"role":       membership.Role,
+				"joined_at":  membership.JoinedAt,
+			},
+		})
+	}
+}
+
+func RemoveTeamMemberHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TeamMemberRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" || req.UserID == "" {
+			http.Error(w, "team_name and user_id are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := teamService.RemoveMember(r.Context(), req.TeamName, req.UserID); err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	}
+}
+
+func SetTeamRoleHandler(teamService *service.TeamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TeamMemberRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.TeamName == "" || req.UserID == "" || req.Role == "" {
+			http.Error(w, "team_name, user_id and role are required", http.StatusBadRequest)
+			return
+		}
+
+		membership, err := teamService.SetRole(r.Context(), req.TeamName, req.UserID, req.Role)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"membership": map[string]interface{}{
+				"user_id":    membership.UserID,
+				"team_name":  membership.TeamName,
+				"is_primary": membership.IsPrimary,
+				"role":       membership.Role,
+			},
+		})
+	}
+}
+
+func writeMembershipError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.NotTeamMemberError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user is not a member of the team")
+	case service.AlreadyInTeamError:
+		writeError(w, http.StatusConflict, "ALREADY_IN_TEAM", "user already belongs to this team")
+	case service.InvalidRoleError:
+		writeError(w, http.StatusBadRequest, "INVALID_ROLE", err.Error())
+	case service.PrimaryMembershipError:
+		writeError(w, http.StatusConflict, "PRIMARY_TEAM", "cannot remove the primary team; move the user instead")
+	default:
+		writeTeamLifecycleError(w, err)
+	}
+}
@@ -14,12 +14,11 @@ type SetIsActiveRequest struct {
 func SetIsActiveHandler(userService *service.UserService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req SetIsActiveRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
-		user, err := userService.SetIsActive(req.UserID, req.IsActive)
+		user, err := userService.SetIsActive(r.Context(), req.UserID, req.IsActive)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusNotFound)
@@ -55,7 +54,7 @@ func GetReviewPRsHandler(prService *service.PullRequestService) http.HandlerFunc
 			return
 		}
 
-		prs, err := prService.GetReviewPRs(userID)
+		prs, err := prService.GetReviewPRs(r.Context(), userID)
 		if err != nil {
 			switch err.(type) {
 			case service.AuthorNotFoundError:
@@ -83,3 +82,73 @@ func GetReviewPRsHandler(prService *service.PullRequestService) http.HandlerFunc
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+type SetSkillsRequest struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+func SetSkillsHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetSkillsRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.SetSkills(r.Context(), req.UserID, req.Skills)
+		if err != nil {
+			switch e := err.(type) {
+			case service.UserNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			case service.InvalidSkillError:
+				writeError(w, http.StatusBadRequest, "INVALID_SKILL", e.Error())
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id": user.ID,
+			"skills":  nonNil(user.Skills),
+		})
+	}
+}
+
+type SetShadowOptInRequest struct {
+	UserID      string `json:"user_id"`
+	ShadowOptIn bool   `json:"shadow_opt_in"`
+}
+
+func SetShadowOptInHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetShadowOptInRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.SetShadowOptIn(r.Context(), req.UserID, req.ShadowOptIn)
+		if err != nil {
+			switch err.(type) {
+			case service.UserNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":       user.ID,
+			"shadow_opt_in": user.ShadowOptIn,
+		})
+	}
+}
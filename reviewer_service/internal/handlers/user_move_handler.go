@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"reviewer_service/internal/service"
+)
+
+type MoveUserRequest struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+	// OpenReviews is "keep" (default) or "reassign".
+	OpenReviews string `json:"open_reviews"`
+}
+
+func MoveUserHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MoveUserRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.TeamName == "" {
+			http.Error(w, "user_id and team_name are required", http.StatusBadRequest)
+			return
+		}
+
+		result, err := userService.MoveUser(r.Context(), req.UserID, req.TeamName, req.OpenReviews)
+		if err != nil {
+			switch err.(type) {
+			case service.UserNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			case service.AlreadyInTeamError:
+				writeError(w, http.StatusConflict, "ALREADY_IN_TEAM", "user already belongs to this team")
+			default:
+				writeTeamLifecycleError(w, err)
+			}
+			return
+		}
+
+		reassigned := make([]map[string]string, 0, len(result.Reassigned))
+		for _, c := range result.Reassigned {
+			reassigned = append(reassigned, map[string]string{
+				"pull_request_id": c.PullRequestID,
+				"old_user_id":     c.OldReviewerID,
+				"new_user_id":     c.NewReviewerID,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user": map[string]interface{}{
+				"user_id":   result.User.ID,
+				"username":  result.User.Username,
+				"team_name": result.User.TeamName,
+				"is_active": result.User.IsActive,
+			},
+			"from_team_name": result.Move.FromTeamName,
+			"open_reviews":   result.Move.OpenReviewsPolicy,
+			"reassigned":     reassigned,
+			"flagged_prs":    nonNil(result.Flagged),
+		})
+	}
+}
+
+func GetTeamHistoryHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		moves, err := userService.GetTeamHistory(r.Context(), userID)
+		if err != nil {
+			switch err.(type) {
+			case service.UserNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		history := make([]map[string]interface{}, 0, len(moves))
+		for _, m := range moves {
+			entry := map[string]interface{}{
+				"from_team_name":     nil,
+				"to_team_name":       m.ToTeamName,
+				"source":             m.Source,
+				"open_reviews":       m.OpenReviewsPolicy,
+				"reassigned_reviews": m.ReassignedReviews,
+				"moved_at":           m.MovedAt,
+			}
+			if m.FromTeamID != nil || m.FromTeamName != "" {
+				entry["from_team_name"] = m.FromTeamName
+			}
+			history = append(history, entry)
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id": userID,
+			"history": history,
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/service"
+	"time"
+)
+
+type SetWorkingHoursRequest struct {
+	UserID   string `json:"user_id"`
+	Timezone string `json:"timezone"`
+	// WorkStart and WorkEnd are local "HH:MM" times, 09:00 and 18:00 by default.
+	WorkStart string `json:"work_start"`
+	WorkEnd   string `json:"work_end"`
+	// WorkDays defaults to mon..fri.
+	WorkDays []string `json:"work_days"`
+}
+
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func formatClock(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func SetWorkingHoursHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetWorkingHoursRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.UserID == "" || req.Timezone == "" {
+			http.Error(w, "user_id and timezone are required", http.StatusBadRequest)
+			return
+		}
+
+		schedule := domain.DefaultWorkSchedule()
+		schedule.Timezone = req.Timezone
+		if req.WorkStart != "" {
+			minute, ok := parseClock(req.WorkStart)
+			if !ok {
+				http.Error(w, "work_start must be HH:MM", http.StatusBadRequest)
+				return
+			}
+			schedule.StartMinute = minute
+		}
+		if req.WorkEnd != "" {
+			minute, ok := parseClock(req.WorkEnd)
+			if !ok {
+				http.Error(w, "work_end must be HH:MM", http.StatusBadRequest)
+				return
+			}
+			// "24:00" does not parse, so "00:00" stands for the end of the day.
+			if minute == 0 {
+				minute = 24 * 60
+			}
+			schedule.EndMinute = minute
+		}
+		if req.WorkDays != nil {
+			days, ok := parseWeekdays(req.WorkDays)
+			if !ok {
+				http.Error(w, "work_days must be sun, mon, tue, wed, thu, fri or sat", http.StatusBadRequest)
+				return
+			}
+			schedule.Weekdays = days
+		}
+
+		user, err := userService.SetWorkSchedule(r.Context(), req.UserID, schedule)
+		if err != nil {
+			writeWorkingHoursError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, workingHoursResponse(user))
+	}
+}
+
+func GetWorkingHoursHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "user_id is required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.GetUser(r.Context(), userID)
+		if err != nil {
+			writeWorkingHoursError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, workingHoursResponse(user))
+	}
+}
+
+func workingHoursResponse(user *domain.User) map[string]interface{} {
+	schedule := user.Schedule
+	now := time.Now()
+	return map[string]interface{}{
+		"user_id":          user.ID,
+		"timezone":         schedule.Timezone,
+		"work_start":       formatClock(schedule.StartMinute),
+		"work_end":         formatClock(schedule.EndMinute),
+		"work_days":        weekdayList(schedule.Weekdays),
+		"in_working_hours": schedule.InHours(now),
+		"next_start":       schedule.NextStart(now).In(schedule.Location()),
+	}
+}
+
+func writeWorkingHoursError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case service.UserNotFoundError:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case service.InvalidScheduleError:
+		writeError(w, http.StatusBadRequest, "INVALID_SCHEDULE", e.Reason)
+	default:
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
+
+func GetReviewClocksHandler(prService *service.PullRequestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prID := r.URL.Query().Get("pull_request_id")
+		if prID == "" {
+			http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+			return
+		}
+
+		pr, clocks, err := prService.GetReviewClocks(r.Context(), prID)
+		if err != nil {
+			switch err.(type) {
+			case service.AuthorNotFoundError:
+				writeError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
+			default:
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		list := make([]map[string]interface{}, 0, len(clocks))
+		for _, c := range clocks {
+			list = append(list, map[string]interface{}{
+				"user_id":                  c.ReviewerID,
+				"started_at":               c.StartedAt,
+				"business_elapsed_seconds": int64(c.Elapsed / time.Second),
+				"due_at":                   c.DueAt,
+				"overdue":                  c.Overdue,
+				"in_working_hours":         c.InWorkingHours,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"pull_request_id": pr.ID,
+			"status":          pr.Status,
+			"reviewers":       list,
+		})
+	}
+}
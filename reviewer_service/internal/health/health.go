@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	// StatusWarn marks a failed informational check; it does not take the
+	// report down.
+	StatusWarn = "warn"
+)
+
+// Check reports an error when the dependency it covers is unhealthy.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+	info  bool
+}
+
+// Checker runs registered checks concurrently, each bounded by timeout.
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// RegisterInfo adds a check whose failure is reported as StatusWarn
+// without affecting the overall status, for state that is shared between
+// replicas and so says nothing about this one.
+func (c *Checker) RegisterInfo(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check, info: true})
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(checkCtx)
+			result := CheckResult{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				if nc.info {
+					result.Status = StatusWarn
+				}
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil && !nc.info {
+				report.Status = StatusDown
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+// Heartbeat lets a background worker prove it is still making progress.
+// The worker calls Beat after every loop iteration; Check fails once no
+// beat has been seen for longer than maxAge.
+type Heartbeat struct {
+	mu      sync.Mutex
+	last    time.Time
+	lastErr error
+	maxAge  time.Duration
+}
+
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, last: time.Now()}
+}
+
+func (h *Heartbeat) Beat(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = time.Now()
+	h.lastErr = err
+}
+
+func (h *Heartbeat) Check(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if age := time.Since(h.last); age > h.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+	if h.lastErr != nil {
+		return fmt.Errorf("last run failed: %w", h.lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package ical reads the subset of iCalendar (RFC 5545) needed to import
+// absences: VEVENT components with their dates, summary and weekly rules.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	// Status is e.g. CONFIRMED or CANCELLED; Transparent marks events that
+	// do not block time.
+	Status      string
+	Transparent bool
+	RRule       string
+}
+
+// Parse returns the events in r. Events without DTSTART are skipped, as are
+// the properties of components nested in an event, such as VALARM.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var ev *Event
+	var hasStart, hasEnd bool
+	// nested counts the components open inside the current event.
+	nested := 0
+	for n, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			ev = &Event{}
+			hasStart, hasEnd = false, false
+			nested = 0
+			continue
+		case ev != nil && name == "BEGIN":
+			nested++
+			continue
+		case ev != nil && name == "END" && nested > 0:
+			nested--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if ev != nil && hasStart {
+				if !hasEnd {
+					ev.End = ev.Start
+					if ev.AllDay {
+						ev.End = ev.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *ev)
+			}
+			ev = nil
+			continue
+		}
+		if ev == nil || nested > 0 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			ev.UID = value
+		case "SUMMARY":
+			ev.Summary = unescape(value)
+		case "DESCRIPTION":
+			ev.Description = unescape(value)
+		case "CATEGORIES":
+			for _, c := range strings.Split(value, ",") {
+				if c = strings.TrimSpace(unescape(c)); c != "" {
+					ev.Categories = append(ev.Categories, c)
+				}
+			}
+		case "STATUS":
+			ev.Status = strings.ToUpper(value)
+		case "TRANSP":
+			ev.Transparent = strings.EqualFold(value, "TRANSPARENT")
+		case "RRULE":
+			ev.RRule = value
+		case "DTSTART", "DTEND":
+			t, allDay, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", n+1, name, err)
+			}
+			if name == "DTSTART" {
+				ev.Start, ev.AllDay, hasStart = t, allDay, true
+			} else {
+				ev.End, hasEnd = t, true
+			}
+		}
+	}
+	return events, nil
+}
+
+// WeeklyRule describes an RRULE of the form FREQ=WEEKLY;BYDAY=...[;UNTIL=...].
+type WeeklyRule struct {
+	Days  []time.Weekday
+	Until *time.Time
+}
+
+// ParseWeekly interprets rule as a weekly recurrence. It returns false for
+// any other frequency or for rules limited by COUNT.
+func ParseWeekly(rule string) (WeeklyRule, bool) {
+	var wr WeeklyRule
+	weekly := false
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			weekly = strings.EqualFold(value, "WEEKLY")
+		case "COUNT":
+			return wr, false
+		case "INTERVAL":
+			if value != "1" {
+				return wr, false
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				day, ok := weekdays[strings.ToUpper(d)]
+				if !ok {
+					return wr, false
+				}
+				wr.Days = append(wr.Days, day)
+			}
+		case "UNTIL":
+			t, _, err := parseTime(value, nil)
+			if err != nil {
+				return wr, false
+			}
+			wr.Until = &t
+		}
+	}
+	return wr, weekly && len(wr.Days) > 0
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// unfold joins continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+func splitProperty(line string) (name string, params map[string]string, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return name, params, value, true
+}
+
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var unescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,162 @@
+package ical
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func parseString(t *testing.T, lines ...string) []Event {
+	t.Helper()
+	events, err := Parse(strings.NewReader(strings.Join(lines, "\r\n")))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return events
+}
+
+func TestParseEvents(t *testing.T) {
+	events := parseString(t,
+		"BEGIN:VCALENDAR",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Lisbon",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T020000",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:vacation-1",
+		"SUMMARY:Отпуск\\, море",
+		"DESCRIPTION:Back on Monday.\\nCall if urgent",
+		"  with a folded line",
+		"DTSTART;VALUE=DATE:20260706",
+		"DTEND;VALUE=DATE:20260718",
+		"CATEGORIES:Vacation, Family",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:meeting-1",
+		"DTSTART;TZID=Europe/Lisbon:20260302T100000",
+		"DTEND:20260302T110000Z",
+		"STATUS:cancelled",
+		"TRANSP:TRANSPARENT",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:no-start",
+		"SUMMARY:Skipped",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+
+	vacation := events[0]
+	want := Event{
+		UID:         "vacation-1",
+		Summary:     "Отпуск, море",
+		Description: "Back on Monday.\nCall if urgent with a folded line",
+		Categories:  []string{"Vacation", "Family"},
+		Start:       time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC),
+		AllDay:      true,
+	}
+	if !reflect.DeepEqual(vacation, want) {
+		t.Errorf("vacation =\n%+v\nwant\n%+v", vacation, want)
+	}
+
+	meeting := events[1]
+	if !meeting.Start.Equal(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)) || meeting.AllDay {
+		t.Errorf("meeting start %s (all day %v)", meeting.Start, meeting.AllDay)
+	}
+	if !meeting.End.Equal(time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("meeting end %s", meeting.End)
+	}
+	if meeting.Status != "CANCELLED" || !meeting.Transparent || meeting.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("meeting %+v", meeting)
+	}
+}
+
+func TestParseDefaultsEnd(t *testing.T) {
+	events := parseString(t,
+		"BEGIN:VEVENT",
+		"UID:day",
+		"DTSTART;VALUE=DATE:20260706",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:moment",
+		"DTSTART:20260706T090000Z",
+		"END:VEVENT",
+	)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if want := time.Date(2026, 7, 7, 0, 0, 0, 0, time.UTC); !events[0].End.Equal(want) {
+		t.Errorf("all-day end %s, want the next day", events[0].End)
+	}
+	if !events[1].End.Equal(events[1].Start) {
+		t.Errorf("timed end %s, want the start", events[1].End)
+	}
+}
+
+func TestParseIgnoresNestedComponents(t *testing.T) {
+	events := parseString(t,
+		"BEGIN:VEVENT",
+		"UID:trip",
+		"SUMMARY:Conference trip",
+		"DTSTART;VALUE=DATE:20260706",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"SUMMARY:Sick leave form due",
+		"DESCRIPTION:Reminder",
+		"TRIGGER:-P1D",
+		"END:VALARM",
+		"DESCRIPTION:Lisbon",
+		"END:VEVENT",
+	)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if ev := events[0]; ev.Summary != "Conference trip" || ev.Description != "Lisbon" {
+		t.Errorf("alarm leaked into the event: summary %q, description %q", ev.Summary, ev.Description)
+	}
+}
+
+func TestParseReportsBadDate(t *testing.T) {
+	_, err := Parse(strings.NewReader("BEGIN:VEVENT\nUID:x\nDTSTART:tomorrow\nEND:VEVENT\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3: DTSTART") {
+		t.Errorf("error %v, want one for DTSTART on line 3", err)
+	}
+}
+
+func TestParseWeekly(t *testing.T) {
+	until := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+	tests := []struct {
+		rule string
+		want WeeklyRule
+		ok   bool
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR", WeeklyRule{Days: []time.Weekday{time.Monday, time.Wednesday, time.Friday}}, true},
+		{"freq=weekly;byday=fr;until=20261231T235959Z", WeeklyRule{Days: []time.Weekday{time.Friday}, Until: &until}, true},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=TU", WeeklyRule{Days: []time.Weekday{time.Tuesday}}, true},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", WeeklyRule{}, false},
+		{"FREQ=WEEKLY;COUNT=4;BYDAY=TU", WeeklyRule{}, false},
+		{"FREQ=WEEKLY", WeeklyRule{}, false},
+		{"FREQ=DAILY;BYDAY=MO", WeeklyRule{}, false},
+		{"FREQ=WEEKLY;BYDAY=1MO", WeeklyRule{}, false},
+		{"FREQ=WEEKLY;BYDAY=MO;UNTIL=soon", WeeklyRule{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := ParseWeekly(tc.rule)
+		if ok != tc.ok {
+			t.Errorf("ParseWeekly(%q) ok = %v, want %v", tc.rule, ok, tc.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseWeekly(%q) = %+v, want %+v", tc.rule, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// Package jobs runs periodic and delayed background jobs next to the HTTP
+// server. Job state lives in Postgres and every run happens under a
+// per-job advisory lock, so with several replicas each run happens once.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/health"
+	"reviewer_service/internal/repository"
+)
+
+const (
+	defaultLease = 5 * time.Minute
+	// delayedBatch is how many due delayed jobs one poll runs at most. They
+	// are claimed one at a time, right before each runs, so a lease only
+	// has to cover the run of its own job.
+	delayedBatch = 20
+)
+
+// RetryPolicy decides what happens after a failed run. A periodic job is
+// retried after Backoff, doubled per attempt up to MaxBackoff, until
+// MaxAttempts runs have failed; then it waits for its next interval. A
+// delayed job is marked failed instead.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay is the wait before the retry that follows the attempt-th failure.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Periodic is a job that runs every Interval, first right after it is
+// registered. A zero Retry uses the scheduler's default policy and a zero
+// Timeout the scheduler's Lease.
+type Periodic struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+	Retry    RetryPolicy
+	// Timeout bounds one run so a hung run does not hold the job's lock
+	// forever.
+	Timeout time.Duration
+}
+
+// Handler runs one delayed job with the payload it was enqueued with.
+type Handler func(ctx context.Context, payload []byte) error
+
+type Options struct {
+	// PollInterval is how often due jobs are looked for.
+	PollInterval time.Duration
+	// DrainTimeout is how long running jobs may take to finish once the
+	// scheduler is stopped before their context is cancelled.
+	DrainTimeout time.Duration
+	Retry        RetryPolicy
+	// Lease bounds a delayed job run; once it expires another replica may
+	// pick the job up again. It is also the default timeout of periodic
+	// runs.
+	Lease time.Duration
+}
+
+type periodicJob struct {
+	Periodic
+	registered bool
+	running    atomic.Bool
+}
+
+type handler struct {
+	run   Handler
+	retry RetryPolicy
+}
+
+type Scheduler struct {
+	repo      repository.JobRepository
+	opts      Options
+	periodic  []*periodicJob
+	handlers  map[string]handler
+	heartbeat *health.Heartbeat
+
+	delayedRunning atomic.Bool
+	wg             sync.WaitGroup
+}
+
+func New(repo repository.JobRepository, opts Options) *Scheduler {
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+	return &Scheduler{
+		repo:      repo,
+		opts:      opts,
+		handlers:  make(map[string]handler),
+		heartbeat: health.NewHeartbeat(3 * opts.PollInterval),
+	}
+}
+
+// AddPeriodic registers a periodic job. It must be called before Start.
+func (s *Scheduler) AddPeriodic(job Periodic) {
+	if job.Retry.MaxAttempts == 0 {
+		job.Retry = s.opts.Retry
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = s.opts.Lease
+	}
+	s.periodic = append(s.periodic, &periodicJob{Periodic: job})
+}
+
+// Handle registers the handler of delayed jobs called name. It must be
+// called before Start. A zero retry uses the scheduler's default policy.
+func (s *Scheduler) Handle(name string, retry RetryPolicy, h Handler) {
+	if retry.MaxAttempts == 0 {
+		retry = s.opts.Retry
+	}
+	s.handlers[name] = handler{run: h, retry: retry}
+}
+
+// Enqueue schedules a delayed job to run at or after runAt. Called with a
+// transaction in ctx, the job is only created if that transaction commits.
+func (s *Scheduler) Enqueue(ctx context.Context, name string, payload []byte, runAt time.Time) (*domain.DelayedJob, error) {
+	if _, ok := s.handlers[name]; !ok {
+		return nil, fmt.Errorf("jobs: no handler for %q", name)
+	}
+	job := &domain.DelayedJob{Name: name, Payload: payload, RunAt: runAt}
+	if err := s.repo.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// EnqueueOnce is Enqueue for jobs where one pending run covers every
+// request, such as a drain: when a job called name is already waiting to
+// run it creates nothing and returns nil.
+func (s *Scheduler) EnqueueOnce(ctx context.Context, name string, payload []byte, runAt time.Time) (*domain.DelayedJob, error) {
+	if _, ok := s.handlers[name]; !ok {
+		return nil, fmt.Errorf("jobs: no handler for %q", name)
+	}
+	job := &domain.DelayedJob{Name: name, Payload: payload, RunAt: runAt}
+	created, err := s.repo.EnqueueOnce(ctx, job)
+	if err != nil || !created {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Check fails when the scheduler loop has stopped polling.
+func (s *Scheduler) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
+
+// JobCheck reports the shared state of the named periodic job: it fails
+// when the last run failed or no replica has run the job for too long.
+// Another replica may own the job, so this is informational only; the
+// readiness of this replica is Check.
+func (s *Scheduler) JobCheck(name string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var interval time.Duration
+		for _, job := range s.periodic {
+			if job.Name == name {
+				interval = job.Interval
+			}
+		}
+		state, err := s.repo.GetJob(ctx, name)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return fmt.Errorf("job %s has not been scheduled yet", name)
+		}
+		if state.LastError != "" {
+			return fmt.Errorf("last run failed: %s", state.LastError)
+		}
+		if late := time.Since(state.NextRunAt); late > 2*interval+s.opts.PollInterval {
+			return fmt.Errorf("overdue by %s", late.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// Start launches the polling loop and returns a channel closed once it has
+// stopped. Cancelling ctx stops new runs; runs already in progress get
+// DrainTimeout to finish before their context is cancelled too.
+func (s *Scheduler) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		defer close(done)
+		defer cancelRuns()
+		ticker := time.NewTicker(s.opts.PollInterval)
+		defer ticker.Stop()
+		for {
+			s.poll(ctx, runCtx)
+			select {
+			case <-ctx.Done():
+				s.drain(cancelRuns)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+func (s *Scheduler) drain(cancelRuns context.CancelFunc) {
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(s.opts.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-finished:
+	case <-timer.C:
+		log.Printf("Background jobs did not finish within %s, cancelling them", s.opts.DrainTimeout)
+		cancelRuns()
+		<-finished
+	}
+}
+
+// poll starts every periodic job that is not already running here and one
+// batch of due delayed jobs. Runs use runCtx so stopping the loop does not
+// interrupt them.
+func (s *Scheduler) poll(ctx, runCtx context.Context) {
+	s.heartbeat.Beat(nil)
+	for _, job := range s.periodic {
+		if !job.running.CompareAndSwap(false, true) {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer job.running.Store(false)
+			s.runPeriodic(ctx, runCtx, job)
+		}()
+	}
+
+	if len(s.handlers) > 0 && s.delayedRunning.CompareAndSwap(false, true) {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.delayedRunning.Store(false)
+			s.runDelayed(ctx, runCtx)
+		}()
+	}
+}
+
+func (s *Scheduler) runPeriodic(ctx, runCtx context.Context, job *periodicJob) {
+	if !job.registered {
+		if err := s.repo.EnsureJob(ctx, job.Name, time.Now()); err != nil {
+			log.Printf("Job %s: failed to register: %v", job.Name, err)
+			return
+		}
+		job.registered = true
+	}
+
+	release, ok, err := s.repo.TryLock(ctx, lockKey(job.Name))
+	if err != nil {
+		log.Printf("Job %s: failed to take lock: %v", job.Name, err)
+		return
+	}
+	if !ok {
+		return // another replica is running it
+	}
+	defer release()
+
+	// Read the state under the lock, as another replica may have just
+	// finished a run.
+	state, err := s.repo.GetJob(ctx, job.Name)
+	if err != nil || state == nil {
+		log.Printf("Job %s: failed to load state: %v", job.Name, err)
+		return
+	}
+	started := time.Now()
+	if state.NextRunAt.After(started) {
+		return
+	}
+	if err := s.repo.StartRun(runCtx, job.Name, started); err != nil {
+		log.Printf("Job %s: failed to record start: %v", job.Name, err)
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(runCtx, job.Timeout)
+	runErr := job.Run(jobCtx)
+	cancel()
+	finished := time.Now()
+
+	attempts, next, msg := 0, started.Add(job.Interval), ""
+	if runErr != nil {
+		msg = runErr.Error()
+		attempts = state.Attempts + 1
+		if attempts < job.Retry.attempts() {
+			next = finished.Add(job.Retry.delay(attempts))
+			log.Printf("Job %s failed (attempt %d of %d), retrying at %s: %v", job.Name, attempts, job.Retry.attempts(), next.Format(time.RFC3339), runErr)
+		} else {
+			log.Printf("Job %s failed (attempt %d of %d): %v", job.Name, attempts, job.Retry.attempts(), runErr)
+			attempts = 0
+		}
+	}
+	if err := s.repo.FinishRun(runCtx, job.Name, finished, msg, attempts, next); err != nil {
+		log.Printf("Job %s: failed to record finish: %v", job.Name, err)
+	}
+}
+
+// runDelayed runs up to delayedBatch due delayed jobs, claiming each one
+// only when the previous one has finished so that a job never waits for
+// others under its lease. It stops claiming once ctx is cancelled.
+func (s *Scheduler) runDelayed(ctx, runCtx context.Context) {
+	names := make([]string, 0, len(s.handlers))
+	for name := range s.handlers {
+		names = append(names, name)
+	}
+	for i := 0; i < delayedBatch && ctx.Err() == nil; i++ {
+		now := time.Now()
+		claimed, err := s.repo.ClaimDue(ctx, names, now, now.Add(s.opts.Lease), 1)
+		if err != nil {
+			log.Printf("Failed to claim delayed jobs: %v", err)
+			return
+		}
+		if len(claimed) == 0 {
+			return
+		}
+		s.runDelayedJob(runCtx, claimed[0])
+	}
+}
+
+func (s *Scheduler) runDelayedJob(runCtx context.Context, job domain.DelayedJob) {
+	h := s.handlers[job.Name]
+	jobCtx, cancel := context.WithTimeout(runCtx, s.opts.Lease)
+	runErr := h.run(jobCtx, job.Payload)
+	cancel()
+
+	finished := time.Now()
+	var err error
+	switch {
+	case runErr == nil:
+		err = s.repo.CompleteDelayed(runCtx, job.ID, finished)
+	case job.Attempts < h.retry.attempts():
+		err = s.repo.RetryDelayed(runCtx, job.ID, runErr.Error(), finished.Add(h.retry.delay(job.Attempts)))
+	default:
+		log.Printf("Delayed job %s #%d failed after %d attempts: %v", job.Name, job.ID, job.Attempts, runErr)
+		err = s.repo.FailDelayed(runCtx, job.ID, runErr.Error(), finished)
+	}
+	if err != nil {
+		log.Printf("Delayed job %s #%d: failed to record result: %v", job.Name, job.ID, err)
+	}
+}
+
+// lockKey maps a job name to its advisory lock key.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("reviewer_service/jobs/" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+// memoryRepo keeps job state in memory and records every claim.
+type memoryRepo struct {
+	repository.JobRepository
+	mu       sync.Mutex
+	pending  []domain.DelayedJob
+	claims   []claim
+	done     map[int64]time.Time
+	state    domain.JobState
+	finished chan string
+}
+
+type claim struct {
+	limit       int
+	at          time.Time
+	lockedUntil time.Time
+}
+
+func (r *memoryRepo) ClaimDue(_ context.Context, _ []string, now, lockedUntil time.Time, limit int) ([]domain.DelayedJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claims = append(r.claims, claim{limit: limit, at: now, lockedUntil: lockedUntil})
+	n := min(limit, len(r.pending))
+	claimed := r.pending[:n]
+	r.pending = r.pending[n:]
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (r *memoryRepo) CompleteDelayed(_ context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done[id] = at
+	return nil
+}
+
+func (r *memoryRepo) EnsureJob(context.Context, string, time.Time) error { return nil }
+
+func (r *memoryRepo) TryLock(context.Context, int64) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+func (r *memoryRepo) GetJob(context.Context, string) (*domain.JobState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.state
+	return &state, nil
+}
+
+func (r *memoryRepo) StartRun(context.Context, string, time.Time) error { return nil }
+
+func (r *memoryRepo) FinishRun(_ context.Context, _ string, _ time.Time, runErr string, _ int, next time.Time) error {
+	r.mu.Lock()
+	r.state.NextRunAt = next
+	r.mu.Unlock()
+	r.finished <- runErr
+	return nil
+}
+
+func TestDelayedJobsAreClaimedOneAtATime(t *testing.T) {
+	repo := &memoryRepo{done: map[int64]time.Time{}}
+	for id := int64(1); id <= 3; id++ {
+		repo.pending = append(repo.pending, domain.DelayedJob{ID: id, Name: "slow"})
+	}
+	s := New(repo, Options{PollInterval: time.Hour, Lease: time.Minute})
+	s.Handle("slow", RetryPolicy{}, func(ctx context.Context, payload []byte) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	s.runDelayed(context.Background(), context.Background())
+
+	if len(repo.done) != 3 {
+		t.Fatalf("completed %d jobs, want 3", len(repo.done))
+	}
+	// Three claims that found a job and a last one that found none.
+	if len(repo.claims) != 4 {
+		t.Fatalf("%d claims, want 4", len(repo.claims))
+	}
+	for i, c := range repo.claims {
+		if c.limit != 1 {
+			t.Errorf("claim %d took up to %d jobs, want 1", i, c.limit)
+		}
+		if got := c.lockedUntil.Sub(c.at); got != time.Minute {
+			t.Errorf("claim %d leased for %s, want the whole lease", i, got)
+		}
+		if id := int64(i); i > 0 && i < 3 && c.at.Before(repo.done[id]) {
+			t.Errorf("job %d claimed before job %d finished", i+1, i)
+		}
+	}
+}
+
+func TestDelayedJobsStopClaimingOnceStopped(t *testing.T) {
+	repo := &memoryRepo{done: map[int64]time.Time{}, pending: []domain.DelayedJob{{ID: 1, Name: "job"}}}
+	s := New(repo, Options{PollInterval: time.Hour})
+	s.Handle("job", RetryPolicy{}, func(context.Context, []byte) error { return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.runDelayed(ctx, context.Background())
+
+	if len(repo.claims) != 0 {
+		t.Errorf("claimed %d times after stop, want none", len(repo.claims))
+	}
+}
+
+func TestPeriodicRunIsBoundedByTimeout(t *testing.T) {
+	repo := &memoryRepo{finished: make(chan string, 1)}
+	s := New(repo, Options{PollInterval: time.Hour})
+	s.AddPeriodic(Periodic{
+		Name:     "hung",
+		Interval: time.Hour,
+		Timeout:  20 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	go s.runPeriodic(context.Background(), context.Background(), s.periodic[0])
+
+	select {
+	case runErr := <-repo.finished:
+		if runErr != context.DeadlineExceeded.Error() {
+			t.Errorf("run finished with %q, want the deadline error", runErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hung run was not cancelled")
+	}
+}
+
+func TestPeriodicTimeoutDefaultsToLease(t *testing.T) {
+	s := New(&memoryRepo{}, Options{Lease: 3 * time.Minute})
+	s.AddPeriodic(Periodic{Name: "job", Interval: time.Minute, Run: func(context.Context) error { return errors.New("unused") }})
+	if got := s.periodic[0].Timeout; got != 3*time.Minute {
+		t.Errorf("timeout %s, want the lease", got)
+	}
+}
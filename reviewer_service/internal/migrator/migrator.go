@@ -0,0 +1,186 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"reviewer_service/migrations"
+)
+
+// lockKey identifies the session-level advisory lock that serializes
+// migration runs across replicas. It is unrelated to the lock golang-migrate
+// takes internally, which only covers a single Up/Down call.
+const lockKey int64 = 0x7265766965776572 // "reviewer"
+
+type Migrator struct {
+	db          *sql.DB
+	lockTimeout time.Duration
+}
+
+func New(db *sql.DB, lockTimeout time.Duration) *Migrator {
+	return &Migrator{db: db, lockTimeout: lockTimeout}
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error {
+		err := mg.Up()
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return m.run(ctx, func(mg *migrate.Migrate) error {
+		err := mg.Steps(-steps)
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error {
+		return mg.Force(version)
+	})
+}
+
+// Version reports the applied schema version. It returns 0 and no error when
+// no migration has been applied yet.
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	err = m.withMigrate(ctx, func(mg *migrate.Migrate) error {
+		version, dirty, err = mg.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+		return err
+	})
+	return version, dirty, err
+}
+
+// CheckSchema verifies that every embedded migration has been applied and the
+// schema is not left dirty by a failed run. A schema that is ahead of this
+// binary is accepted so old replicas stay ready during a rolling deploy.
+// It reads schema_migrations directly so it never waits on the migration lock.
+func (m *Migrator) CheckSchema(ctx context.Context) error {
+	latest, err := Latest()
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	var dirty bool
+	err = m.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no migrations applied, expected version %d", latest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+	if uint(version) < latest {
+		return fmt.Errorf("schema version %d, expected at least %d", version, latest)
+	}
+	return nil
+}
+
+// Latest returns the highest migration version embedded in the binary.
+func Latest() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	v, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		v = next
+	}
+}
+
+// run executes fn while holding the migration advisory lock, so replicas
+// that start together apply migrations one at a time.
+func (m *Migrator) run(ctx context.Context, fn func(*migrate.Migrate) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	lockCtx := ctx
+	if m.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, m.lockTimeout)
+		defer cancel()
+	}
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return m.withMigrate(ctx, fn)
+}
+
+func (m *Migrator) withMigrate(ctx context.Context, fn func(*migrate.Migrate) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("create postgres driver: %w", err)
+	}
+
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		driver.Close()
+		return fmt.Errorf("open embedded migrations: %w", err)
+	}
+
+	mg, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return fmt.Errorf("create migrate instance: %w", err)
+	}
+	defer func() {
+		if srcErr, dbErr := mg.Close(); srcErr != nil || dbErr != nil {
+			log.Printf("Failed to close migrate instance: source: %v, db: %v", srcErr, dbErr)
+		}
+	}()
+
+	return fn(mg)
+}
@@ -0,0 +1,235 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"reviewer_service/internal/domain"
+)
+
+// EmailJob is the delayed job that sends one queued email.
+const EmailJob = "send_email"
+
+// Queue runs delayed jobs; the job scheduler is one.
+type Queue interface {
+	Enqueue(ctx context.Context, name string, payload []byte, runAt time.Time) (*domain.DelayedJob, error)
+}
+
+// EmailMessage is the payload of an EmailJob.
+type EmailMessage struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+	// UnsubscribeURL is linked from the body and the List-Unsubscribe
+	// header; empty when no unsubscribe URL is configured.
+	UnsubscribeURL string `json:"unsubscribe_url,omitempty"`
+}
+
+// Email queues one email per recipient with an address who has not
+// unsubscribed; SMTP sends them from the queue, so a failed delivery is
+// retried without holding up the caller. UnsubscribeURL gets the
+// recipient's token appended as the token query parameter.
+type Email struct {
+	Store          PreferenceStore
+	Queue          Queue
+	UnsubscribeURL string
+}
+
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	prefs, err := e.Store.GetPreferences(ctx, msg.Recipients, domain.NotifyInstant)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pref := range prefs {
+		if pref.Email == "" || pref.EmailUnsubscribed {
+			continue
+		}
+		email := EmailMessage{To: pref.Email, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}
+		if e.UnsubscribeURL != "" && pref.UnsubscribeToken != "" {
+			email.UnsubscribeURL = e.UnsubscribeURL + "?token=" + url.QueryEscape(pref.UnsubscribeToken)
+		}
+		payload, err := json.Marshal(email)
+		if err != nil {
+			return err
+		}
+		if _, err := e.Queue.Enqueue(ctx, EmailJob, payload, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("email to %s: %w", pref.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SMTP sends queued emails. It upgrades the connection with STARTTLS when
+// the server offers it and authenticates when Username is set.
+type SMTP struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// Send is the handler of EmailJob.
+func (s *SMTP) Send(ctx context.Context, payload []byte) error {
+	var msg EmailMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+	body, err := s.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: s.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose renders the email as MIME: plain text alone, or plain text and
+// HTML as multipart/alternative.
+func (s *SMTP) compose(msg EmailMessage) ([]byte, error) {
+	text, body := msg.Text, msg.HTML
+	if msg.UnsubscribeURL != "" {
+		text += "\n\n--\nUnsubscribe: " + msg.UnsubscribeURL
+		if body != "" {
+			body += `<hr><p><a href="` + html.EscapeString(msg.UnsubscribeURL) + `">Unsubscribe</a></p>`
+		}
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", s.From)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(s.From))
+	header("MIME-Version", "1.0")
+	if msg.UnsubscribeURL != "" {
+		header("List-Unsubscribe", "<"+msg.UnsubscribeURL+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+
+	if body == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", text},
+		{"text/html", body},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(s, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the domain of from.
+func messageID(from string) string {
+	domainPart := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domainPart = strings.TrimRight(from[i+1:], ">")
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domainPart + ">"
+}
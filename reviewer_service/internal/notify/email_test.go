@@ -0,0 +1,328 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/jobs"
+	"reviewer_service/internal/repository"
+)
+
+// smtpServer is a minimal SMTP server on a local port. It answers the
+// first failRcpt RCPT commands with a temporary failure and keeps the data
+// of every accepted message.
+type smtpServer struct {
+	ln        net.Listener
+	mu        sync.Mutex
+	failRcpt  int
+	rcptTries int
+	messages  []string
+}
+
+func newSMTPServer(t *testing.T, failRcpt int) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, failRcpt: failRcpt}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) Addr() string { return s.ln.Addr().String() }
+
+func (s *smtpServer) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP test")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, _, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcptTries++
+			fail := s.rcptTries <= s.failRcpt
+			s.mu.Unlock()
+			if fail {
+				tp.PrintfLine("451 4.3.0 Try again later")
+			} else {
+				tp.PrintfLine("250 OK")
+			}
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func testSMTP(addr string) *SMTP {
+	return &SMTP{Addr: addr, Username: "bot", Password: "secret", From: "Reviewer <reviewer@example.com>", Timeout: 5 * time.Second}
+}
+
+func TestSMTPSendsMultipartWithUnsubscribe(t *testing.T) {
+	srv := newSMTPServer(t, 0)
+	payload, _ := json.Marshal(EmailMessage{
+		To:             "alice@example.com",
+		Subject:        "Review requested: Поиск",
+		Text:           "You were assigned to review pr-1.",
+		HTML:           "<p>You were assigned to review <b>pr-1</b>.</p>",
+		UnsubscribeURL: "https://reviews.example.com/users/unsubscribe?token=tok123",
+	})
+	if err := testSMTP(srv.Addr()).Send(context.Background(), payload); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(msgs))
+	}
+	m, err := mail.ReadMessage(strings.NewReader(msgs[0]))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := m.Header.Get("To"); got != "alice@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject")); err != nil || subject != "Review requested: Поиск" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if got := m.Header.Get("List-Unsubscribe"); got != "<https://reviews.example.com/users/unsubscribe?token=tok123>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := m.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/alternative", m.Header.Get("Content-Type"), err)
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		if enc := p.Header.Get("Content-Transfer-Encoding"); enc != "quoted-printable" {
+			t.Errorf("part encoding %q, want quoted-printable", enc)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+
+	if text := parts["text/plain"]; !strings.Contains(text, "You were assigned to review pr-1.") || !strings.Contains(text, "Unsubscribe: https://reviews.example.com/users/unsubscribe?token=tok123") {
+		t.Errorf("text part:\n%s", text)
+	}
+	if html := parts["text/html"]; !strings.Contains(html, "<b>pr-1</b>") || !strings.Contains(html, `href="https://reviews.example.com/users/unsubscribe?token=tok123"`) {
+		t.Errorf("html part:\n%s", html)
+	}
+}
+
+func TestSMTPSendsPlainTextWithoutHTML(t *testing.T) {
+	srv := newSMTPServer(t, 0)
+	payload, _ := json.Marshal(EmailMessage{To: "bob@example.com", Subject: "Digest", Text: "Nothing new."})
+	if err := testSMTP(srv.Addr()).Send(context.Background(), payload); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	m, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(srv.Messages()[0])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := m.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if m.Header.Get("List-Unsubscribe") != "" {
+		t.Error("List-Unsubscribe set without an unsubscribe URL")
+	}
+}
+
+// fakeQueue keeps the jobs Email enqueues.
+type fakeQueue struct {
+	jobs []domain.DelayedJob
+}
+
+func (q *fakeQueue) Enqueue(_ context.Context, name string, payload []byte, runAt time.Time) (*domain.DelayedJob, error) {
+	job := domain.DelayedJob{ID: int64(len(q.jobs) + 1), Name: name, Payload: payload, RunAt: runAt}
+	q.jobs = append(q.jobs, job)
+	return &job, nil
+}
+
+func TestEmailSkipsUnsubscribedAndAddresslessUsers(t *testing.T) {
+	store := fakeStore{
+		"alice": {Email: "alice@example.com", UnsubscribeToken: "tok-a"},
+		"bob":   {Email: "bob@example.com", UnsubscribeToken: "tok-b", EmailUnsubscribed: true},
+		"carol": {},
+	}
+	queue := &fakeQueue{}
+	e := &Email{Store: store, Queue: queue, UnsubscribeURL: "https://reviews.example.com/users/unsubscribe"}
+
+	msg := Message{Kind: KindAssigned, Recipients: []string{"alice", "bob", "carol"}, Subject: "Review requested", Text: "text", HTML: "<p>html</p>"}
+	if err := e.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(queue.jobs) != 1 {
+		t.Fatalf("queued %d emails, want 1", len(queue.jobs))
+	}
+	job := queue.jobs[0]
+	if job.Name != EmailJob {
+		t.Errorf("job %q, want %q", job.Name, EmailJob)
+	}
+	var email EmailMessage
+	if err := json.Unmarshal(job.Payload, &email); err != nil {
+		t.Fatal(err)
+	}
+	want := EmailMessage{To: "alice@example.com", Subject: "Review requested", Text: "text", HTML: "<p>html</p>", UnsubscribeURL: "https://reviews.example.com/users/unsubscribe?token=tok-a"}
+	if email != want {
+		t.Errorf("queued %+v, want %+v", email, want)
+	}
+}
+
+// memoryJobs is the delayed half of a job repository kept in memory.
+type memoryJobs struct {
+	repository.JobRepository
+	mu   sync.Mutex
+	jobs []*domain.DelayedJob
+}
+
+func (r *memoryJobs) Enqueue(_ context.Context, job *domain.DelayedJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = int64(len(r.jobs) + 1)
+	job.Status = "pending"
+	stored := *job
+	r.jobs = append(r.jobs, &stored)
+	return nil
+}
+
+func (r *memoryJobs) ClaimDue(_ context.Context, names []string, now, _ time.Time, limit int) ([]domain.DelayedJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []domain.DelayedJob
+	for _, job := range r.jobs {
+		if job.Status == "pending" && !job.RunAt.After(now) && len(claimed) < limit {
+			job.Attempts++
+			job.Status = "running"
+			claimed = append(claimed, *job)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memoryJobs) finish(id int64, status, runErr string, runAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[id-1]
+	job.Status, job.LastError, job.RunAt = status, runErr, runAt
+}
+
+func (r *memoryJobs) CompleteDelayed(_ context.Context, id int64, at time.Time) error {
+	r.finish(id, "done", "", at)
+	return nil
+}
+
+func (r *memoryJobs) RetryDelayed(_ context.Context, id int64, runErr string, runAt time.Time) error {
+	r.finish(id, "pending", runErr, runAt)
+	return nil
+}
+
+func (r *memoryJobs) FailDelayed(_ context.Context, id int64, runErr string, at time.Time) error {
+	r.finish(id, "failed", runErr, at)
+	return nil
+}
+
+func (r *memoryJobs) get(id int64) domain.DelayedJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.jobs[id-1]
+}
+
+func TestEmailFailedSendIsRetriedFromQueue(t *testing.T) {
+	srv := newSMTPServer(t, 1)
+	repo := &memoryJobs{}
+	scheduler := jobs.New(repo, jobs.Options{PollInterval: 10 * time.Millisecond, DrainTimeout: time.Second})
+	scheduler.Handle(EmailJob, jobs.RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}, testSMTP(srv.Addr()).Send)
+
+	e := &Email{Store: fakeStore{"alice": {Email: "alice@example.com"}}, Queue: scheduler}
+	if err := e.Notify(context.Background(), Message{Recipients: []string{"alice"}, Subject: "s", Text: "t"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := scheduler.Start(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.get(1).Status != "done" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	job := repo.get(1)
+	if job.Status != "done" || job.Attempts != 2 {
+		t.Fatalf("job %s after %d attempts (%s), want done after 2", job.Status, job.Attempts, job.LastError)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("server got %d messages, want 1", n)
+	}
+}
@@ -0,0 +1,57 @@
+// Package notify delivers notifications about reviews over the configured
+// channels.
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+)
+
+const (
+	KindAssigned      = "review_assigned"
+	KindReassigned    = "review_reassigned"
+	KindDigest        = "review_digest"
+	KindWeeklySummary = "review_weekly_summary"
+	KindSLABreached   = "review_sla_breached"
+	KindEscalated     = "review_escalated"
+)
+
+type Message struct {
+	Kind string
+	// Recipients are user IDs.
+	Recipients    []string
+	PullRequestID string
+	// Data is what the templates of Kind render Subject, Text and HTML
+	// from. HTML is empty for kinds without an HTML template.
+	Data    map[string]interface{}
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Log writes notifications to the service log.
+type Log struct{}
+
+func (Log) Notify(_ context.Context, msg Message) error {
+	log.Printf("Notification %s to %s: %s", msg.Kind, strings.Join(msg.Recipients, ", "), msg.Subject)
+	return nil
+}
+
+// Multi sends every message to all of its notifiers, even when some fail.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+
+	"reviewer_service/internal/domain"
+)
+
+// PreferenceStore looks up notification preferences; users without any
+// get defaultMode.
+type PreferenceStore interface {
+	GetPreferences(ctx context.Context, userIDs []string, defaultMode string) ([]domain.NotificationPreferences, error)
+}
+
+// Preferences drops the recipients who do not want a message: users in
+// digest mode only get digests and weekly summaries and users in off mode
+// get nothing.
+type Preferences struct {
+	Store       PreferenceStore
+	DefaultMode string
+	Next        Notifier
+}
+
+func (p Preferences) Notify(ctx context.Context, msg Message) error {
+	prefs, err := p.Store.GetPreferences(ctx, msg.Recipients, p.DefaultMode)
+	if err != nil {
+		return err
+	}
+	var recipients []string
+	for _, pref := range prefs {
+		if pref.Mode == domain.NotifyInstant || (pref.Mode == domain.NotifyDigest && (msg.Kind == KindDigest || msg.Kind == KindWeeklySummary)) {
+			recipients = append(recipients, pref.UserID)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	msg.Recipients = recipients
+	return p.Next.Notify(ctx, msg)
+}
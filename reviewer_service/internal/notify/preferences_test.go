@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"reviewer_service/internal/domain"
+)
+
+// fakeStore returns the stored preferences of the requested users, with
+// defaultMode for those without a mode.
+type fakeStore map[string]domain.NotificationPreferences
+
+func (s fakeStore) GetPreferences(_ context.Context, userIDs []string, defaultMode string) ([]domain.NotificationPreferences, error) {
+	var prefs []domain.NotificationPreferences
+	for _, id := range userIDs {
+		p, ok := s[id]
+		if !ok {
+			continue
+		}
+		p.UserID = id
+		if p.Username == "" {
+			p.Username = id
+		}
+		if p.Mode == "" {
+			p.Mode = defaultMode
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, nil
+}
+
+type recordingNotifier struct {
+	msgs []Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg Message) error {
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+func TestPreferencesFilterByMode(t *testing.T) {
+	store := fakeStore{
+		"instant": {Mode: domain.NotifyInstant},
+		"digest":  {Mode: domain.NotifyDigest},
+		"off":     {Mode: domain.NotifyOff},
+		"default": {},
+	}
+	everyone := []string{"default", "digest", "instant", "off"}
+
+	tests := []struct {
+		kind        string
+		defaultMode string
+		want        []string
+	}{
+		{KindAssigned, domain.NotifyInstant, []string{"default", "instant"}},
+		{KindReassigned, domain.NotifyDigest, []string{"instant"}},
+		{KindSLABreached, domain.NotifyOff, []string{"instant"}},
+		{KindDigest, domain.NotifyInstant, []string{"default", "digest", "instant"}},
+		{KindWeeklySummary, domain.NotifyDigest, []string{"default", "digest", "instant"}},
+	}
+	for _, tc := range tests {
+		next := &recordingNotifier{}
+		p := Preferences{Store: store, DefaultMode: tc.defaultMode, Next: next}
+		if err := p.Notify(context.Background(), Message{Kind: tc.kind, Recipients: everyone}); err != nil {
+			t.Fatalf("%s: %v", tc.kind, err)
+		}
+		if len(next.msgs) != 1 {
+			t.Fatalf("%s with default %s: %d messages passed on, want 1", tc.kind, tc.defaultMode, len(next.msgs))
+		}
+		if got := next.msgs[0].Recipients; !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s with default %s: recipients %v, want %v", tc.kind, tc.defaultMode, got, tc.want)
+		}
+	}
+}
+
+func TestPreferencesDropsMessageWithoutRecipients(t *testing.T) {
+	next := &recordingNotifier{}
+	p := Preferences{Store: fakeStore{"off": {Mode: domain.NotifyOff}, "digest": {Mode: domain.NotifyDigest}}, DefaultMode: domain.NotifyInstant, Next: next}
+	if err := p.Notify(context.Background(), Message{Kind: KindAssigned, Recipients: []string{"off", "digest", "unknown"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(next.msgs) != 0 {
+		t.Errorf("passed on %+v, want nothing", next.msgs)
+	}
+}
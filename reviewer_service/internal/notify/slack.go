@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"reviewer_service/internal/domain"
+)
+
+// Slack posts messages to Slack-compatible incoming webhooks. A recipient
+// with a personal webhook gets the message there; the others get it on the
+// shared WebhookURL with a mention, or not at all if it is unset.
+type Slack struct {
+	WebhookURL string
+	Store      PreferenceStore
+	Client     *http.Client
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (s *Slack) Notify(ctx context.Context, msg Message) error {
+	prefs, err := s.Store.GetPreferences(ctx, msg.Recipients, domain.NotifyInstant)
+	if err != nil {
+		return err
+	}
+	text := "*" + msg.Subject + "*\n" + msg.Text
+
+	var errs []error
+	for _, pref := range prefs {
+		url, body := pref.SlackWebhookURL, text
+		if url == "" {
+			if s.WebhookURL == "" {
+				continue
+			}
+			url = s.WebhookURL
+			mention := pref.Username
+			if pref.SlackMemberID != "" {
+				mention = "<@" + pref.SlackMemberID + ">"
+			}
+			body = mention + " " + text
+		}
+		if err := s.post(ctx, url, body); err != nil {
+			errs = append(errs, fmt.Errorf("slack to %s: %w", pref.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Slack) post(ctx context.Context, url, text string) error {
+	body, err := json.Marshal(slackPayload{Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type slackServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	posts map[string][]string
+}
+
+// newSlackServer accepts webhook posts on any path except /broken, which
+// answers 500, and keeps the text of each by path.
+func newSlackServer(t *testing.T) *slackServer {
+	s := &slackServer{posts: map[string][]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s with content type %q", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var payload slackPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("payload: %v", err)
+		}
+		s.mu.Lock()
+		s.posts[r.URL.Path] = append(s.posts[r.URL.Path], payload.Text)
+		s.mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSlackPostsToPersonalAndSharedWebhooks(t *testing.T) {
+	srv := newSlackServer(t)
+	store := fakeStore{
+		"alice": {SlackWebhookURL: srv.URL + "/alice"},
+		"bob":   {SlackMemberID: "U0BOB"},
+		"carol": {Username: "carol.k"},
+	}
+	s := &Slack{WebhookURL: srv.URL + "/shared", Store: store, Client: srv.Client()}
+
+	msg := Message{Kind: KindAssigned, Recipients: []string{"alice", "bob", "carol"}, Subject: "Review requested: Add search", Text: "You were assigned to review pr-1."}
+	if err := s.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	text := "*Review requested: Add search*\nYou were assigned to review pr-1."
+	if got := srv.posts["/alice"]; len(got) != 1 || got[0] != text {
+		t.Errorf("personal webhook got %q, want %q", got, text)
+	}
+	shared := srv.posts["/shared"]
+	want := []string{"<@U0BOB> " + text, "carol.k " + text}
+	if len(shared) != 2 || shared[0] != want[0] || shared[1] != want[1] {
+		t.Errorf("shared webhook got %q, want %q", shared, want)
+	}
+}
+
+func TestSlackSkipsUsersWithoutWebhook(t *testing.T) {
+	srv := newSlackServer(t)
+	s := &Slack{Store: fakeStore{"bob": {SlackMemberID: "U0BOB"}}, Client: srv.Client()}
+	if err := s.Notify(context.Background(), Message{Recipients: []string{"bob"}, Subject: "s", Text: "t"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(srv.posts) != 0 {
+		t.Errorf("posted %v without a shared webhook", srv.posts)
+	}
+}
+
+func TestSlackReportsNon2xx(t *testing.T) {
+	srv := newSlackServer(t)
+	store := fakeStore{
+		"alice": {SlackWebhookURL: srv.URL + "/broken"},
+		"bob":   {SlackWebhookURL: srv.URL + "/bob"},
+	}
+	s := &Slack{Store: store, Client: srv.Client()}
+
+	err := s.Notify(context.Background(), Message{Recipients: []string{"alice", "bob"}, Subject: "s", Text: "t"})
+	if err == nil {
+		t.Fatal("Notify succeeded, want the 500 reported")
+	}
+	if !strings.Contains(err.Error(), "alice") || !strings.Contains(err.Error(), "500") {
+		t.Errorf("error %q should name alice and the status", err)
+	}
+	if len(srv.posts["/bob"]) != 1 {
+		t.Errorf("bob got %d posts, want 1 despite alice's failure", len(srv.posts["/bob"]))
+	}
+}
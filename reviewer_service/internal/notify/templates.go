@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// defaultTemplates define "<kind>.subject" and "<kind>.text" for every kind.
+// Files in the templates directory may redefine any of them.
+const defaultTemplates = `
+{{define "review_assigned.subject"}}Review requested: {{.Title}}{{end}}
+{{define "review_assigned.text"}}You were assigned to review {{.PullRequestID}} "{{.Title}}" by {{.AuthorID}}.{{end}}
+
+{{define "review_reassigned.subject"}}Review handed over to you: {{.Title}}{{end}}
+{{define "review_reassigned.text"}}You take over the review of {{.PullRequestID}} "{{.Title}}" by {{.AuthorID}} from {{.ReplacedReviewerID}}.{{end}}
+
+{{define "review_digest.subject"}}{{len .PullRequests}} review(s) waiting for you{{end}}
+{{define "review_digest.text"}}Good morning, {{.Username}}! Pull requests waiting for your review:
+{{range .PullRequests}}• {{.ID}} "{{.Title}}" by {{.AuthorID}}
+{{end}}{{end}}
+
+{{define "review_sla_breached.subject"}}Review of {{.PullRequestID}} is overdue{{end}}
+{{define "review_sla_breached.text"}}"{{.Title}}" was assigned to you {{.Elapsed}} of working time ago; the review SLA is {{.SLA}}.{{end}}
+
+{{define "review_escalated.subject"}}Review of {{.PullRequestID}} escalated{{end}}
+{{define "review_escalated.text"}}"{{.Title}}" waited {{.Elapsed}} of working time for {{.ReviewerID}}; {{if .AddedID}}{{.AddedID}} was added.{{else}}nobody could take it over.{{end}}{{end}}
+
+{{define "review_weekly_summary.subject"}}Your reviews this week{{end}}
+{{define "review_weekly_summary.text"}}Hi {{.Username}}, here is your week since {{.Since.Format "Jan 2"}}.
+{{if .Reviewed}}Merged after your review:
+{{range .Reviewed}}• {{.ID}} "{{.Title}}" by {{.AuthorID}}
+{{end}}{{end}}{{if .Pending}}Still waiting for you:
+{{range .Pending}}• {{.ID}} "{{.Title}}" by {{.AuthorID}}
+{{end}}{{end}}{{end}}
+`
+
+// defaultHTMLTemplates define "<kind>.html" for the kinds sent by email;
+// the others are sent as plain text only. Files in the templates directory
+// named *.html may redefine them.
+const defaultHTMLTemplates = `
+{{define "review_assigned.html"}}<p>You were assigned to review <b>{{.PullRequestID}}</b> &ldquo;{{.Title}}&rdquo; by {{.AuthorID}}.</p>{{end}}
+
+{{define "review_reassigned.html"}}<p>You take over the review of <b>{{.PullRequestID}}</b> &ldquo;{{.Title}}&rdquo; by {{.AuthorID}} from {{.ReplacedReviewerID}}.</p>{{end}}
+
+{{define "review_sla_breached.html"}}<p>&ldquo;{{.Title}}&rdquo; (<b>{{.PullRequestID}}</b>) was assigned to you {{.Elapsed}} of working time ago; the review SLA is {{.SLA}}.</p>{{end}}
+
+{{define "review_weekly_summary.html"}}<p>Hi {{.Username}}, here is your week since {{.Since.Format "Jan 2"}}.</p>
+{{if .Reviewed}}<p>Merged after your review:</p>
+<ul>{{range .Reviewed}}<li><b>{{.ID}}</b> &ldquo;{{.Title}}&rdquo; by {{.AuthorID}}</li>{{end}}</ul>{{end}}
+{{if .Pending}}<p>Still waiting for you:</p>
+<ul>{{range .Pending}}<li><b>{{.ID}}</b> &ldquo;{{.Title}}&rdquo; by {{.AuthorID}}</li>{{end}}</ul>{{end}}{{end}}
+`
+
+// Templates render message subjects, texts and HTML bodies from their
+// Data.
+type Templates struct {
+	set  *template.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplates parses the built-in templates and then every *.tmpl and
+// *.html file in dir, if dir is set, so those files override the defines
+// they repeat.
+func LoadTemplates(dir string) (*Templates, error) {
+	set, err := template.New("notify").Parse(defaultTemplates)
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New("notify").Parse(defaultHTMLTemplates)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		err := parseDir(dir, "*.tmpl", func(name, text string) error {
+			_, err := set.New(name).Parse(text)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = parseDir(dir, "*.html", func(name, text string) error {
+			_, err := html.New(name).Parse(text)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Templates{set: set, html: html}, nil
+}
+
+func parseDir(dir, pattern string, parse func(name, text string) error) error {
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := parse(filepath.Base(file), string(data)); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Render fills msg.Subject, msg.Text and msg.HTML from the templates of its
+// kind; parts without a template are left as they are.
+func (t *Templates) Render(msg *Message) error {
+	for _, part := range []struct {
+		name string
+		dst  *string
+	}{{"subject", &msg.Subject}, {"text", &msg.Text}} {
+		text, ok, err := t.execute(msg.Kind+"."+part.name, msg.Data)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*part.dst = strings.TrimSpace(text)
+		}
+	}
+
+	if tmpl := t.html.Lookup(msg.Kind + ".html"); tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, msg.Data); err != nil {
+			return fmt.Errorf("template %s.html: %w", msg.Kind, err)
+		}
+		msg.HTML = strings.TrimSpace(buf.String())
+	}
+	return nil
+}
+
+func (t *Templates) execute(name string, data interface{}) (string, bool, error) {
+	tmpl := t.set.Lookup(name)
+	if tmpl == nil {
+		return "", false, nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", false, fmt.Errorf("template %s: %w", name, err)
+	}
+	return buf.String(), true, nil
+}
+
+// Templated renders every message before passing it on to Next.
+type Templated struct {
+	Templates *Templates
+	Next      Notifier
+}
+
+func (t Templated) Notify(ctx context.Context, msg Message) error {
+	if err := t.Templates.Render(&msg); err != nil {
+		return err
+	}
+	return t.Next.Notify(ctx, msg)
+}
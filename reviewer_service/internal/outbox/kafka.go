@@ -0,0 +1,40 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka publishes to a Kafka cluster. Messages are spread over partitions
+// by a hash of their key, so messages with one key stay in order.
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(brokers []string) *Kafka {
+	return &Kafka{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// The relay sends one message at a time and waits for it, so there
+		// is nothing to batch.
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+func (k *Kafka) Publish(ctx context.Context, msg Message) error {
+	m := kafka.Message{Topic: msg.Topic, Value: msg.Value}
+	if msg.Key != "" {
+		m.Key = []byte(msg.Key)
+	}
+	for name, value := range msg.Headers {
+		m.Headers = append(m.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	return k.writer.WriteMessages(ctx, m)
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
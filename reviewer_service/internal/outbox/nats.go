@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// flushTimeout bounds the wait for the server on core NATS when ctx has no
+// deadline of its own.
+const flushTimeout = 10 * time.Second
+
+// NATS publishes to NATS subjects. With JetStream every message is
+// acknowledged by the stream that captures its subject, and duplicates are
+// dropped by message ID; core NATS only confirms the server received it.
+// The key is sent in the Partition-Key header.
+type NATS struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+func NewNATS(url string, useJetStream bool) (*NATS, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("reviewer_service"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NATS{conn: conn}
+	if useJetStream {
+		n.js, err = jetstream.New(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Value
+	for name, value := range msg.Headers {
+		m.Header.Set(name, value)
+	}
+	if msg.Key != "" {
+		m.Header.Set("Partition-Key", msg.Key)
+	}
+
+	if n.js != nil {
+		_, err := n.js.PublishMsg(ctx, m, jetstream.WithMsgID(msg.ID))
+		return err
+	}
+	if err := n.conn.PublishMsg(m); err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flushTimeout)
+		defer cancel()
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runNATSServer starts an in-process NATS server on a free port, with
+// JetStream when asked to.
+func runNATSServer(t *testing.T, jetStream bool) *server.Server {
+	t.Helper()
+	opts := &server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true}
+	if jetStream {
+		opts.JetStream = true
+		opts.StoreDir = t.TempDir()
+	}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+func newTestNATS(t *testing.T, srv *server.Server, jetStream bool) *NATS {
+	t.Helper()
+	n, err := NewNATS(srv.ClientURL(), jetStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+func testMessage(id string) Message {
+	return Message{
+		ID:      id,
+		Topic:   "reviewer.events.pull_request.merged",
+		Key:     "pr-1",
+		Value:   []byte(`{"version":1,"id":` + id + `}`),
+		Headers: map[string]string{"Event-Type": "pull_request.merged", "Event-Version": "1"},
+	}
+}
+
+func TestNATSPublish(t *testing.T) {
+	srv := runNATSServer(t, false)
+	n := newTestNATS(t, srv, false)
+
+	sub, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	received, err := sub.SubscribeSync("reviewer.events.>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Publish(ctx, testMessage("42")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	msg, err := received.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no message: %v", err)
+	}
+	if msg.Subject != "reviewer.events.pull_request.merged" {
+		t.Errorf("subject %q", msg.Subject)
+	}
+	if string(msg.Data) != `{"version":1,"id":42}` {
+		t.Errorf("data %s", msg.Data)
+	}
+	for name, want := range map[string]string{"Partition-Key": "pr-1", "Event-Type": "pull_request.merged", "Event-Version": "1"} {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNATSPublishWithoutKey(t *testing.T) {
+	srv := runNATSServer(t, false)
+	n := newTestNATS(t, srv, false)
+
+	sub, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	received, _ := sub.SubscribeSync(">")
+	sub.Flush()
+
+	msg := testMessage("1")
+	msg.Key = ""
+	if err := n.Publish(context.Background(), msg); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	got, err := received.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Header["Partition-Key"]; ok {
+		t.Errorf("Partition-Key set without a key: %v", got.Header)
+	}
+}
+
+func TestNATSJetStreamDeduplicates(t *testing.T) {
+	srv := runNATSServer(t, true)
+	n := newTestNATS(t, srv, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "REVIEWER", Subjects: []string{"reviewer.events.>"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The relay publishes an event again when marking it failed; the
+	// message ID keeps the stream from storing it twice.
+	for i := 0; i < 2; i++ {
+		if err := n.Publish(ctx, testMessage("42")); err != nil {
+			t.Fatalf("Publish %d: %v", i, err)
+		}
+	}
+	if err := n.Publish(ctx, testMessage("43")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State.Msgs != 2 {
+		t.Errorf("stream holds %d messages, want 2", info.State.Msgs)
+	}
+
+	stored, err := stream.GetMsg(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.Header.Get(jetstream.MsgIDHeader); got != "42" {
+		t.Errorf("%s = %q, want 42", jetstream.MsgIDHeader, got)
+	}
+	if got := stored.Header.Get("Partition-Key"); got != "pr-1" {
+		t.Errorf("Partition-Key = %q, want pr-1", got)
+	}
+}
+
+func TestNATSJetStreamFailsWithoutStream(t *testing.T) {
+	srv := runNATSServer(t, true)
+	n := newTestNATS(t, srv, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Publish(ctx, testMessage("1")); err == nil {
+		t.Error("Publish succeeded without a stream for the subject, want an error so the relay retries")
+	}
+}
@@ -0,0 +1,157 @@
+// Package outbox publishes the PR, user and team events that repositories
+// write to the outbox table alongside their changes. Delivery is at least
+// once: every sink that accepts an event is recorded and a failed event is
+// retried only on the sinks that have not, but a sink may still see an event
+// again if recording fails and should deduplicate by its ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+// Sink receives published events. Events of one aggregate, such as a PR,
+// arrive in order.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event domain.OutboxEvent) error
+}
+
+// EnvelopeVersion is bumped on incompatible changes to Envelope or to the
+// payload of an existing event type.
+const EnvelopeVersion = 1
+
+// Envelope is the JSON form of an event handed to webhooks and brokers.
+type Envelope struct {
+	ID            int64           `json:"id"`
+	Version       int             `json:"version"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func Encode(event domain.OutboxEvent) ([]byte, error) {
+	return json.Marshal(Envelope{
+		ID:            event.ID,
+		Version:       EnvelopeVersion,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		CreatedAt:     event.CreatedAt,
+		Payload:       event.Payload,
+	})
+}
+
+type Options struct {
+	BatchSize int
+	// Backoff is the delay after an event's first failed delivery, doubled
+	// per attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long published events are kept; 0 keeps them.
+	Retention time.Duration
+}
+
+// Relay moves events from the outbox to the sinks. Only one relay may run
+// at a time, which the job scheduler's per-job lock guarantees.
+type Relay struct {
+	repo  repository.OutboxRepository
+	sinks []Sink
+	opts  Options
+}
+
+func NewRelay(repo repository.OutboxRepository, sinks []Sink, opts Options) *Relay {
+	return &Relay{repo: repo, sinks: sinks, opts: opts}
+}
+
+// RelayResult counts the events handled by one Run.
+type RelayResult struct {
+	Published int
+	Failed    int
+	Deleted   int64
+}
+
+// Run publishes due events until none are left. A failed event is retried
+// with backoff on the sinks that failed and holds back the later events of
+// its aggregate until it goes through. Only database errors are returned; delivery failures are
+// recorded on the event.
+func (r *Relay) Run(ctx context.Context) (*RelayResult, error) {
+	res := &RelayResult{}
+	for {
+		events, err := r.repo.ListPending(ctx, time.Now(), r.opts.BatchSize)
+		if err != nil {
+			return res, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			if err := r.publish(ctx, event); err != nil {
+				if ctx.Err() != nil {
+					return res, ctx.Err()
+				}
+				res.Failed++
+				next := time.Now().Add(r.backoff(event.Attempts + 1))
+				log.Printf("Outbox event %d (%s, %s %s) failed, retrying at %s: %v", event.ID, event.Type, event.AggregateType, event.AggregateID, next.Format(time.RFC3339), err)
+				if err := r.repo.MarkFailed(ctx, event.ID, err.Error(), next); err != nil {
+					return res, err
+				}
+				continue
+			}
+			res.Published++
+			if err := r.repo.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	if r.opts.Retention > 0 {
+		n, err := r.repo.DeletePublished(ctx, time.Now().Add(-r.opts.Retention))
+		if err != nil {
+			return res, err
+		}
+		res.Deleted = n
+	}
+	return res, nil
+}
+
+// publish hands the event to the sinks that have not accepted it yet and
+// records each that does.
+func (r *Relay) publish(ctx context.Context, event domain.OutboxEvent) error {
+	var errs []error
+	for _, sink := range r.sinks {
+		name := sink.Name()
+		if slices.Contains(event.DeliveredTo, name) {
+			continue
+		}
+		err := sink.Publish(ctx, event)
+		if err == nil {
+			err = r.repo.MarkDelivered(ctx, event.ID, name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Relay) backoff(attempt int) time.Duration {
+	d := r.opts.Backoff
+	for i := 1; i < attempt && (r.opts.MaxBackoff <= 0 || d < r.opts.MaxBackoff); i++ {
+		d *= 2
+	}
+	if r.opts.MaxBackoff > 0 && d > r.opts.MaxBackoff {
+		d = r.opts.MaxBackoff
+	}
+	return d
+}
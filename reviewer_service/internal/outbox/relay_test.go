@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+)
+
+// memoryRepo keeps the outbox in memory with the semantics of the Postgres
+// repository: only the oldest unpublished event of an aggregate is pending.
+type memoryRepo struct {
+	events []*domain.OutboxEvent
+	next   map[int64]time.Time
+}
+
+func newMemoryRepo(events ...domain.OutboxEvent) *memoryRepo {
+	r := &memoryRepo{next: map[int64]time.Time{}}
+	for i := range events {
+		e := events[i]
+		r.events = append(r.events, &e)
+	}
+	return r
+}
+
+func (r *memoryRepo) ListPending(_ context.Context, now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	seen := map[string]bool{}
+	var out []domain.OutboxEvent
+	for _, e := range r.events {
+		if e.PublishedAt != nil {
+			continue
+		}
+		agg := e.AggregateType + "/" + e.AggregateID
+		if seen[agg] {
+			continue
+		}
+		seen[agg] = true
+		if r.next[e.ID].After(now) {
+			continue
+		}
+		out = append(out, *e)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryRepo) find(id int64) *domain.OutboxEvent {
+	for _, e := range r.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) MarkDelivered(_ context.Context, id int64, sink string) error {
+	e := r.find(id)
+	e.DeliveredTo = append(e.DeliveredTo, sink)
+	return nil
+}
+
+func (r *memoryRepo) MarkPublished(_ context.Context, id int64, at time.Time) error {
+	r.find(id).PublishedAt = &at
+	return nil
+}
+
+func (r *memoryRepo) MarkFailed(_ context.Context, id int64, publishErr string, nextAttempt time.Time) error {
+	e := r.find(id)
+	e.Attempts++
+	e.LastError = publishErr
+	r.next[id] = nextAttempt
+	return nil
+}
+
+func (r *memoryRepo) DeletePublished(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// flakySink records delivered event IDs and fails the events in failOnce
+// the first time they come.
+type flakySink struct {
+	name      string
+	failOnce  map[int64]bool
+	delivered []int64
+}
+
+func (s *flakySink) Name() string { return s.name }
+
+func (s *flakySink) Publish(_ context.Context, event domain.OutboxEvent) error {
+	if s.failOnce[event.ID] {
+		delete(s.failOnce, event.ID)
+		return errors.New("unavailable")
+	}
+	s.delivered = append(s.delivered, event.ID)
+	return nil
+}
+
+func TestRelayKeepsAggregateOrder(t *testing.T) {
+	pr := func(id int64, prID, typ string) domain.OutboxEvent {
+		return domain.OutboxEvent{ID: id, Type: typ, AggregateType: domain.AggregatePullRequest, AggregateID: prID}
+	}
+	repo := newMemoryRepo(
+		pr(1, "pr-1", domain.EventPRCreated),
+		pr(2, "pr-2", domain.EventPRCreated),
+		pr(3, "pr-1", domain.EventReviewersAssigned),
+		pr(4, "pr-2", domain.EventPRMerged),
+		pr(5, "pr-1", domain.EventPRMerged),
+	)
+	sink := &flakySink{name: "flaky", failOnce: map[int64]bool{1: true}}
+	relay := NewRelay(repo, []Sink{sink}, Options{BatchSize: 10, Backoff: time.Hour})
+
+	// The first run delivers pr-2 but holds pr-1 back behind its failed
+	// first event.
+	res, err := relay.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Published != 2 || res.Failed != 1 {
+		t.Fatalf("first run: %+v, want 2 published and 1 failed", res)
+	}
+	if e := repo.find(1); e.Attempts != 1 || e.LastError == "" {
+		t.Errorf("event 1 after failure: attempts %d, error %q", e.Attempts, e.LastError)
+	}
+
+	repo.next[1] = time.Time{}
+	if _, err := relay.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	byAggregate := map[string][]int64{}
+	for _, id := range sink.delivered {
+		e := repo.find(id)
+		byAggregate[e.AggregateID] = append(byAggregate[e.AggregateID], id)
+	}
+	for agg, ids := range byAggregate {
+		if !sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }) {
+			t.Errorf("%s delivered out of order: %v", agg, ids)
+		}
+	}
+	if got := byAggregate["pr-1"]; len(got) != 3 {
+		t.Errorf("pr-1 delivered %v, want events 1, 3 and 5", got)
+	}
+	if got := byAggregate["pr-2"]; len(got) != 2 {
+		t.Errorf("pr-2 delivered %v, want events 2 and 4", got)
+	}
+}
+
+func TestRelayRetriesOnlyFailedSinks(t *testing.T) {
+	repo := newMemoryRepo(domain.OutboxEvent{ID: 1, Type: domain.EventReviewersAssigned, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1"})
+	broker := &flakySink{name: "nats reviewer.events"}
+	notifications := &flakySink{name: "notifications", failOnce: map[int64]bool{1: true}}
+	relay := NewRelay(repo, []Sink{broker, notifications}, Options{BatchSize: 10, Backoff: time.Hour})
+
+	res, err := relay.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Failed != 1 || repo.find(1).PublishedAt != nil {
+		t.Fatalf("first run: %+v, want the event to fail", res)
+	}
+
+	repo.next[1] = time.Time{}
+	res, err = relay.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Published != 1 || repo.find(1).PublishedAt == nil {
+		t.Fatalf("second run: %+v, want the event published", res)
+	}
+	if len(broker.delivered) != 1 {
+		t.Errorf("broker got the event %d times, want once", len(broker.delivered))
+	}
+	if len(notifications.delivered) != 1 {
+		t.Errorf("notifications got the event %d times, want once", len(notifications.delivered))
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(nil, nil, Options{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := r.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
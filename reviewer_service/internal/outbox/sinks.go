@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"reviewer_service/internal/domain"
+)
+
+// Log writes events to the service log.
+type Log struct{}
+
+func (Log) Name() string { return "log" }
+
+func (Log) Publish(_ context.Context, event domain.OutboxEvent) error {
+	log.Printf("Event %d %s for %s %s: %s", event.ID, event.Type, event.AggregateType, event.AggregateID, event.Payload)
+	return nil
+}
+
+// Webhook POSTs the event envelope as JSON. With a Secret the body is
+// signed with HMAC-SHA256 in the X-Signature-256 header. Any status other
+// than 2xx counts as a failure.
+type Webhook struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (w *Webhook) Name() string { return "webhook " + w.URL }
+
+func (w *Webhook) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-Version", strconv.Itoa(EnvelopeVersion))
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Message is what a Publisher sends to the broker. ID identifies the
+// message for brokers that deduplicate.
+type Message struct {
+	ID      string
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+// Publisher is a message broker client. Publish returns once the broker has
+// accepted the message; messages with the same key must keep their order,
+// e.g. by landing in the same partition.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// Partition key modes of Broker.
+const (
+	// KeyAggregate keys messages by aggregate ID, i.e. the PR ID for PR
+	// events.
+	KeyAggregate = "aggregate"
+	KeyType      = "type"
+	KeyNone      = "none"
+)
+
+// Broker publishes the event envelope through Publisher. With
+// SubjectPerType the event type is appended to Topic, giving NATS subjects
+// such as "reviewer.events.pull_request.created".
+type Broker struct {
+	Kind           string
+	Topic          string
+	SubjectPerType bool
+	Key            string
+	Publisher      Publisher
+}
+
+func (b *Broker) Name() string { return b.Kind + " " + b.Topic }
+
+func (b *Broker) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	msg := Message{
+		ID:    strconv.FormatInt(event.ID, 10),
+		Topic: b.Topic,
+		Value: body,
+		Headers: map[string]string{
+			"Event-ID":      strconv.FormatInt(event.ID, 10),
+			"Event-Type":    event.Type,
+			"Event-Version": strconv.Itoa(EnvelopeVersion),
+		},
+	}
+	if b.SubjectPerType {
+		msg.Topic += "." + event.Type
+	}
+	switch b.Key {
+	case KeyAggregate:
+		msg.Key = event.AggregateID
+	case KeyType:
+		msg.Key = event.Type
+	}
+	return b.Publisher.Publish(ctx, msg)
+}
+
+// Handler hands events to an in-process consumer such as the notification
+// service.
+type Handler struct {
+	Label  string
+	Handle func(ctx context.Context, event domain.OutboxEvent) error
+}
+
+func (h Handler) Name() string { return h.Label }
+
+func (h Handler) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	return h.Handle(ctx, event)
+}
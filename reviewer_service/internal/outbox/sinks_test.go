@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+)
+
+// recordingPublisher stands in for a broker client and keeps what the
+// broker sink hands it.
+type recordingPublisher struct {
+	msgs []Message
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg Message) error {
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+func TestBrokerEnvelope(t *testing.T) {
+	created := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		event   domain.OutboxEvent
+		subject string
+		key     string
+	}{
+		{
+			name:    "pr created",
+			event:   domain.OutboxEvent{ID: 1, Type: domain.EventPRCreated, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{"title":"Add search","author_id":"u1"}`)},
+			subject: "reviewer.events.pull_request.created",
+			key:     "pr-1",
+		},
+		{
+			name:    "reviewer assigned",
+			event:   domain.OutboxEvent{ID: 2, Type: domain.EventReviewersAssigned, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{"reviewer_ids":["u2","u3"]}`)},
+			subject: "reviewer.events.pull_request.reviewers_assigned",
+			key:     "pr-1",
+		},
+		{
+			name:    "reviewer removed",
+			event:   domain.OutboxEvent{ID: 3, Type: domain.EventReviewerRemoved, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{"reviewer_id":"u2"}`)},
+			subject: "reviewer.events.pull_request.reviewer_removed",
+			key:     "pr-1",
+		},
+		{
+			name:    "pr merged",
+			event:   domain.OutboxEvent{ID: 4, Type: domain.EventPRMerged, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{}`)},
+			subject: "reviewer.events.pull_request.merged",
+			key:     "pr-1",
+		},
+		{
+			name:    "user deactivated",
+			event:   domain.OutboxEvent{ID: 5, Type: domain.EventUserDeactivated, AggregateType: domain.AggregateUser, AggregateID: "u3", Payload: []byte(`{}`)},
+			subject: "reviewer.events.user.deactivated",
+			key:     "u3",
+		},
+		{
+			name:    "team changed",
+			event:   domain.OutboxEvent{ID: 6, Type: domain.EventTeamChanged, AggregateType: domain.AggregateTeam, AggregateID: "7", Payload: []byte(`{"field":"parent"}`)},
+			subject: "reviewer.events.team.changed",
+			key:     "7",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &recordingPublisher{}
+			b := &Broker{Kind: "nats", Topic: "reviewer.events", SubjectPerType: true, Key: KeyAggregate, Publisher: p}
+			tc.event.CreatedAt = created
+			if err := b.Publish(context.Background(), tc.event); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if len(p.msgs) != 1 {
+				t.Fatalf("got %d messages, want 1", len(p.msgs))
+			}
+			msg := p.msgs[0]
+
+			if msg.Topic != tc.subject {
+				t.Errorf("subject = %q, want %q", msg.Topic, tc.subject)
+			}
+			if msg.Key != tc.key {
+				t.Errorf("key = %q, want %q", msg.Key, tc.key)
+			}
+			if msg.Headers["Event-Version"] != "1" || msg.Headers["Event-Type"] != tc.event.Type {
+				t.Errorf("headers = %v", msg.Headers)
+			}
+
+			var env Envelope
+			if err := json.Unmarshal(msg.Value, &env); err != nil {
+				t.Fatalf("envelope: %v", err)
+			}
+			if env.Version != EnvelopeVersion {
+				t.Errorf("version = %d, want %d", env.Version, EnvelopeVersion)
+			}
+			if env.ID != tc.event.ID || env.Type != tc.event.Type || env.AggregateType != tc.event.AggregateType || env.AggregateID != tc.event.AggregateID {
+				t.Errorf("envelope = %+v, want event %+v", env, tc.event)
+			}
+			if !env.CreatedAt.Equal(created) {
+				t.Errorf("created_at = %s, want %s", env.CreatedAt, created)
+			}
+			if string(env.Payload) != string(tc.event.Payload) {
+				t.Errorf("payload = %s, want %s", env.Payload, tc.event.Payload)
+			}
+		})
+	}
+}
+
+func TestBrokerKeyModes(t *testing.T) {
+	event := domain.OutboxEvent{ID: 1, Type: domain.EventPRMerged, AggregateType: domain.AggregatePullRequest, AggregateID: "pr-1", Payload: []byte(`{}`)}
+	for mode, want := range map[string]string{KeyAggregate: "pr-1", KeyType: domain.EventPRMerged, KeyNone: ""} {
+		p := &recordingPublisher{}
+		b := &Broker{Kind: "kafka", Topic: "reviewer-events", Key: mode, Publisher: p}
+		if err := b.Publish(context.Background(), event); err != nil {
+			t.Fatalf("%s: Publish: %v", mode, err)
+		}
+		if got := p.msgs[0]; got.Key != want || got.Topic != "reviewer-events" {
+			t.Errorf("%s: topic %q key %q, want reviewer-events and %q", mode, got.Topic, got.Key, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reviewer_service/internal/domain"
+	"time"
+)
+
+// unavailableNow matches users (aliased u) with an absence covering the
+// current moment. Weekdays are evaluated in the user's timezone.
+const unavailableNow = `EXISTS (
+	SELECT 1 FROM user_absences a
+	WHERE a.user_id = u.id
+		AND a.starts_at <= NOW()
+		AND (a.ends_at IS NULL OR a.ends_at > NOW())
+		AND (a.weekdays = 0 OR a.weekdays & (1 << EXTRACT(DOW FROM NOW() AT TIME ZONE u.timezone)::int) != 0)
+)`
+
+type AbsenceRepository interface {
+	Create(ctx context.Context, a *domain.Absence) error
+	// UpsertExternal creates or updates the absence identified by
+	// (UserID, ExternalUID) and reports whether it was newly created.
+	UpsertExternal(ctx context.Context, a *domain.Absence) (bool, error)
+	Delete(ctx context.Context, userID string, id int64) (bool, error)
+	DeleteExternal(ctx context.Context, userID, externalUID string) error
+	ListByUser(ctx context.Context, userID string) ([]domain.Absence, error)
+	ListPendingHandover(ctx context.Context, startsBefore time.Time) ([]domain.Absence, error)
+	MarkHandedOver(ctx context.Context, id int64) error
+}
+
+type PostgresAbsenceRepository struct {
+	db *sql.DB
+}
+
+func NewAbsenceRepository(db *sql.DB) *PostgresAbsenceRepository {
+	return &PostgresAbsenceRepository{db: db}
+}
+
+const absenceColumns = "id, user_id, kind, starts_at, ends_at, weekdays, note, source, COALESCE(external_uid, ''), handed_over_at, created_at"
+
+func scanAbsence(row rowScanner) (domain.Absence, error) {
+	var a domain.Absence
+	var endsAt, handedOverAt sql.NullTime
+	var weekdays int
+	err := row.Scan(&a.ID, &a.UserID, &a.Kind, &a.StartsAt, &endsAt, &weekdays, &a.Note, &a.Source, &a.ExternalUID, &handedOverAt, &a.CreatedAt)
+	if err != nil {
+		return a, err
+	}
+	if endsAt.Valid {
+		a.EndsAt = &endsAt.Time
+	}
+	if handedOverAt.Valid {
+		a.HandedOverAt = &handedOverAt.Time
+	}
+	a.Weekdays = weekdaysFromMask(weekdays)
+	return a, nil
+}
+
+func weekdaysMask(days []time.Weekday) int {
+	mask := 0
+	for _, d := range days {
+		mask |= 1 << uint(d)
+	}
+	return mask
+}
+
+func weekdaysFromMask(mask int) []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if mask&(1<<uint(d)) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func nullableExternalUID(uid string) sql.NullString {
+	return sql.NullString{String: uid, Valid: uid != ""}
+}
+
+func (r *PostgresAbsenceRepository) Create(ctx context.Context, a *domain.Absence) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO user_absences (user_id, kind, starts_at, ends_at, weekdays, note, source, external_uid)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, a.UserID, a.Kind, a.StartsAt, a.EndsAt, weekdaysMask(a.Weekdays), a.Note, a.Source, nullableExternalUID(a.ExternalUID),
+	).Scan(&a.ID, &a.CreatedAt)
+}
+
+func (r *PostgresAbsenceRepository) UpsertExternal(ctx context.Context, a *domain.Absence) (bool, error) {
+	var created bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO user_absences (user_id, kind, starts_at, ends_at, weekdays, note, source, external_uid)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, external_uid) WHERE external_uid IS NOT NULL DO UPDATE
+		SET kind = EXCLUDED.kind, starts_at = EXCLUDED.starts_at, ends_at = EXCLUDED.ends_at,
+			weekdays = EXCLUDED.weekdays, note = EXCLUDED.note,
+			handed_over_at = CASE WHEN user_absences.starts_at = EXCLUDED.starts_at THEN user_absences.handed_over_at END
+		RETURNING id, created_at, (xmax = 0)
+	`, a.UserID, a.Kind, a.StartsAt, a.EndsAt, weekdaysMask(a.Weekdays), a.Note, a.Source, a.ExternalUID,
+	).Scan(&a.ID, &a.CreatedAt, &created)
+	return created, err
+}
+
+func (r *PostgresAbsenceRepository) Delete(ctx context.Context, userID string, id int64) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM user_absences WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PostgresAbsenceRepository) DeleteExternal(ctx context.Context, userID, externalUID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM user_absences WHERE user_id = $1 AND external_uid = $2", userID, externalUID)
+	return err
+}
+
+func (r *PostgresAbsenceRepository) ListByUser(ctx context.Context, userID string) ([]domain.Absence, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+absenceColumns+" FROM user_absences WHERE user_id = $1 ORDER BY starts_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAbsences(rows)
+}
+
+// ListPendingHandover returns vacations and sick leaves that start before
+// startsBefore, have not ended and whose reviews were not handed over yet.
+func (r *PostgresAbsenceRepository) ListPendingHandover(ctx context.Context, startsBefore time.Time) ([]domain.Absence, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+absenceColumns+`
+		FROM user_absences
+		WHERE handed_over_at IS NULL
+			AND kind IN ('vacation', 'sick_leave')
+			AND starts_at <= $1
+			AND (ends_at IS NULL OR ends_at > NOW())
+		ORDER BY starts_at, id
+	`, startsBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAbsences(rows)
+}
+
+func (r *PostgresAbsenceRepository) MarkHandedOver(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE user_absences SET handed_over_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+func scanAbsences(rows *sql.Rows) ([]domain.Absence, error) {
+	var absences []domain.Absence
+	for rows.Next() {
+		a, err := scanAbsence(rows)
+		if err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+	return absences, rows.Err()
+}
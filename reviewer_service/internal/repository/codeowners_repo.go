@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reviewer_service/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type CodeOwnersRepository interface {
+	// ReplaceRules swaps the team's rules for rules, keeping their order.
+	ReplaceRules(ctx context.Context, teamID int64, rules []domain.CodeOwnerRule) error
+	GetRules(ctx context.Context, teamID int64) ([]domain.CodeOwnerRule, error)
+}
+
+type PostgresCodeOwnersRepository struct {
+	db *sql.DB
+}
+
+func NewCodeOwnersRepository(db *sql.DB) *PostgresCodeOwnersRepository {
+	return &PostgresCodeOwnersRepository{db: db}
+}
+
+func (r *PostgresCodeOwnersRepository) ReplaceRules(ctx context.Context, teamID int64, rules []domain.CodeOwnerRule) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		if _, err := q.ExecContext(ctx, "DELETE FROM code_owner_rules WHERE team_id = $1", teamID); err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+
+		stmt, err := q.PrepareContext(ctx, "INSERT INTO code_owner_rules (team_id, position, pattern, owner_ids) VALUES ($1, $2, $3, $4)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, rule := range rules {
+			if _, err := stmt.ExecContext(ctx, teamID, i, rule.Pattern, pq.Array(rule.OwnerIDs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PostgresCodeOwnersRepository) GetRules(ctx context.Context, teamID int64) ([]domain.CodeOwnerRule, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT pattern, owner_ids FROM code_owner_rules WHERE team_id = $1 ORDER BY position", teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.CodeOwnerRule
+	for rows.Next() {
+		var rule domain.CodeOwnerRule
+		if err := rows.Scan(&rule.Pattern, pq.Array(&rule.OwnerIDs)); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
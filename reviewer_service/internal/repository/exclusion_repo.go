@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reviewer_service/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type ExclusionRepository interface {
+	// Add creates the exclusion or updates the one for the same pair.
+	Add(ctx context.Context, exclusion domain.ReviewerExclusion) error
+	// Remove deletes the exclusion of excludedUserID by userID, or a mutual
+	// one stored the other way round, and reports whether one existed.
+	Remove(ctx context.Context, userID, excludedUserID string) (bool, error)
+	// ListForUser returns the exclusions userID is on either side of.
+	ListForUser(ctx context.Context, userID string) ([]domain.ReviewerExclusion, error)
+	// ExcludedReviewers returns the users who must not review PRs written
+	// by authorIDs.
+	ExcludedReviewers(ctx context.Context, authorIDs []string) ([]string, error)
+}
+
+type PostgresExclusionRepository struct {
+	db *sql.DB
+}
+
+func NewExclusionRepository(db *sql.DB) *PostgresExclusionRepository {
+	return &PostgresExclusionRepository{db: db}
+}
+
+func (r *PostgresExclusionRepository) Add(ctx context.Context, e domain.ReviewerExclusion) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO reviewer_exclusions (user_id, excluded_user_id, mutual, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, excluded_user_id) DO UPDATE SET mutual = EXCLUDED.mutual, reason = EXCLUDED.reason
+	`, e.UserID, e.ExcludedUserID, e.Mutual, e.Reason)
+	return err
+}
+
+func (r *PostgresExclusionRepository) Remove(ctx context.Context, userID, excludedUserID string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM reviewer_exclusions
+		WHERE (user_id = $1 AND excluded_user_id = $2)
+			OR (mutual AND user_id = $2 AND excluded_user_id = $1)
+	`, userID, excludedUserID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PostgresExclusionRepository) ListForUser(ctx context.Context, userID string) ([]domain.ReviewerExclusion, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT user_id, excluded_user_id, mutual, reason, created_at
+		FROM reviewer_exclusions
+		WHERE user_id = $1 OR excluded_user_id = $1
+		ORDER BY created_at, user_id, excluded_user_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exclusions []domain.ReviewerExclusion
+	for rows.Next() {
+		var e domain.ReviewerExclusion
+		if err := rows.Scan(&e.UserID, &e.ExcludedUserID, &e.Mutual, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, e)
+	}
+	return exclusions, rows.Err()
+}
+
+func (r *PostgresExclusionRepository) ExcludedReviewers(ctx context.Context, authorIDs []string) ([]string, error) {
+	if len(authorIDs) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, conn(ctx, r.db), `
+		SELECT excluded_user_id FROM reviewer_exclusions WHERE user_id = ANY($1)
+		UNION
+		SELECT user_id FROM reviewer_exclusions WHERE mutual AND excluded_user_id = ANY($1)
+	`, pq.Array(authorIDs))
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"reviewer_service/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type JobRepository interface {
+	// EnsureJob creates the job's state, first due at firstRun, unless it
+	// already exists.
+	EnsureJob(ctx context.Context, name string, firstRun time.Time) error
+	GetJob(ctx context.Context, name string) (*domain.JobState, error)
+	ListJobs(ctx context.Context) ([]domain.JobState, error)
+	StartRun(ctx context.Context, name string, at time.Time) error
+	// FinishRun records a run; an empty runErr marks it successful.
+	FinishRun(ctx context.Context, name string, at time.Time, runErr string, attempts int, nextRun time.Time) error
+	// TryLock takes the session-level advisory lock key on a dedicated
+	// connection without waiting. When ok, release must be called.
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+
+	Enqueue(ctx context.Context, job *domain.DelayedJob) error
+	// EnqueueOnce creates the job unless a pending job of the same name is
+	// already waiting to run, and reports whether it created one. A job
+	// that is running does not count, so changes made during its run are
+	// picked up by the next one.
+	EnqueueOnce(ctx context.Context, job *domain.DelayedJob) (bool, error)
+	// ClaimDue leases up to limit due pending jobs with one of names until
+	// lockedUntil and counts the attempt.
+	ClaimDue(ctx context.Context, names []string, now, lockedUntil time.Time, limit int) ([]domain.DelayedJob, error)
+	CompleteDelayed(ctx context.Context, id int64, at time.Time) error
+	RetryDelayed(ctx context.Context, id int64, runErr string, runAt time.Time) error
+	FailDelayed(ctx context.Context, id int64, runErr string, at time.Time) error
+}
+
+type PostgresJobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *PostgresJobRepository {
+	return &PostgresJobRepository{db: db}
+}
+
+const jobColumns = "name, next_run_at, attempts, last_started_at, last_finished_at, last_success_at, COALESCE(last_error, '')"
+
+func scanJob(row rowScanner) (domain.JobState, error) {
+	var j domain.JobState
+	var started, finished, succeeded sql.NullTime
+	if err := row.Scan(&j.Name, &j.NextRunAt, &j.Attempts, &started, &finished, &succeeded, &j.LastError); err != nil {
+		return j, err
+	}
+	j.LastStartedAt = nullableTime(started)
+	j.LastFinishedAt = nullableTime(finished)
+	j.LastSuccessAt = nullableTime(succeeded)
+	return j, nil
+}
+
+func nullableTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+func (r *PostgresJobRepository) EnsureJob(ctx context.Context, name string, firstRun time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (name, next_run_at) VALUES ($1, $2)
+		ON CONFLICT (name) DO NOTHING
+	`, name, firstRun)
+	return err
+}
+
+func (r *PostgresJobRepository) GetJob(ctx context.Context, name string) (*domain.JobState, error) {
+	j, err := scanJob(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+jobColumns+" FROM scheduled_jobs WHERE name = $1", name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *PostgresJobRepository) ListJobs(ctx context.Context) ([]domain.JobState, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+jobColumns+" FROM scheduled_jobs ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.JobState
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *PostgresJobRepository) StartRun(ctx context.Context, name string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE scheduled_jobs SET last_started_at = $1 WHERE name = $2", at, name)
+	return err
+}
+
+func (r *PostgresJobRepository) FinishRun(ctx context.Context, name string, at time.Time, runErr string, attempts int, nextRun time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE scheduled_jobs
+		SET last_finished_at = $1,
+			last_success_at = CASE WHEN $2 = '' THEN $1 ELSE last_success_at END,
+			last_error = NULLIF($2, ''),
+			attempts = $3,
+			next_run_at = $4
+		WHERE name = $5
+	`, at, runErr, attempts, nextRun, name)
+	return err
+}
+
+func (r *PostgresJobRepository) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	c, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	if err := c.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil || !ok {
+		c.Close()
+		return nil, false, err
+	}
+
+	release := func() {
+		if _, err := c.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("Failed to release job lock %d: %v", key, err)
+		}
+		c.Close()
+	}
+	return release, true, nil
+}
+
+const delayedJobColumns = "id, name, payload, run_at, status, attempts, COALESCE(last_error, ''), created_at, finished_at"
+
+func (r *PostgresJobRepository) Enqueue(ctx context.Context, job *domain.DelayedJob) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO delayed_jobs (name, payload, run_at) VALUES ($1, $2, $3)
+		RETURNING id, status, created_at
+	`, job.Name, job.Payload, job.RunAt).Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+func (r *PostgresJobRepository) EnqueueOnce(ctx context.Context, job *domain.DelayedJob) (bool, error) {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO delayed_jobs (name, payload, run_at)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM delayed_jobs
+			WHERE name = $1 AND status = 'pending'
+				AND (locked_until IS NULL OR locked_until <= NOW())
+		)
+		RETURNING id, status, created_at
+	`, job.Name, job.Payload, job.RunAt).Scan(&job.ID, &job.Status, &job.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresJobRepository) ClaimDue(ctx context.Context, names []string, now, lockedUntil time.Time, limit int) ([]domain.DelayedJob, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		UPDATE delayed_jobs SET locked_until = $3, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM delayed_jobs
+			WHERE status = 'pending' AND run_at <= $1 AND name = ANY($2)
+				AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY run_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+delayedJobColumns, now, pq.Array(names), lockedUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.DelayedJob
+	for rows.Next() {
+		var j domain.DelayedJob
+		var finished sql.NullTime
+		if err := rows.Scan(&j.ID, &j.Name, &j.Payload, &j.RunAt, &j.Status, &j.Attempts, &j.LastError, &j.CreatedAt, &finished); err != nil {
+			return nil, err
+		}
+		j.FinishedAt = nullableTime(finished)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *PostgresJobRepository) CompleteDelayed(ctx context.Context, id int64, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE delayed_jobs SET status = 'done', finished_at = $1, locked_until = NULL, last_error = NULL
+		WHERE id = $2
+	`, at, id)
+	return err
+}
+
+func (r *PostgresJobRepository) RetryDelayed(ctx context.Context, id int64, runErr string, runAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE delayed_jobs SET run_at = $1, locked_until = NULL, last_error = $2
+		WHERE id = $3
+	`, runAt, runErr, id)
+	return err
+}
+
+func (r *PostgresJobRepository) FailDelayed(ctx context.Context, id int64, runErr string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE delayed_jobs SET status = 'failed', finished_at = $1, locked_until = NULL, last_error = $2
+		WHERE id = $3
+	`, at, runErr, id)
+	return err
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reviewer_service/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type NotificationRepository interface {
+	// GetPreferences returns the preferences of the existing users among
+	// userIDs; users who never set any get defaultMode.
+	GetPreferences(ctx context.Context, userIDs []string, defaultMode string) ([]domain.NotificationPreferences, error)
+	SetPreferences(ctx context.Context, prefs *domain.NotificationPreferences) error
+	// ListDigestRecipients returns the active users whose mode, explicit or
+	// defaultMode, is digest.
+	ListDigestRecipients(ctx context.Context, defaultMode string) ([]domain.DigestRecipient, error)
+	MarkDigestSent(ctx context.Context, userID string, at time.Time) error
+	// ListActive returns the preferences of active users whose mode is not
+	// off.
+	ListActive(ctx context.Context, defaultMode string) ([]domain.NotificationPreferences, error)
+	// SetEmail replaces the user's email and unsubscribe token and
+	// subscribes them again; an empty email clears both.
+	SetEmail(ctx context.Context, userID, email, token string) error
+	// Unsubscribe stops emails to the user holding token and reports
+	// whether there is one.
+	Unsubscribe(ctx context.Context, token string) (bool, error)
+}
+
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+// preferencesColumns selects the preferences of users aliased u joined to
+// notification_preferences n, with $1 as the default mode.
+const preferencesColumns = `u.id, u.username, COALESCE(n.mode, $1), COALESCE(n.slack_webhook_url, ''), COALESCE(n.slack_member_id, ''),
+	COALESCE(u.email, ''), u.email_unsubscribed, COALESCE(u.unsubscribe_token, '')`
+
+func preferencesDest(p *domain.NotificationPreferences) []interface{} {
+	return []interface{}{&p.UserID, &p.Username, &p.Mode, &p.SlackWebhookURL, &p.SlackMemberID, &p.Email, &p.EmailUnsubscribed, &p.UnsubscribeToken}
+}
+
+func scanPreferences(rows *sql.Rows) ([]domain.NotificationPreferences, error) {
+	var prefs []domain.NotificationPreferences
+	for rows.Next() {
+		var p domain.NotificationPreferences
+		if err := rows.Scan(preferencesDest(&p)...); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) GetPreferences(ctx context.Context, userIDs []string, defaultMode string) ([]domain.NotificationPreferences, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+preferencesColumns+`
+		FROM users u
+		LEFT JOIN notification_preferences n ON n.user_id = u.id
+		WHERE u.id = ANY($2)
+		ORDER BY u.id
+	`, defaultMode, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPreferences(rows)
+}
+
+func (r *PostgresNotificationRepository) SetPreferences(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, mode, slack_webhook_url, slack_member_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (user_id) DO UPDATE
+		SET mode = EXCLUDED.mode,
+			slack_webhook_url = EXCLUDED.slack_webhook_url,
+			slack_member_id = EXCLUDED.slack_member_id,
+			updated_at = NOW()
+	`, prefs.UserID, prefs.Mode, prefs.SlackWebhookURL, prefs.SlackMemberID)
+	return err
+}
+
+func (r *PostgresNotificationRepository) ListDigestRecipients(ctx context.Context, defaultMode string) ([]domain.DigestRecipient, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+preferencesColumns+`, u.last_digest_at, `+scheduleColumns+`
+		FROM users u
+		LEFT JOIN notification_preferences n ON n.user_id = u.id
+		WHERE u.is_active AND COALESCE(n.mode, $1) = 'digest'
+		ORDER BY u.id
+	`, defaultMode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []domain.DigestRecipient
+	for rows.Next() {
+		var rcpt domain.DigestRecipient
+		var lastDigestAt sql.NullTime
+		sched, done := scheduleDest(&rcpt.Schedule)
+		dest := append(preferencesDest(&rcpt.NotificationPreferences), &lastDigestAt)
+		if err := rows.Scan(append(dest, sched...)...); err != nil {
+			return nil, err
+		}
+		done()
+		rcpt.LastDigestAt = nullableTime(lastDigestAt)
+		recipients = append(recipients, rcpt)
+	}
+	return recipients, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) MarkDigestSent(ctx context.Context, userID string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET last_digest_at = $1 WHERE id = $2", at, userID)
+	return err
+}
+
+func (r *PostgresNotificationRepository) ListActive(ctx context.Context, defaultMode string) ([]domain.NotificationPreferences, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+preferencesColumns+`
+		FROM users u
+		LEFT JOIN notification_preferences n ON n.user_id = u.id
+		WHERE u.is_active AND COALESCE(n.mode, $1) <> 'off'
+		ORDER BY u.id
+	`, defaultMode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPreferences(rows)
+}
+
+func (r *PostgresNotificationRepository) SetEmail(ctx context.Context, userID, email, token string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET email = NULLIF($1, ''), unsubscribe_token = NULLIF($2, ''), email_unsubscribed = FALSE
+		WHERE id = $3
+	`, email, token, userID)
+	return err
+}
+
+func (r *PostgresNotificationRepository) Unsubscribe(ctx context.Context, token string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET email_unsubscribed = TRUE WHERE unsubscribe_token = $1", token)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
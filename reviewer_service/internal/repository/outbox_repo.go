@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reviewer_service/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OutboxRepository interface {
+	// ListPending returns up to limit unpublished events that are due. Only
+	// the oldest unpublished event of each aggregate is returned, so its
+	// events are published one after another in order.
+	ListPending(ctx context.Context, now time.Time, limit int) ([]domain.OutboxEvent, error)
+	// MarkDelivered records that the named sink accepted the event.
+	MarkDelivered(ctx context.Context, id int64, sink string) error
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, publishErr string, nextAttempt time.Time) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+// appendEvent writes an event about the aggregate through q, so it is
+// committed or rolled back together with the change it describes.
+func appendEvent(ctx context.Context, q querier, aggregateType, aggregateID, eventType string, payload map[string]interface{}) error {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, "INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4)", aggregateType, aggregateID, eventType, data)
+	return err
+}
+
+func (r *PostgresOutboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT e.id, e.event_type, e.aggregate_type, e.aggregate_id, e.payload, e.created_at, e.attempts, COALESCE(e.last_error, ''), e.delivered_to
+		FROM outbox_events e
+		WHERE e.published_at IS NULL AND e.next_attempt_at <= $1
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events p
+				WHERE p.aggregate_type = e.aggregate_type AND p.aggregate_id = e.aggregate_id
+					AND p.published_at IS NULL AND p.id < e.id
+			)
+		ORDER BY e.id
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.AggregateType, &e.AggregateID, &e.Payload, &e.CreatedAt, &e.Attempts, &e.LastError, pq.Array(&e.DeliveredTo)); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *PostgresOutboxRepository) MarkDelivered(ctx context.Context, id int64, sink string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events
+		SET delivered_to = array_append(delivered_to, $1)
+		WHERE id = $2 AND NOT $1 = ANY(delivered_to)
+	`, sink, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE outbox_events SET published_at = $1, last_error = NULL WHERE id = $2", at, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id int64, publishErr string, nextAttempt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3
+	`, publishErr, nextAttempt, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM outbox_events WHERE published_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
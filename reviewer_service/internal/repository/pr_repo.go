@@ -1,25 +1,96 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"reviewer_service/internal/domain"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type PullRequestRepository interface {
-	Create(pr *domain.PullRequest) error
-	GetByID(id string) (*domain.PullRequest, error)
-	AssignReviewers(prID string, reviewerIDs []string) error
-	Merge(prID string, mergedAt time.Time) error
-	GetReviewers(prID string) ([]string, error)
-	ReplaceReviewer(prID, oldReviewerID, newReviewerID string) error
-	GetPRsByReviewer(reviewerID string) ([]*domain.PullRequest, error)
-	GetReviewStats() (map[string]int, error)
-	GetOpenPRsWithReviewers(userIDs []string) ([]*domain.PullRequest, error)
+	Create(ctx context.Context, pr *domain.PullRequest) error
+	GetByID(ctx context.Context, id string) (*domain.PullRequest, error)
+	AssignReviewers(ctx context.Context, prID string, reviewerIDs []string) error
+	Merge(ctx context.Context, prID string, mergedAt time.Time) error
+	GetReviewers(ctx context.Context, prID string) ([]string, error)
+	GetChangedFiles(ctx context.Context, prID string) ([]string, error)
+	GetRequiredSkills(ctx context.Context, prID string) ([]string, error)
+	GetCoAuthors(ctx context.Context, prID string) ([]string, error)
+	AssignShadow(ctx context.Context, prID string, shadow domain.ShadowReviewer) error
+	GetShadow(ctx context.Context, prID string) (*domain.ShadowReviewer, error)
+	// GetShadowStats counts shadow reviews per shadow reviewer.
+	GetShadowStats(ctx context.Context) (map[string]int, error)
+	// GetFeatureReviewers returns who reviewed other PRs of feature, most
+	// recent first.
+	GetFeatureReviewers(ctx context.Context, feature, excludePRID string) ([]string, error)
+	// GetRecentReviewerSets returns the reviewers of the author's latest PRs
+	// created since since, newest first and at most limit of them.
+	GetRecentReviewerSets(ctx context.Context, authorID, excludePRID string, since time.Time, limit int) ([][]string, error)
+	ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error
+	// RemoveReviewer unassigns the reviewer without a replacement and
+	// reports whether they were assigned.
+	RemoveReviewer(ctx context.Context, prID, reviewerID string) (bool, error)
+	RecordReviewerEvent(ctx context.Context, event *domain.ReviewerEvent) error
+	GetReviewerEvents(ctx context.Context, prID string) ([]domain.ReviewerEvent, error)
+	GetPRsByReviewer(ctx context.Context, reviewerID string) ([]*domain.PullRequest, error)
+	GetAssignments(ctx context.Context, prID string) ([]domain.ReviewAssignment, error)
+	// ListOpenAssignments returns the assignments on open PRs of teamID, or
+	// of all teams when teamID is 0, oldest first.
+	ListOpenAssignments(ctx context.Context, teamID int64) ([]domain.ReviewAssignment, error)
+	MarkBreached(ctx context.Context, prID, reviewerID string, at time.Time) error
+	MarkEscalated(ctx context.Context, prID, reviewerID string, at time.Time) error
+	GetReviewStats(ctx context.Context) (map[string]int, error)
+	GetTeamStats(ctx context.Context) (map[int64]domain.TeamStats, error)
+	GetOpenPRsWithReviewers(ctx context.Context, userIDs []string) ([]*domain.PullRequest, error)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+	SetNeedsAttention(ctx context.Context, prID, reason string) error
+	// CountByTeam and DeleteByTeam cover the PRs filed under the team,
+	// merged ones included.
+	CountByTeam(ctx context.Context, teamID int64) (int, error)
+	DeleteByTeam(ctx context.Context, teamID int64) error
+	// CountByAuthorsOutsideTeam counts the PRs of authorIDs filed under
+	// other teams or none.
+	CountByAuthorsOutsideTeam(ctx context.Context, authorIDs []string, teamID int64) (int, error)
+}
+
+const prColumns = "pr.id, pr.title, pr.author_id, COALESCE(pr.team_id, 0), COALESCE((SELECT name FROM teams WHERE id = pr.team_id), ''), pr.status, pr.created_at, pr.merged_at, pr.needs_attention, COALESCE(pr.attention_reason, ''), COALESCE(pr.feature, ''), COALESCE((SELECT slots FROM review_queue WHERE pr_id = pr.id), 0)"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPullRequest(row rowScanner) (*domain.PullRequest, error) {
+	var pr domain.PullRequest
+	var createdAt, mergedAt sql.NullTime
+	err := row.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.TeamID, &pr.TeamName, &pr.Status, &createdAt, &mergedAt, &pr.NeedsAttention, &pr.AttentionReason, &pr.Feature, &pr.PendingReviewers)
+	if err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		pr.CreatedAt = &createdAt.Time
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
+	return &pr, nil
+}
+
+func scanPullRequests(rows *sql.Rows) ([]*domain.PullRequest, error) {
+	var prs []*domain.PullRequest
+	for rows.Next() {
+		pr, err := scanPullRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
 }
 
 type PostgresPullRequestRepository struct {
@@ -30,168 +101,430 @@ func NewPullRequestRepository(db *sql.DB) *PostgresPullRequestRepository {
 	return &PostgresPullRequestRepository{db: db}
 }
 
-func (r *PostgresPullRequestRepository) Create(pr *domain.PullRequest) error {
-	_, err := r.db.Exec(`
-		INSERT INTO pull_requests (id, title, author_id, status, created_at, merged_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, pr.ID, pr.Title, pr.AuthorID, pr.Status, pr.CreatedAt, pr.MergedAt)
-	return err
+func (r *PostgresPullRequestRepository) Create(ctx context.Context, pr *domain.PullRequest) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO pull_requests (id, title, author_id, team_id, status, created_at, merged_at, feature)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, NULLIF($8, ''))
+		`, pr.ID, pr.Title, pr.AuthorID, pr.TeamID, pr.Status, pr.CreatedAt, pr.MergedAt, pr.Feature)
+		if err != nil {
+			return err
+		}
+		if len(pr.ChangedFiles) > 0 {
+			_, err = q.ExecContext(ctx, "INSERT INTO pull_request_files (pr_id, path) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", pr.ID, pq.Array(pr.ChangedFiles))
+			if err != nil {
+				return err
+			}
+		}
+		if len(pr.RequiredSkills) > 0 {
+			_, err = q.ExecContext(ctx, "INSERT INTO pull_request_skills (pr_id, skill) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", pr.ID, pq.Array(pr.RequiredSkills))
+			if err != nil {
+				return err
+			}
+		}
+		if len(pr.CoAuthors) > 0 {
+			_, err = q.ExecContext(ctx, "INSERT INTO pull_request_co_authors (pr_id, user_id) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", pr.ID, pq.Array(pr.CoAuthors))
+			if err != nil {
+				return err
+			}
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, pr.ID, domain.EventPRCreated, map[string]interface{}{
+			"title":      pr.Title,
+			"author_id":  pr.AuthorID,
+			"co_authors": pr.CoAuthors,
+			"status":     pr.Status,
+			"feature":    pr.Feature,
+		})
+	})
 }
 
-func (r *PostgresPullRequestRepository) AssignReviewers(prID string, reviewerIDs []string) error {
+func (r *PostgresPullRequestRepository) AssignReviewers(ctx context.Context, prID string, reviewerIDs []string) error {
 	if len(reviewerIDs) == 0 {
 		return nil
 	}
-	if len(reviewerIDs) > 2 {
-		reviewerIDs = reviewerIDs[:2]
+
+	return withTx(ctx, r.db, func(q querier) error {
+		stmt, err := q.PrepareContext(ctx, "INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, id := range reviewerIDs {
+			_, err := stmt.ExecContext(ctx, prID, id)
+			if err != nil {
+				return err
+			}
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventReviewersAssigned, map[string]interface{}{"reviewer_ids": reviewerIDs})
+	})
+}
+
+func (r *PostgresPullRequestRepository) GetByID(ctx context.Context, id string) (*domain.PullRequest, error) {
+	pr, err := scanPullRequest(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+prColumns+`
+		FROM pull_requests pr
+		WHERE pr.id = $1
+	`, id))
+	if err != nil {
+		return nil, err
 	}
 
-	tx, err := r.db.Begin()
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1", id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewerID string
+		if err := rows.Scan(&reviewerID); err != nil {
+			return nil, err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-	stmt, err := tx.Prepare("INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)")
+	pr.ChangedFiles, err = r.GetChangedFiles(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pr.RequiredSkills, err = r.GetRequiredSkills(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pr.CoAuthors, err = r.GetCoAuthors(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pr.Shadow, err = r.GetShadow(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
+	return pr, nil
+}
 
-	for _, id := range reviewerIDs {
-		_, err := stmt.Exec(prID, id)
+func (r *PostgresPullRequestRepository) Merge(ctx context.Context, prID string, mergedAt time.Time) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		res, err := q.ExecContext(ctx, `
+			UPDATE pull_requests
+			SET status = 'MERGED', merged_at = $1
+			WHERE id = $2 AND status = 'OPEN'
+		`, mergedAt, prID)
 		if err != nil {
 			return err
 		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventPRMerged, map[string]interface{}{"merged_at": mergedAt})
+	})
+}
+
+func (r *PostgresPullRequestRepository) GetReviewers(ctx context.Context, prID string) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1", prID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return tx.Commit()
+	var reviewers []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, id)
+	}
+	return reviewers, rows.Err()
 }
 
-func (r *PostgresPullRequestRepository) GetByID(id string) (*domain.PullRequest, error) {
-	var pr domain.PullRequest
-	var createdAt, mergedAt sql.NullTime
-	err := r.db.QueryRow(`
-		SELECT id, title, author_id, status, created_at, merged_at
-		FROM pull_requests
-		WHERE id = $1
-	`, id).Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+func (r *PostgresPullRequestRepository) GetChangedFiles(ctx context.Context, prID string) ([]string, error) {
+	return queryStrings(ctx, conn(ctx, r.db), "SELECT path FROM pull_request_files WHERE pr_id = $1 ORDER BY path", prID)
+}
+
+func (r *PostgresPullRequestRepository) GetRequiredSkills(ctx context.Context, prID string) ([]string, error) {
+	return queryStrings(ctx, conn(ctx, r.db), "SELECT skill FROM pull_request_skills WHERE pr_id = $1 ORDER BY skill", prID)
+}
+
+func (r *PostgresPullRequestRepository) GetCoAuthors(ctx context.Context, prID string) ([]string, error) {
+	return queryStrings(ctx, conn(ctx, r.db), "SELECT user_id FROM pull_request_co_authors WHERE pr_id = $1 ORDER BY user_id", prID)
+}
+
+func (r *PostgresPullRequestRepository) GetFeatureReviewers(ctx context.Context, feature, excludePRID string) ([]string, error) {
+	return queryStrings(ctx, conn(ctx, r.db), `
+		SELECT prr.reviewer_id
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.id = prr.pr_id
+		WHERE pr.feature = $1 AND pr.id != $2
+		GROUP BY prr.reviewer_id
+		ORDER BY MAX(pr.created_at) DESC
+	`, feature, excludePRID)
+}
+
+func (r *PostgresPullRequestRepository) GetRecentReviewerSets(ctx context.Context, authorID, excludePRID string, since time.Time, limit int) ([][]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT ARRAY(SELECT reviewer_id FROM pr_reviewers WHERE pr_id = pr.id)
+		FROM pull_requests pr
+		WHERE pr.author_id = $1 AND pr.id != $2 AND pr.created_at >= $3
+		ORDER BY pr.created_at DESC
+		LIMIT $4
+	`, authorID, excludePRID, since, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	if createdAt.Valid {
-		pr.CreatedAt = &createdAt.Time
+	var sets [][]string
+	for rows.Next() {
+		var reviewers []string
+		if err := rows.Scan(pq.Array(&reviewers)); err != nil {
+			return nil, err
+		}
+		sets = append(sets, reviewers)
 	}
-	if mergedAt.Valid {
-		pr.MergedAt = &mergedAt.Time
+	return sets, rows.Err()
+}
+
+func (r *PostgresPullRequestRepository) AssignShadow(ctx context.Context, prID string, shadow domain.ShadowReviewer) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		_, err := q.ExecContext(ctx, "INSERT INTO pr_shadow_reviewers (pr_id, reviewer_id, mentor_id) VALUES ($1, $2, NULLIF($3, ''))", prID, shadow.ReviewerID, shadow.MentorID)
+		if err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventShadowAssigned, map[string]interface{}{
+			"reviewer_id": shadow.ReviewerID,
+			"mentor_id":   shadow.MentorID,
+		})
+	})
+}
+
+func (r *PostgresPullRequestRepository) GetShadow(ctx context.Context, prID string) (*domain.ShadowReviewer, error) {
+	var shadow domain.ShadowReviewer
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT reviewer_id, COALESCE(mentor_id, '')
+		FROM pr_shadow_reviewers
+		WHERE pr_id = $1
+		ORDER BY assigned_at
+		LIMIT 1
+	`, prID).Scan(&shadow.ReviewerID, &shadow.MentorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &shadow, nil
+}
 
-	rows, err := r.db.Query("SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1", id)
+func (r *PostgresPullRequestRepository) GetShadowStats(ctx context.Context) (map[string]int, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT reviewer_id, COUNT(*) FROM pr_shadow_reviewers GROUP BY reviewer_id")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	stats := make(map[string]int)
 	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
 			return nil, err
 		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		stats[userID] = count
 	}
+	return stats, rows.Err()
+}
 
-	return &pr, nil
+// queryStrings returns the single text column of every row.
+func queryStrings(ctx context.Context, q querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
 }
 
-func (r *PostgresPullRequestRepository) Merge(prID string, mergedAt time.Time) error {
-	_, err := r.db.Exec(`
-		UPDATE pull_requests
-		SET status = 'MERGED', merged_at = $1
-		WHERE id = $2 AND status = 'OPEN'
-	`, mergedAt, prID)
-	return err
+func (r *PostgresPullRequestRepository) ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		_, err := q.ExecContext(ctx, "DELETE FROM pr_reviewers WHERE pr_id = $1 AND reviewer_id = $2", prID, oldReviewerID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, "INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)", prID, newReviewerID)
+		if err != nil {
+			return err
+		}
+
+		// The shadow follows whoever takes over from their mentor.
+		_, err = q.ExecContext(ctx, "UPDATE pr_shadow_reviewers SET mentor_id = $3 WHERE pr_id = $1 AND mentor_id = $2", prID, oldReviewerID, newReviewerID)
+		if err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventReviewerReplaced, map[string]interface{}{
+			"old_reviewer_id": oldReviewerID,
+			"new_reviewer_id": newReviewerID,
+		})
+	})
 }
 
-func (r *PostgresPullRequestRepository) GetReviewers(prID string) ([]string, error) {
-	rows, err := r.db.Query("SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1", prID)
+func (r *PostgresPullRequestRepository) RemoveReviewer(ctx context.Context, prID, reviewerID string) (bool, error) {
+	var removed bool
+	err := withTx(ctx, r.db, func(q querier) error {
+		res, err := q.ExecContext(ctx, "DELETE FROM pr_reviewers WHERE pr_id = $1 AND reviewer_id = $2", prID, reviewerID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		removed = n > 0
+
+		if !removed {
+			return nil
+		}
+
+		_, err = q.ExecContext(ctx, "UPDATE pr_shadow_reviewers SET mentor_id = NULL WHERE pr_id = $1 AND mentor_id = $2", prID, reviewerID)
+		if err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventReviewerRemoved, map[string]interface{}{"reviewer_id": reviewerID})
+	})
+	return removed, err
+}
+
+func (r *PostgresPullRequestRepository) RecordReviewerEvent(ctx context.Context, event *domain.ReviewerEvent) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO pr_reviewer_events (pr_id, action, reviewer_id, previous_reviewer_id, actor)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at
+	`, event.PullRequestID, event.Action, event.ReviewerID, event.PreviousReviewerID, event.Actor).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (r *PostgresPullRequestRepository) GetReviewerEvents(ctx context.Context, prID string) ([]domain.ReviewerEvent, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, pr_id, action, reviewer_id, COALESCE(previous_reviewer_id, ''), actor, created_at
+		FROM pr_reviewer_events
+		WHERE pr_id = $1
+		ORDER BY created_at, id
+	`, prID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reviewers []string
+	var events []domain.ReviewerEvent
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var e domain.ReviewerEvent
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.Action, &e.ReviewerID, &e.PreviousReviewerID, &e.Actor, &e.CreatedAt); err != nil {
 			return nil, err
 		}
-		reviewers = append(reviewers, id)
+		events = append(events, e)
 	}
-	return reviewers, nil
+	return events, rows.Err()
 }
 
-func (r *PostgresPullRequestRepository) ReplaceReviewer(prID, oldReviewerID, newReviewerID string) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("Failed to rollback transaction: %v", err)
+const assignmentColumns = "pr.id, pr.title, pr.author_id, COALESCE(pr.team_id, 0), prr.reviewer_id, prr.assigned_at, prr.breached_at, prr.escalated_at"
+
+func scanAssignments(rows *sql.Rows) ([]domain.ReviewAssignment, error) {
+	var assignments []domain.ReviewAssignment
+	for rows.Next() {
+		var a domain.ReviewAssignment
+		var breachedAt, escalatedAt sql.NullTime
+		if err := rows.Scan(&a.PullRequestID, &a.Title, &a.AuthorID, &a.TeamID, &a.ReviewerID, &a.AssignedAt, &breachedAt, &escalatedAt); err != nil {
+			return nil, err
+		}
+		if breachedAt.Valid {
+			a.BreachedAt = &breachedAt.Time
 		}
-	}()
+		if escalatedAt.Valid {
+			a.EscalatedAt = &escalatedAt.Time
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
 
-	_, err = tx.Exec("DELETE FROM pr_reviewers WHERE pr_id = $1 AND reviewer_id = $2", prID, oldReviewerID)
+func (r *PostgresPullRequestRepository) GetAssignments(ctx context.Context, prID string) ([]domain.ReviewAssignment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+assignmentColumns+`
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.id = prr.pr_id
+		WHERE pr.id = $1
+		ORDER BY prr.assigned_at, prr.reviewer_id
+	`, prID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	_, err = tx.Exec("INSERT INTO pr_reviewers (pr_id, reviewer_id) VALUES ($1, $2)", prID, newReviewerID)
+	return scanAssignments(rows)
+}
+
+func (r *PostgresPullRequestRepository) ListOpenAssignments(ctx context.Context, teamID int64) ([]domain.ReviewAssignment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+assignmentColumns+`
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.id = prr.pr_id
+		WHERE pr.status = 'OPEN' AND ($1 = 0 OR pr.team_id = $1)
+		ORDER BY prr.assigned_at, pr.id, prr.reviewer_id
+	`, teamID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return tx.Commit()
+	return scanAssignments(rows)
 }
 
-func (r *PostgresPullRequestRepository) GetPRsByReviewer(reviewerID string) ([]*domain.PullRequest, error) {
+func (r *PostgresPullRequestRepository) MarkBreached(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE pr_reviewers SET breached_at = $3 WHERE pr_id = $1 AND reviewer_id = $2 AND breached_at IS NULL", prID, reviewerID, at)
+	return err
+}
+
+func (r *PostgresPullRequestRepository) MarkEscalated(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE pr_reviewers SET escalated_at = $3 WHERE pr_id = $1 AND reviewer_id = $2 AND escalated_at IS NULL", prID, reviewerID, at)
+	return err
+}
+
+func (r *PostgresPullRequestRepository) GetPRsByReviewer(ctx context.Context, reviewerID string) ([]*domain.PullRequest, error) {
 	query := `
-		SELECT pr.id, pr.title, pr.author_id, pr.status, pr.created_at, pr.merged_at
+		SELECT ` + prColumns + `
 		FROM pull_requests pr
 		JOIN pr_reviewers prr ON pr.id = prr.pr_id
 		WHERE prr.reviewer_id = $1
 	`
-	rows, err := r.db.Query(query, reviewerID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, reviewerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prs []*domain.PullRequest
-	for rows.Next() {
-		var pr domain.PullRequest
-		var createdAt, mergedAt sql.NullTime
-		if err := rows.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt); err != nil {
-			return nil, err
-		}
-		if createdAt.Valid {
-			pr.CreatedAt = &createdAt.Time
-		}
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
-		}
-		prs = append(prs, &pr)
-	}
-	return prs, nil
+	return scanPullRequests(rows)
 }
 
-func (r *PostgresPullRequestRepository) GetReviewStats() (map[string]int, error) {
+func (r *PostgresPullRequestRepository) GetReviewStats(ctx context.Context) (map[string]int, error) {
 	query := `
 		SELECT reviewer_id, COUNT(*) as count
 		FROM pr_reviewers
 		GROUP BY reviewer_id
 	`
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -206,10 +539,38 @@ func (r *PostgresPullRequestRepository) GetReviewStats() (map[string]int, error)
 		}
 		stats[userID] = count
 	}
-	return stats, nil
+	return stats, rows.Err()
+}
+
+func (r *PostgresPullRequestRepository) GetTeamStats(ctx context.Context) (map[int64]domain.TeamStats, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT pr.team_id,
+			COUNT(DISTINCT pr.id),
+			COUNT(DISTINCT pr.id) FILTER (WHERE pr.status = 'OPEN'),
+			COUNT(rv.reviewer_id)
+		FROM pull_requests pr
+		LEFT JOIN pr_reviewers rv ON rv.pr_id = pr.id
+		WHERE pr.team_id IS NOT NULL
+		GROUP BY pr.team_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int64]domain.TeamStats)
+	for rows.Next() {
+		var teamID int64
+		var st domain.TeamStats
+		if err := rows.Scan(&teamID, &st.PullRequests, &st.OpenPullRequests, &st.ReviewAssignments); err != nil {
+			return nil, err
+		}
+		stats[teamID] = st
+	}
+	return stats, rows.Err()
 }
 
-func (r *PostgresPullRequestRepository) GetOpenPRsWithReviewers(userIDs []string) ([]*domain.PullRequest, error) {
+func (r *PostgresPullRequestRepository) GetOpenPRsWithReviewers(ctx context.Context, userIDs []string) ([]*domain.PullRequest, error) {
 	if len(userIDs) == 0 {
 		return []*domain.PullRequest{}, nil
 	}
@@ -222,32 +583,98 @@ func (r *PostgresPullRequestRepository) GetOpenPRsWithReviewers(userIDs []string
 	}
 
 	query := fmt.Sprintf(`
-		SELECT DISTINCT pr.id, pr.title, pr.author_id, pr.status, pr.created_at, pr.merged_at
+		SELECT DISTINCT %s
 		FROM pull_requests pr
 		JOIN pr_reviewers prr ON pr.id = prr.pr_id
 		WHERE pr.status = 'OPEN' AND prr.reviewer_id IN (%s)
+	`, prColumns, strings.Join(placeholders, ","))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPullRequests(rows)
+}
+
+func (r *PostgresPullRequestRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT prr.reviewer_id, COUNT(*)
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.id = prr.pr_id
+		WHERE pr.status = 'OPEN' AND prr.reviewer_id IN (%s)
+		GROUP BY prr.reviewer_id
 	`, strings.Join(placeholders, ","))
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prs []*domain.PullRequest
 	for rows.Next() {
-		var pr domain.PullRequest
-		var createdAt, mergedAt sql.NullTime
-		if err := rows.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt); err != nil {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
 			return nil, err
 		}
-		if createdAt.Valid {
-			pr.CreatedAt = &createdAt.Time
-		}
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
-		}
-		prs = append(prs, &pr)
+		counts[userID] = count
 	}
-	return prs, nil
+	return counts, rows.Err()
+}
+
+func (r *PostgresPullRequestRepository) SetNeedsAttention(ctx context.Context, prID, reason string) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		_, err := q.ExecContext(ctx, `
+			UPDATE pull_requests
+			SET needs_attention = true, attention_reason = $1
+			WHERE id = $2
+		`, reason, prID)
+		if err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.AggregatePullRequest, prID, domain.EventPRNeedsAttention, map[string]interface{}{"reason": reason})
+	})
+}
+
+func (r *PostgresPullRequestRepository) CountByTeam(ctx context.Context, teamID int64) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests WHERE team_id = $1", teamID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresPullRequestRepository) DeleteByTeam(ctx context.Context, teamID int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM pull_requests
+			WHERE team_id = $1
+			RETURNING id
+		)
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type)
+		SELECT $2, id, $3 FROM deleted
+	`, teamID, domain.AggregatePullRequest, domain.EventPRDeleted)
+	return err
+}
+
+func (r *PostgresPullRequestRepository) CountByAuthorsOutsideTeam(ctx context.Context, authorIDs []string, teamID int64) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM pull_requests
+		WHERE author_id = ANY($1) AND team_id IS DISTINCT FROM $2
+	`, pq.Array(authorIDs), teamID).Scan(&count)
+	return count, err
 }
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reviewer_service/internal/domain"
+)
+
+type ReviewQueueRepository interface {
+	// Enqueue adds the PR or, when it is already queued, sets its slots.
+	Enqueue(ctx context.Context, entry domain.QueueEntry) error
+	// LockEntry returns the PR's entry and locks it for the surrounding
+	// transaction; it returns nil when the PR is no longer queued or another
+	// drain holds the lock.
+	LockEntry(ctx context.Context, prID string) (*domain.QueueEntry, error)
+	List(ctx context.Context, byPriority bool) ([]domain.QueueEntry, error)
+	// IsEmpty reports whether no PR is waiting for reviewers.
+	IsEmpty(ctx context.Context) (bool, error)
+	SetSlots(ctx context.Context, prID string, slots int) error
+	Dequeue(ctx context.Context, prID string) error
+}
+
+type PostgresReviewQueueRepository struct {
+	db *sql.DB
+}
+
+func NewReviewQueueRepository(db *sql.DB) *PostgresReviewQueueRepository {
+	return &PostgresReviewQueueRepository{db: db}
+}
+
+func (r *PostgresReviewQueueRepository) Enqueue(ctx context.Context, entry domain.QueueEntry) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO review_queue (pr_id, slots, priority)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (pr_id) DO UPDATE SET slots = EXCLUDED.slots
+	`, entry.PullRequestID, entry.Slots, entry.Priority)
+	return err
+}
+
+func queueOrder(byPriority bool) string {
+	if byPriority {
+		return " ORDER BY priority DESC, enqueued_at, pr_id"
+	}
+	return " ORDER BY enqueued_at, pr_id"
+}
+
+func (r *PostgresReviewQueueRepository) LockEntry(ctx context.Context, prID string) (*domain.QueueEntry, error) {
+	var e domain.QueueEntry
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT pr_id, slots, priority, enqueued_at FROM review_queue
+		WHERE pr_id = $1
+		FOR UPDATE SKIP LOCKED
+	`, prID).Scan(&e.PullRequestID, &e.Slots, &e.Priority, &e.EnqueuedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *PostgresReviewQueueRepository) List(ctx context.Context, byPriority bool) ([]domain.QueueEntry, error) {
+	return r.list(ctx, "SELECT pr_id, slots, priority, enqueued_at FROM review_queue"+queueOrder(byPriority))
+}
+
+func (r *PostgresReviewQueueRepository) IsEmpty(ctx context.Context) (bool, error) {
+	var queued bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM review_queue)").Scan(&queued)
+	return !queued, err
+}
+
+func (r *PostgresReviewQueueRepository) list(ctx context.Context, query string) ([]domain.QueueEntry, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.QueueEntry
+	for rows.Next() {
+		var e domain.QueueEntry
+		if err := rows.Scan(&e.PullRequestID, &e.Slots, &e.Priority, &e.EnqueuedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *PostgresReviewQueueRepository) SetSlots(ctx context.Context, prID string, slots int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE review_queue SET slots = $1 WHERE pr_id = $2", slots, prID)
+	return err
+}
+
+func (r *PostgresReviewQueueRepository) Dequeue(ctx context.Context, prID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id = $1", prID)
+	return err
+}
@@ -1,15 +1,61 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"reviewer_service/internal/domain"
+	"strconv"
+	"time"
 )
 
 type TeamRepository interface {
-	Create(name string) (int64, error)
-	GetByName(name string) (*domain.Team, error)
-	GetByID(id int64) (*domain.Team, error)
-	Exists(name string) (bool, error)
+	Create(ctx context.Context, name string) (int64, error)
+	GetByName(ctx context.Context, name string) (*domain.Team, error)
+	GetByID(ctx context.Context, id int64) (*domain.Team, error)
+	Exists(ctx context.Context, name string) (bool, error)
+	Rename(ctx context.Context, id int64, name string) error
+	SetArchived(ctx context.Context, id int64, archived bool) error
+	Delete(ctx context.Context, id int64) error
+	SetParent(ctx context.Context, id int64, parentID *int64) error
+	SetMaxOpenReviews(ctx context.Context, id int64, limit *int) error
+	SetReviewSLA(ctx context.Context, id int64, sla, escalationAfter *time.Duration) error
+	GetChildren(ctx context.Context, id int64) ([]*domain.Team, error)
+	List(ctx context.Context) ([]*domain.Team, error)
+}
+
+const teamColumns = "t.id, t.name, t.archived_at, t.parent_id, COALESCE(p.name, ''), t.max_open_reviews, t.review_sla_seconds, t.escalation_after_seconds"
+
+const teamFrom = "FROM teams t LEFT JOIN teams p ON p.id = t.parent_id"
+
+func scanTeam(row rowScanner) (*domain.Team, error) {
+	var team domain.Team
+	var archivedAt sql.NullTime
+	var parentID, maxOpenReviews, reviewSLA, escalationAfter sql.NullInt64
+	if err := row.Scan(&team.ID, &team.Name, &archivedAt, &parentID, &team.ParentName, &maxOpenReviews, &reviewSLA, &escalationAfter); err != nil {
+		return nil, err
+	}
+	team.MaxOpenReviews = nullableInt(maxOpenReviews)
+	team.ReviewSLA = nullableSeconds(reviewSLA)
+	team.EscalationAfter = nullableSeconds(escalationAfter)
+	if archivedAt.Valid {
+		team.ArchivedAt = &archivedAt.Time
+	}
+	if parentID.Valid {
+		team.ParentID = &parentID.Int64
+	}
+	return &team, nil
+}
+
+func scanTeams(rows *sql.Rows) ([]*domain.Team, error) {
+	var teams []*domain.Team
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
 }
 
 type PostgresTeamRepository struct {
@@ -20,21 +66,25 @@ func NewTeamRepository(db *sql.DB) *PostgresTeamRepository {
 	return &PostgresTeamRepository{db: db}
 }
 
-func (r *PostgresTeamRepository) Exists(name string) (bool, error) {
+func (r *PostgresTeamRepository) Exists(ctx context.Context, name string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE name = $1)", name).Scan(&exists)
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE name = $1)", name).Scan(&exists)
 	return exists, err
 }
 
-func (r *PostgresTeamRepository) Create(name string) (int64, error) {
+func (r *PostgresTeamRepository) Create(ctx context.Context, name string) (int64, error) {
 	var id int64
-	err := r.db.QueryRow("INSERT INTO teams (name) VALUES ($1) RETURNING id", name).Scan(&id)
+	err := withTx(ctx, r.db, func(q querier) error {
+		if err := q.QueryRowContext(ctx, "INSERT INTO teams (name) VALUES ($1) RETURNING id", name).Scan(&id); err != nil {
+			return err
+		}
+		return teamEvent(ctx, q, id, domain.EventTeamCreated, map[string]interface{}{"name": name})
+	})
 	return id, err
 }
 
-func (r *PostgresTeamRepository) GetByName(name string) (*domain.Team, error) {
-	var team domain.Team
-	err := r.db.QueryRow("SELECT id, name FROM teams WHERE name = $1", name).Scan(&team.ID, &team.Name)
+func (r *PostgresTeamRepository) GetByName(ctx context.Context, name string) (*domain.Team, error) {
+	team, err := scanTeam(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+teamColumns+" "+teamFrom+" WHERE t.name = $1", name))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -42,7 +92,15 @@ func (r *PostgresTeamRepository) GetByName(name string) (*domain.Team, error) {
 		return nil, err
 	}
 
-	rows, err := r.db.Query("SELECT id, username, is_active FROM users WHERE team_id = $1", team.ID)
+	// Members include secondary memberships; TeamID on each member is their
+	// primary team.
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT u.id, u.username, u.is_active, COALESCE(p.team_id, 0), m.role
+		FROM team_memberships m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN team_memberships p ON p.user_id = u.id AND p.is_primary
+		WHERE m.team_id = $1
+	`, team.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -50,22 +108,115 @@ func (r *PostgresTeamRepository) GetByName(name string) (*domain.Team, error) {
 
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.Username, &user.IsActive)
+		err := rows.Scan(&user.ID, &user.Username, &user.IsActive, &user.TeamID, &user.Role)
 		if err != nil {
 			return nil, err
 		}
-		user.TeamID = team.ID
 		team.Members = append(team.Members, user)
 	}
 
-	return &team, nil
+	return team, rows.Err()
 }
 
-func (r *PostgresTeamRepository) GetByID(id int64) (*domain.Team, error) {
-	var team domain.Team
-	err := r.db.QueryRow("SELECT id, name FROM teams WHERE id = $1", id).Scan(&team.ID, &team.Name)
+func (r *PostgresTeamRepository) GetByID(ctx context.Context, id int64) (*domain.Team, error) {
+	return scanTeam(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+teamColumns+" "+teamFrom+" WHERE t.id = $1", id))
+}
+
+func (r *PostgresTeamRepository) Rename(ctx context.Context, id int64, name string) error {
+	return r.update(ctx, id, map[string]interface{}{"change": "renamed", "name": name},
+		"UPDATE teams SET name = $1 WHERE id = $2", name, id)
+}
+
+func (r *PostgresTeamRepository) SetArchived(ctx context.Context, id int64, archived bool) error {
+	if archived {
+		return r.update(ctx, id, map[string]interface{}{"change": "archived"},
+			"UPDATE teams SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1", id)
+	}
+	return r.update(ctx, id, map[string]interface{}{"change": "unarchived"},
+		"UPDATE teams SET archived_at = NULL WHERE id = $1", id)
+}
+
+func (r *PostgresTeamRepository) Delete(ctx context.Context, id int64) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		if _, err := q.ExecContext(ctx, "DELETE FROM teams WHERE id = $1", id); err != nil {
+			return err
+		}
+		return teamEvent(ctx, q, id, domain.EventTeamDeleted, nil)
+	})
+}
+
+func (r *PostgresTeamRepository) SetParent(ctx context.Context, id int64, parentID *int64) error {
+	return r.update(ctx, id, map[string]interface{}{"change": "parent", "parent_id": parentID},
+		"UPDATE teams SET parent_id = $1 WHERE id = $2", parentID, id)
+}
+
+func (r *PostgresTeamRepository) GetChildren(ctx context.Context, id int64) ([]*domain.Team, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+teamColumns+" "+teamFrom+" WHERE t.parent_id = $1 ORDER BY t.name", id)
 	if err != nil {
 		return nil, err
 	}
-	return &team, nil
+	defer rows.Close()
+	return scanTeams(rows)
+}
+
+func (r *PostgresTeamRepository) List(ctx context.Context) ([]*domain.Team, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+teamColumns+" "+teamFrom+" ORDER BY t.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTeams(rows)
+}
+
+func (r *PostgresTeamRepository) SetMaxOpenReviews(ctx context.Context, id int64, limit *int) error {
+	return r.update(ctx, id, map[string]interface{}{"change": "max_open_reviews", "max_open_reviews": limit},
+		"UPDATE teams SET max_open_reviews = $1 WHERE id = $2", limit, id)
+}
+
+// SetReviewSLA stores the team's SLA overrides; nil clears one.
+func (r *PostgresTeamRepository) SetReviewSLA(ctx context.Context, id int64, sla, escalationAfter *time.Duration) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"change":                   "review_sla",
+		"review_sla_seconds":       seconds(sla),
+		"escalation_after_seconds": seconds(escalationAfter),
+	}, "UPDATE teams SET review_sla_seconds = $1, escalation_after_seconds = $2 WHERE id = $3", seconds(sla), seconds(escalationAfter), id)
+}
+
+// update runs a single-statement change of the team and records it as a
+// team.changed event with payload.
+func (r *PostgresTeamRepository) update(ctx context.Context, id int64, payload map[string]interface{}, query string, args ...interface{}) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		if _, err := q.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		return teamEvent(ctx, q, id, domain.EventTeamChanged, payload)
+	})
+}
+
+func teamEvent(ctx context.Context, q querier, id int64, eventType string, payload map[string]interface{}) error {
+	return appendEvent(ctx, q, domain.AggregateTeam, strconv.FormatInt(id, 10), eventType, payload)
+}
+
+func seconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	s := int64(*d / time.Second)
+	return &s
+}
+
+func nullableSeconds(v sql.NullInt64) *time.Duration {
+	if !v.Valid {
+		return nil
+	}
+	d := time.Duration(v.Int64) * time.Second
+	return &d
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
 }
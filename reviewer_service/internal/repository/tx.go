@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx the repositories use, so the
+// same query code runs inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type txKey struct{}
+
+// TxManager runs a function inside a single database transaction. Repository
+// calls made with the context passed to fn join that transaction.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type PostgresTxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *PostgresTxManager {
+	return &PostgresTxManager{db: db}
+}
+
+func (m *PostgresTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, m.db, func(tx querier) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db when there is none.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(querier); ok {
+		return tx
+	}
+	return db
+}
+
+// withTx runs fn in the transaction carried by ctx, or in a new one that is
+// committed when fn succeeds.
+func withTx(ctx context.Context, db *sql.DB, fn func(q querier) error) error {
+	if tx, ok := ctx.Value(txKey{}).(querier); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,22 +1,47 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"reviewer_service/internal/domain"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {
-	UpsertMany(users []domain.User) error
-	GetActiveUsersInTeamExcluding(teamID int64, excludeUserID string) ([]domain.User, error)
-	GetTeamIDByUserID(userID string) (int64, error)
-	GetTeamByUserID(userID string) (*domain.Team, error)
-	DeactivateUsers(userIDs []string) error
-	SetIsActive(userID string, isActive bool) error
-	GetUserByID(userID string) (*domain.User, error)
+	UpsertMany(ctx context.Context, users []domain.User) error
+	GetActiveUsersInTeamExcluding(ctx context.Context, teamID int64, excludeUserIDs []string) ([]domain.User, error)
+	GetTeamIDByUserID(ctx context.Context, userID string) (int64, error)
+	GetTeamByUserID(ctx context.Context, userID string) (*domain.Team, error)
+	DeactivateUsers(ctx context.Context, userIDs []string) error
+	SetIsActive(ctx context.Context, userID string, isActive bool) error
+	SetWorkSchedule(ctx context.Context, userID string, schedule domain.WorkSchedule) error
+	SetSkills(ctx context.Context, userID string, skills []string) error
+	SetMaxOpenReviews(ctx context.Context, userID string, limit *int) error
+	SetShadowOptIn(ctx context.Context, userID string, optIn bool) error
+	GetSkills(ctx context.Context, userIDs []string) (map[string][]string, error)
+	GetUserByID(ctx context.Context, userID string) (*domain.User, error)
+	// ResolveUsers maps each name to the ID of the user with that ID or,
+	// failing that, the only user with that username. Unknown or ambiguous
+	// names are left out.
+	ResolveUsers(ctx context.Context, names []string) (map[string]string, error)
+	DeleteUsers(ctx context.Context, userIDs []string) error
+	MoveTeamMembers(ctx context.Context, fromTeamID, toTeamID int64) error
+	SetPrimaryTeam(ctx context.Context, userID string, teamID int64) error
+	AddMembership(ctx context.Context, userID string, teamID int64, role string) error
+	SetRole(ctx context.Context, userID string, teamID int64, role string) error
+	RemoveMembership(ctx context.Context, userID string, teamID int64) error
+	IsMember(ctx context.Context, userID string, teamID int64) (bool, error)
+	// IsAvailable reports whether no absence of userID covers the current
+	// moment.
+	IsAvailable(ctx context.Context, userID string) (bool, error)
+	GetMemberships(ctx context.Context, userID string) ([]domain.Membership, error)
+	RecordTeamMove(ctx context.Context, move *domain.TeamMove) error
+	GetTeamHistory(ctx context.Context, userID string) ([]domain.TeamMove, error)
 }
 
 type PostgresUserRepository struct {
@@ -27,40 +52,58 @@ func NewUserRepository(db *sql.DB) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
-func (r *PostgresUserRepository) UpsertMany(users []domain.User) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("Failed to rollback transaction: %v", err)
-		}
-	}()
+// skillsColumn selects the skills of the user aliased u as a text array.
+const skillsColumn = "ARRAY(SELECT s.skill FROM user_skills s WHERE s.user_id = u.id ORDER BY s.skill)"
 
-	stmt, err := tx.Prepare("INSERT INTO users (id, username, is_active, team_id) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET username = EXCLUDED.username, is_active = EXCLUDED.is_active, team_id = EXCLUDED.team_id")
-	if err != nil {
-		return err
+// scheduleColumns selects the working hours of users aliased u in the order
+// scanSchedule expects.
+const scheduleColumns = "u.timezone, EXTRACT(EPOCH FROM u.work_start)::int / 60, EXTRACT(EPOCH FROM u.work_end)::int / 60, u.work_days"
+
+// scheduleDest returns scan targets for scheduleColumns; call the returned
+// func after scanning to fill s.
+func scheduleDest(s *domain.WorkSchedule) ([]interface{}, func()) {
+	var days int
+	return []interface{}{&s.Timezone, &s.StartMinute, &s.EndMinute, &days}, func() {
+		s.Weekdays = weekdaysFromMask(days)
 	}
-	defer stmt.Close()
+}
 
-	for _, u := range users {
-		_, err := stmt.Exec(u.ID, u.Username, u.IsActive, u.TeamID)
+func (r *PostgresUserRepository) UpsertMany(ctx context.Context, users []domain.User) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		stmt, err := q.PrepareContext(ctx, "INSERT INTO users (id, username, is_active) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET username = EXCLUDED.username, is_active = EXCLUDED.is_active")
 		if err != nil {
 			return err
 		}
-	}
+		defer stmt.Close()
 
-	return tx.Commit()
+		for _, u := range users {
+			_, err := stmt.ExecContext(ctx, u.ID, u.Username, u.IsActive)
+			if err != nil {
+				return err
+			}
+			if err := setPrimaryTeam(ctx, q, u.ID, u.TeamID, u.Role); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *PostgresUserRepository) GetActiveUsersInTeamExcluding(teamID int64, excludeUserID string) ([]domain.User, error) {
+// GetActiveUsersInTeamExcluding returns active, currently available members
+// of teamID, primary or not, other than excludeUserIDs. TeamID on the returned users is still
+// their primary team; Role is their role in teamID.
+func (r *PostgresUserRepository) GetActiveUsersInTeamExcluding(ctx context.Context, teamID int64, excludeUserIDs []string) ([]domain.User, error) {
 	query := `
-		SELECT id, username, is_active, team_id
-		FROM users
-		WHERE team_id = $1 AND is_active = true AND id != $2
+		SELECT u.id, u.username, u.is_active, COALESCE(p.team_id, 0), m.role, ` + skillsColumn + `,
+			COALESCE(u.max_open_reviews, t.max_open_reviews), u.shadow_opt_in, ` + scheduleColumns + `
+		FROM team_memberships m
+		JOIN users u ON u.id = m.user_id
+		JOIN teams t ON t.id = m.team_id
+		LEFT JOIN team_memberships p ON p.user_id = u.id AND p.is_primary
+		WHERE m.team_id = $1 AND u.is_active = true AND NOT (u.id = ANY($2)) AND t.archived_at IS NULL
+			AND NOT ` + unavailableNow + `
 	`
-	rows, err := r.db.Query(query, teamID, excludeUserID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, teamID, pq.Array(excludeUserIDs))
 	if err != nil {
 		return nil, err
 	}
@@ -69,75 +112,372 @@ func (r *PostgresUserRepository) GetActiveUsersInTeamExcluding(teamID int64, exc
 	var users []domain.User
 	for rows.Next() {
 		var u domain.User
-		if err := rows.Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamID); err != nil {
+		var maxOpenReviews sql.NullInt64
+		sched, done := scheduleDest(&u.Schedule)
+		if err := rows.Scan(append([]interface{}{&u.ID, &u.Username, &u.IsActive, &u.TeamID, &u.Role, pq.Array(&u.Skills), &maxOpenReviews, &u.ShadowOptIn}, sched...)...); err != nil {
 			return nil, err
 		}
+		done()
+		u.MaxOpenReviews = nullableInt(maxOpenReviews)
 		users = append(users, u)
 	}
-	return users, nil
+	return users, rows.Err()
 }
 
-func (r *PostgresUserRepository) GetTeamIDByUserID(userID string) (int64, error) {
+// GetTeamIDByUserID returns the user's primary team.
+func (r *PostgresUserRepository) GetTeamIDByUserID(ctx context.Context, userID string) (int64, error) {
 	var teamID int64
-	err := r.db.QueryRow("SELECT team_id FROM users WHERE id = $1", userID).Scan(&teamID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT team_id FROM team_memberships WHERE user_id = $1 AND is_primary", userID).Scan(&teamID)
 	if err != nil {
 		return 0, err
 	}
 	return teamID, nil
 }
 
-func (r *PostgresUserRepository) GetTeamByUserID(userID string) (*domain.Team, error) {
+// GetTeamByUserID returns the user's primary team.
+func (r *PostgresUserRepository) GetTeamByUserID(ctx context.Context, userID string) (*domain.Team, error) {
 	query := `
 		SELECT t.id, t.name
 		FROM teams t
-		JOIN users u ON u.team_id = t.id
-		WHERE u.id = $1
+		JOIN team_memberships m ON m.team_id = t.id
+		WHERE m.user_id = $1 AND m.is_primary
 	`
 	var team domain.Team
-	err := r.db.QueryRow(query, userID).Scan(&team.ID, &team.Name)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&team.ID, &team.Name)
 	if err != nil {
 		return nil, err
 	}
 	return &team, nil
 }
 
-func (r *PostgresUserRepository) DeactivateUsers(userIDs []string) error {
+func (r *PostgresUserRepository) DeactivateUsers(ctx context.Context, userIDs []string) error {
 	if len(userIDs) == 0 {
 		return nil
 	}
 
-	placeholders := make([]string, len(userIDs))
-	args := make([]interface{}, len(userIDs))
-	for i, id := range userIDs {
-		placeholders[i] = "$" + strconv.Itoa(i+1)
-		args[i] = id
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH changed AS (
+			UPDATE users SET is_active = false WHERE id = ANY($1) AND is_active RETURNING id
+		)
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type)
+		SELECT $2, id, $3 FROM changed
+	`, pq.Array(userIDs), domain.AggregateUser, domain.EventUserDeactivated)
+	return err
+}
+
+func (r *PostgresUserRepository) SetIsActive(ctx context.Context, userID string, isActive bool) error {
+	event := domain.EventUserDeactivated
+	if isActive {
+		event = domain.EventUserActivated
 	}
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH changed AS (
+			UPDATE users SET is_active = $1 WHERE id = $2 AND is_active != $1 RETURNING id
+		)
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type)
+		SELECT $3, id, $4 FROM changed
+	`, isActive, userID, domain.AggregateUser, event)
+	return err
+}
 
-	query := fmt.Sprintf("UPDATE users SET is_active = false WHERE id IN (%s)", strings.Join(placeholders, ","))
-	_, err := r.db.Exec(query, args...)
+func (r *PostgresUserRepository) SetWorkSchedule(ctx context.Context, userID string, schedule domain.WorkSchedule) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET timezone = $1, work_start = $2, work_end = $3, work_days = $4
+		WHERE id = $5
+	`, schedule.Timezone, clockTime(schedule.StartMinute), clockTime(schedule.EndMinute), weekdaysMask(schedule.Weekdays), userID)
 	return err
 }
 
-func (r *PostgresUserRepository) SetIsActive(userID string, isActive bool) error {
-	_, err := r.db.Exec("UPDATE users SET is_active = $1 WHERE id = $2", isActive, userID)
+func (r *PostgresUserRepository) SetSkills(ctx context.Context, userID string, skills []string) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		if _, err := q.ExecContext(ctx, "DELETE FROM user_skills WHERE user_id = $1", userID); err != nil {
+			return err
+		}
+		if len(skills) == 0 {
+			return nil
+		}
+		_, err := q.ExecContext(ctx, "INSERT INTO user_skills (user_id, skill) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", userID, pq.Array(skills))
+		return err
+	})
+}
+
+func (r *PostgresUserRepository) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET max_open_reviews = $1 WHERE id = $2", limit, userID)
+	return err
+}
+
+func (r *PostgresUserRepository) SetShadowOptIn(ctx context.Context, userID string, optIn bool) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET shadow_opt_in = $1 WHERE id = $2", optIn, userID)
 	return err
 }
 
-func (r *PostgresUserRepository) GetUserByID(userID string) (*domain.User, error) {
+func (r *PostgresUserRepository) GetSkills(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	skills := make(map[string][]string)
+	if len(userIDs) == 0 {
+		return skills, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT user_id, skill FROM user_skills WHERE user_id = ANY($1) ORDER BY user_id, skill", pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, skill string
+		if err := rows.Scan(&userID, &skill); err != nil {
+			return nil, err
+		}
+		skills[userID] = append(skills[userID], skill)
+	}
+	return skills, rows.Err()
+}
+
+// clockTime renders minutes after midnight as a TIME literal.
+func clockTime(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func (r *PostgresUserRepository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
 	query := `
-		SELECT u.id, u.username, u.is_active, t.name, u.team_id
+		SELECT u.id, u.username, u.is_active, t.name, t.id, ` + skillsColumn + `,
+			COALESCE(u.max_open_reviews, t.max_open_reviews), u.shadow_opt_in, ` + scheduleColumns + `
 		FROM users u
-		JOIN teams t ON u.team_id = t.id
+		JOIN team_memberships m ON m.user_id = u.id AND m.is_primary
+		JOIN teams t ON m.team_id = t.id
 		WHERE u.id = $1
 	`
 	var user domain.User
 	var teamName string
-	err := r.db.QueryRow(query, userID).Scan(
-		&user.ID, &user.Username, &user.IsActive, &teamName, &user.TeamID,
+	var maxOpenReviews sql.NullInt64
+	sched, done := scheduleDest(&user.Schedule)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		append([]interface{}{&user.ID, &user.Username, &user.IsActive, &teamName, &user.TeamID, pq.Array(&user.Skills), &maxOpenReviews, &user.ShadowOptIn}, sched...)...,
 	)
 	if err != nil {
 		return nil, err
 	}
+	done()
+	user.MaxOpenReviews = nullableInt(maxOpenReviews)
 	user.TeamName = teamName
 	return &user, nil
 }
+
+func (r *PostgresUserRepository) ResolveUsers(ctx context.Context, names []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	if len(names) == 0 {
+		return resolved, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT id, username FROM users WHERE id = ANY($1) OR username = ANY($1)", pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byUsername := make(map[string][]string)
+	for rows.Next() {
+		var id, username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		if slices.Contains(names, id) {
+			resolved[id] = id
+		}
+		byUsername[username] = append(byUsername[username], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, ok := resolved[name]; !ok && len(byUsername[name]) == 1 {
+			resolved[name] = byUsername[name][0]
+		}
+	}
+	return resolved, nil
+}
+
+func (r *PostgresUserRepository) DeleteUsers(ctx context.Context, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("DELETE FROM users WHERE id IN (%s)", strings.Join(placeholders, ","))
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	return err
+}
+
+// MoveTeamMembers transfers every membership of fromTeamID to toTeamID. Users
+// already in toTeamID keep that membership, which becomes primary if the
+// moved one was.
+func (r *PostgresUserRepository) MoveTeamMembers(ctx context.Context, fromTeamID, toTeamID int64) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		rows, err := q.QueryContext(ctx, `
+			DELETE FROM team_memberships f
+			WHERE f.team_id = $1
+				AND EXISTS (SELECT 1 FROM team_memberships t WHERE t.user_id = f.user_id AND t.team_id = $2)
+			RETURNING f.user_id, f.is_primary
+		`, fromTeamID, toTeamID)
+		if err != nil {
+			return err
+		}
+		var promote []string
+		for rows.Next() {
+			var userID string
+			var isPrimary bool
+			if err := rows.Scan(&userID, &isPrimary); err != nil {
+				rows.Close()
+				return err
+			}
+			if isPrimary {
+				promote = append(promote, userID)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, userID := range promote {
+			if _, err := q.ExecContext(ctx, "UPDATE team_memberships SET is_primary = true WHERE user_id = $1 AND team_id = $2", userID, toTeamID); err != nil {
+				return err
+			}
+		}
+
+		_, err = q.ExecContext(ctx, "UPDATE team_memberships SET team_id = $1 WHERE team_id = $2", toTeamID, fromTeamID)
+		return err
+	})
+}
+
+// SetPrimaryTeam makes teamID the user's primary team. The previous primary
+// membership is dropped, so this is a move rather than a second membership.
+// An existing role in teamID is kept.
+func (r *PostgresUserRepository) SetPrimaryTeam(ctx context.Context, userID string, teamID int64) error {
+	return withTx(ctx, r.db, func(q querier) error {
+		return setPrimaryTeam(ctx, q, userID, teamID, "")
+	})
+}
+
+// setPrimaryTeam upserts the primary membership. An empty role keeps the
+// current one, or falls back to the column default for a new membership.
+func setPrimaryTeam(ctx context.Context, q querier, userID string, teamID int64, role string) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM team_memberships WHERE user_id = $1 AND is_primary AND team_id != $2", userID, teamID); err != nil {
+		return err
+	}
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO team_memberships (user_id, team_id, is_primary, role)
+		VALUES ($1, $2, true, COALESCE(NULLIF($3, ''), 'member'))
+		ON CONFLICT (user_id, team_id) DO UPDATE
+		SET is_primary = true, role = COALESCE(NULLIF($3, ''), team_memberships.role)
+	`, userID, teamID, role)
+	return err
+}
+
+// AddMembership adds a secondary membership, or a primary one when the user
+// has none yet.
+func (r *PostgresUserRepository) AddMembership(ctx context.Context, userID string, teamID int64, role string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO team_memberships (user_id, team_id, is_primary, role)
+		VALUES ($1, $2, NOT EXISTS (SELECT 1 FROM team_memberships WHERE user_id = $1 AND is_primary), COALESCE(NULLIF($3, ''), 'member'))
+		ON CONFLICT (user_id, team_id) DO NOTHING
+	`, userID, teamID, role)
+	return err
+}
+
+func (r *PostgresUserRepository) SetRole(ctx context.Context, userID string, teamID int64, role string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE team_memberships SET role = $1 WHERE user_id = $2 AND team_id = $3", role, userID, teamID)
+	return err
+}
+
+func (r *PostgresUserRepository) RemoveMembership(ctx context.Context, userID string, teamID int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM team_memberships WHERE user_id = $1 AND team_id = $2", userID, teamID)
+	return err
+}
+
+func (r *PostgresUserRepository) IsMember(ctx context.Context, userID string, teamID int64) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM team_memberships WHERE user_id = $1 AND team_id = $2)", userID, teamID).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresUserRepository) IsAvailable(ctx context.Context, userID string) (bool, error) {
+	var available bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT NOT "+unavailableNow+" FROM users u WHERE u.id = $1", userID).Scan(&available)
+	return available, err
+}
+
+func (r *PostgresUserRepository) GetMemberships(ctx context.Context, userID string) ([]domain.Membership, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT m.user_id, m.team_id, t.name, m.is_primary, m.role, m.joined_at
+		FROM team_memberships m
+		JOIN teams t ON t.id = m.team_id
+		WHERE m.user_id = $1
+		ORDER BY m.is_primary DESC, t.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []domain.Membership
+	for rows.Next() {
+		var m domain.Membership
+		if err := rows.Scan(&m.UserID, &m.TeamID, &m.TeamName, &m.IsPrimary, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, rows.Err()
+}
+
+func (r *PostgresUserRepository) RecordTeamMove(ctx context.Context, move *domain.TeamMove) error {
+	var fromTeamName sql.NullString
+	if move.FromTeamID != nil {
+		fromTeamName = sql.NullString{String: move.FromTeamName, Valid: true}
+	}
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO user_team_history
+			(user_id, from_team_id, from_team_name, to_team_id, to_team_name, source, open_reviews_policy, reassigned_reviews)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, moved_at
+	`, move.UserID, move.FromTeamID, fromTeamName, move.ToTeamID, move.ToTeamName, move.Source, move.OpenReviewsPolicy, move.ReassignedReviews,
+	).Scan(&move.ID, &move.MovedAt)
+}
+
+func (r *PostgresUserRepository) GetTeamHistory(ctx context.Context, userID string) ([]domain.TeamMove, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, from_team_id, COALESCE(from_team_name, ''), COALESCE(to_team_id, 0), to_team_name,
+			source, open_reviews_policy, reassigned_reviews, moved_at
+		FROM user_team_history
+		WHERE user_id = $1
+		ORDER BY moved_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []domain.TeamMove
+	for rows.Next() {
+		var m domain.TeamMove
+		var fromTeamID sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.UserID, &fromTeamID, &m.FromTeamName, &m.ToTeamID, &m.ToTeamName,
+			&m.Source, &m.OpenReviewsPolicy, &m.ReassignedReviews, &m.MovedAt); err != nil {
+			return nil, err
+		}
+		if fromTeamID.Valid {
+			m.FromTeamID = &fromTeamID.Int64
+		}
+		moves = append(moves, m)
+	}
+	return moves, rows.Err()
+}
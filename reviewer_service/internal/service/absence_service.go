@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/ical"
+	"reviewer_service/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	AbsenceSourceManual = "manual"
+	AbsenceSourceICS    = "ics"
+)
+
+type AbsenceService struct {
+	absenceRepo repository.AbsenceRepository
+	userRepo    repository.UserRepository
+	prRepo      repository.PullRequestRepository
+	prService   *PullRequestService
+	tx          repository.TxManager
+}
+
+func NewAbsenceService(absenceRepo repository.AbsenceRepository, userRepo repository.UserRepository, prRepo repository.PullRequestRepository, prService *PullRequestService, tx repository.TxManager) *AbsenceService {
+	return &AbsenceService{absenceRepo: absenceRepo, userRepo: userRepo, prRepo: prRepo, prService: prService, tx: tx}
+}
+
+type InvalidAbsenceError struct {
+	Reason string
+}
+
+func (e InvalidAbsenceError) Error() string { return "invalid absence: " + e.Reason }
+
+type AbsenceNotFoundError struct{}
+
+func (e AbsenceNotFoundError) Error() string { return "absence not found" }
+
+func validateAbsence(a *domain.Absence) error {
+	switch a.Kind {
+	case domain.AbsenceVacation, domain.AbsenceSickLeave:
+		if a.EndsAt == nil {
+			return InvalidAbsenceError{Reason: "ends_at is required for " + a.Kind}
+		}
+	case domain.AbsencePartTime:
+		if len(a.Weekdays) == 0 {
+			return InvalidAbsenceError{Reason: "weekdays are required for part_time"}
+		}
+	default:
+		return InvalidAbsenceError{Reason: "kind must be vacation, sick_leave or part_time"}
+	}
+	if a.StartsAt.IsZero() {
+		return InvalidAbsenceError{Reason: "starts_at is required"}
+	}
+	if a.EndsAt != nil && !a.EndsAt.After(a.StartsAt) {
+		return InvalidAbsenceError{Reason: "ends_at must be after starts_at"}
+	}
+	return nil
+}
+
+func (s *AbsenceService) ensureUser(ctx context.Context, userID string) error {
+	if _, err := s.userRepo.GetTeamIDByUserID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserNotFoundError{}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *AbsenceService) AddAbsence(ctx context.Context, a *domain.Absence) error {
+	if err := validateAbsence(a); err != nil {
+		return err
+	}
+	if err := s.ensureUser(ctx, a.UserID); err != nil {
+		return err
+	}
+	a.Source = AbsenceSourceManual
+	return s.absenceRepo.Create(ctx, a)
+}
+
+// ListAbsences also reports whether the user is available right now;
+// recurring days off are matched in the user's timezone.
+func (s *AbsenceService) ListAbsences(ctx context.Context, userID string) ([]domain.Absence, bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, UserNotFoundError{}
+		}
+		return nil, false, err
+	}
+	absences, err := s.absenceRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().In(user.Schedule.Location())
+	for _, a := range absences {
+		if a.ActiveAt(now) {
+			return absences, false, nil
+		}
+	}
+	return absences, true, nil
+}
+
+func (s *AbsenceService) DeleteAbsence(ctx context.Context, userID string, id int64) error {
+	deleted, err := s.absenceRepo.Delete(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return AbsenceNotFoundError{}
+	}
+	return nil
+}
+
+type ImportResult struct {
+	Created int
+	Updated int
+	Removed int
+	Skipped int
+}
+
+// ImportICS imports the events of an iCalendar file as absences of userID.
+// Events are matched by UID, so importing the same calendar again updates
+// earlier imports; cancelled events remove them. Weekly recurring events
+// become part-time absences, events mentioning sickness become sick leave
+// and everything else is treated as vacation. Free (transparent) events
+// are skipped.
+func (s *AbsenceService) ImportICS(ctx context.Context, userID string, r io.Reader) (*ImportResult, error) {
+	events, err := ical.Parse(r)
+	if err != nil {
+		return nil, InvalidAbsenceError{Reason: err.Error()}
+	}
+
+	result := &ImportResult{}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.ensureUser(ctx, userID); err != nil {
+			return err
+		}
+
+		for _, ev := range events {
+			if ev.UID == "" || ev.Transparent {
+				result.Skipped++
+				continue
+			}
+			if ev.Status == "CANCELLED" {
+				if err := s.absenceRepo.DeleteExternal(ctx, userID, ev.UID); err != nil {
+					return err
+				}
+				result.Removed++
+				continue
+			}
+
+			a, ok := absenceFromEvent(userID, ev)
+			if !ok || validateAbsence(a) != nil {
+				result.Skipped++
+				continue
+			}
+			created, err := s.absenceRepo.UpsertExternal(ctx, a)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func absenceFromEvent(userID string, ev ical.Event) (*domain.Absence, bool) {
+	a := &domain.Absence{
+		UserID:      userID,
+		Kind:        domain.AbsenceVacation,
+		StartsAt:    ev.Start,
+		Note:        ev.Summary,
+		Source:      AbsenceSourceICS,
+		ExternalUID: ev.UID,
+	}
+
+	if ev.RRule != "" {
+		rule, ok := ical.ParseWeekly(ev.RRule)
+		if !ok {
+			return nil, false
+		}
+		a.Kind = domain.AbsencePartTime
+		a.Weekdays = rule.Days
+		a.EndsAt = rule.Until
+		return a, true
+	}
+
+	end := ev.End
+	a.EndsAt = &end
+	if mentionsSickness(ev) {
+		a.Kind = domain.AbsenceSickLeave
+	}
+	return a, true
+}
+
+func mentionsSickness(ev ical.Event) bool {
+	text := strings.ToLower(ev.Summary + " " + strings.Join(ev.Categories, " "))
+	for _, word := range []string{"sick", "illness", "больнич", "болезн"} {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+type HandoverResult struct {
+	Absences   int
+	Reassigned []ReviewerChange
+	Flagged    []string
+}
+
+// HandOverUpcoming reassigns the open reviews of users whose vacation or sick
+// leave starts within lead. Each absence is handed over once; reviews that
+// cannot be reassigned are flagged as needing attention.
+func (s *AbsenceService) HandOverUpcoming(ctx context.Context, lead time.Duration) (*HandoverResult, error) {
+	absences, err := s.absenceRepo.ListPendingHandover(ctx, time.Now().Add(lead))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HandoverResult{}
+	for _, a := range absences {
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			return s.handOver(ctx, a, result)
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Absences++
+	}
+	return result, nil
+}
+
+func (s *AbsenceService) handOver(ctx context.Context, a domain.Absence, result *HandoverResult) error {
+	openPRs, err := s.prRepo.GetOpenPRsWithReviewers(ctx, []string{a.UserID})
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range openPRs {
+		reviewers, err := s.prRepo.GetReviewers(ctx, pr.ID)
+		if err != nil {
+			return err
+		}
+		teamID, err := s.prService.reviewTeamID(ctx, pr, a.UserID)
+		if err != nil {
+			return err
+		}
+
+		newID, err := s.prService.replaceWithinTeam(ctx, pr, a.UserID, teamID, reviewers)
+		if err != nil {
+			return err
+		}
+		if newID == "" {
+			reason := "reviewer " + a.UserID + " is away from " + a.StartsAt.Format("2006-01-02")
+			if err := s.prRepo.SetNeedsAttention(ctx, pr.ID, reason); err != nil {
+				return err
+			}
+			result.Flagged = append(result.Flagged, pr.ID)
+			continue
+		}
+		result.Reassigned = append(result.Reassigned, ReviewerChange{
+			PullRequestID: pr.ID,
+			OldReviewerID: a.UserID,
+			NewReviewerID: newID,
+		})
+	}
+	return s.absenceRepo.MarkHandedOver(ctx, a.ID)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"reviewer_service/internal/domain"
+	"strings"
+	"time"
+)
+
+const (
+	// RotationHard never picks reviewers over the rotation limit.
+	RotationHard = "hard"
+	// RotationSoft picks them only when nobody else qualifies.
+	RotationSoft = "soft"
+)
+
+// normalizeFeature trims a feature tag or branch name; "refs/heads/main"
+// and "main" are the same feature.
+func normalizeFeature(feature string) string {
+	return strings.TrimPrefix(strings.TrimSpace(feature), "refs/heads/")
+}
+
+// preferFeatureReviewers moves reviewers of earlier PRs with pr's feature
+// ahead of the rest of their tier, so follow-ups go back to the same people.
+func (s *PullRequestService) preferFeatureReviewers(ctx context.Context, pr *domain.PullRequest, tiers [][]domain.User) ([][]domain.User, error) {
+	if !s.opts.Affinity || pr.Feature == "" || len(tiers) == 0 {
+		return tiers, nil
+	}
+	reviewers, err := s.prRepo.GetFeatureReviewers(ctx, pr.Feature, pr.ID)
+	if err != nil || len(reviewers) == 0 {
+		return tiers, err
+	}
+	ids := make(map[string]bool, len(reviewers))
+	for _, id := range reviewers {
+		ids[id] = true
+	}
+	return preferFirst(tiers, ids), nil
+}
+
+// limitRotated drops candidates who reviewed each of the author's last
+// RotationLimit PRs within RotationWindow. In RotationSoft mode they are
+// moved into a last tier instead, so they are only picked when nobody else
+// qualifies.
+func (s *PullRequestService) limitRotated(ctx context.Context, pr *domain.PullRequest, tiers [][]domain.User) ([][]domain.User, error) {
+	limit := s.opts.RotationLimit
+	if limit <= 0 || len(tiers) == 0 {
+		return tiers, nil
+	}
+	since := time.Now().Add(-s.opts.RotationWindow)
+	sets, err := s.prRepo.GetRecentReviewerSets(ctx, pr.AuthorID, pr.ID, since, limit)
+	if err != nil || len(sets) < limit {
+		return tiers, err
+	}
+
+	streak := make(map[string]int)
+	for _, set := range sets {
+		for _, id := range set {
+			streak[id]++
+		}
+	}
+
+	var demoted []domain.User
+	out := make([][]domain.User, 0, len(tiers)+1)
+	for _, tier := range tiers {
+		var kept []domain.User
+		for _, u := range tier {
+			if streak[u.ID] >= limit {
+				demoted = append(demoted, u)
+			} else {
+				kept = append(kept, u)
+			}
+		}
+		if len(kept) > 0 {
+			out = append(out, kept)
+		}
+	}
+	if len(demoted) > 0 && s.opts.RotationMode == RotationSoft {
+		out = append(out, demoted)
+	}
+	return out, nil
+}
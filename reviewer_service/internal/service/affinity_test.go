@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+type fakeRecentReviewersRepo struct {
+	repository.PullRequestRepository
+	sets [][]string
+}
+
+func (r *fakeRecentReviewersRepo) GetRecentReviewerSets(_ context.Context, _, _ string, _ time.Time, limit int) ([][]string, error) {
+	if len(r.sets) > limit {
+		return r.sets[:limit], nil
+	}
+	return r.sets, nil
+}
+
+func TestLimitRotated(t *testing.T) {
+	prs := &fakeRecentReviewersRepo{sets: [][]string{{"u1", "u2"}, {"u1", "u3"}}}
+	tiers := [][]domain.User{usersOf("u1"), usersOf("u2", "u4")}
+	pr := &domain.PullRequest{ID: "pr-3", AuthorID: "author"}
+
+	tests := []struct {
+		mode  string
+		limit int
+		want  [][]string
+	}{
+		{RotationHard, 2, [][]string{{"u2", "u4"}}},
+		{RotationSoft, 2, [][]string{{"u2", "u4"}, {"u1"}}},
+		{RotationHard, 3, [][]string{{"u1"}, {"u2", "u4"}}},
+		{RotationHard, 0, [][]string{{"u1"}, {"u2", "u4"}}},
+	}
+	for _, tc := range tests {
+		s := NewPullRequestService(prs, nil, nil, nil, nil, nil, nil, nil, AssignmentOptions{
+			RotationLimit: tc.limit, RotationWindow: time.Hour, RotationMode: tc.mode,
+		})
+		got, err := s.limitRotated(context.Background(), pr, tiers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := userIDs(got); !reflect.DeepEqual(ids, tc.want) {
+			t.Errorf("%s mode, limit %d: tiers %v, want %v", tc.mode, tc.limit, ids, tc.want)
+		}
+	}
+}
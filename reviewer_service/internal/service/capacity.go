@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"reviewer_service/internal/domain"
+	"time"
+)
+
+const (
+	QueueOrderFIFO     = "fifo"
+	QueueOrderPriority = "priority"
+
+	// CapacityReached is reported when reviewers at their open review cap
+	// were skipped and the PR got fewer reviewers than it needs.
+	CapacityReached = "CAPACITY_REACHED"
+
+	// QueueDrainJob is the delayed job that drains the review queue once
+	// capacity may have been freed.
+	QueueDrainJob = "drain_review_queue"
+)
+
+// JobQueue runs delayed jobs; the job scheduler is one. EnqueueOnce
+// creates nothing when a job of that name is already waiting to run.
+type JobQueue interface {
+	EnqueueOnce(ctx context.Context, name string, payload []byte, runAt time.Time) (*domain.DelayedJob, error)
+}
+
+type InvalidCapacityError struct{}
+
+func (e InvalidCapacityError) Error() string { return "max_open_reviews must not be negative" }
+
+func hasWarning(warnings []string, code string) bool {
+	for _, w := range warnings {
+		if w == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PullRequestService) capacityOf(u domain.User) int {
+	if u.MaxOpenReviews != nil {
+		return *u.MaxOpenReviews
+	}
+	return s.opts.MaxOpenReviews
+}
+
+// dropAtCapacity removes candidates who already hold as many open reviews as
+// their cap allows and reports whether anybody was removed.
+func (s *PullRequestService) dropAtCapacity(ctx context.Context, tiers [][]domain.User) ([][]domain.User, bool, error) {
+	var capped []string
+	for _, tier := range tiers {
+		for _, u := range tier {
+			if s.capacityOf(u) > 0 {
+				capped = append(capped, u.ID)
+			}
+		}
+	}
+	if len(capped) == 0 {
+		return tiers, false, nil
+	}
+	load, err := s.prRepo.CountOpenReviews(ctx, capped)
+	if err != nil {
+		return nil, false, err
+	}
+
+	skipped := false
+	out := make([][]domain.User, 0, len(tiers))
+	for _, tier := range tiers {
+		var kept []domain.User
+		for _, u := range tier {
+			if c := s.capacityOf(u); c > 0 && load[u.ID] >= c {
+				skipped = true
+				continue
+			}
+			kept = append(kept, u)
+		}
+		if len(kept) > 0 {
+			out = append(out, kept)
+		}
+	}
+	return out, skipped, nil
+}
+
+// DrainQueue assigns reviewers to queued PRs in FIFO or priority order,
+// as far as capacity allows, and returns how many reviewers were assigned.
+// Each entry is drained in its own transaction: entries a concurrent drain
+// is working on are skipped, and an entry that fails is logged and left
+// queued for the next drain.
+func (s *PullRequestService) DrainQueue(ctx context.Context) (int, error) {
+	entries, err := s.queueRepo.List(ctx, s.opts.QueueOrder == QueueOrderPriority)
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for _, queued := range entries {
+		if err := ctx.Err(); err != nil {
+			return assigned, err
+		}
+		var n int
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			entry, err := s.queueRepo.LockEntry(ctx, queued.PullRequestID)
+			if err != nil || entry == nil {
+				return err
+			}
+			n, err = s.drainEntry(ctx, *entry)
+			return err
+		})
+		if err != nil {
+			log.Printf("Review queue drain of %s failed: %v", queued.PullRequestID, err)
+			continue
+		}
+		assigned += n
+	}
+	return assigned, nil
+}
+
+func (s *PullRequestService) drainEntry(ctx context.Context, entry domain.QueueEntry) (int, error) {
+	pr, err := s.prRepo.GetByID(ctx, entry.PullRequestID)
+	if err != nil {
+		return 0, err
+	}
+	if pr.Status == StatusMerged {
+		return 0, s.queueRepo.Dequeue(ctx, pr.ID)
+	}
+
+	teamID, err := s.reviewTeamID(ctx, pr, pr.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	kept, err := s.reviewerProfiles(ctx, teamID, pr.AssignedReviewers)
+	if err != nil {
+		return 0, err
+	}
+	exclude := append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+	picked, _, err := s.pickEscalating(ctx, teamID, pr, exclude, kept, entry.Slots)
+	if _, ok := err.(AssignmentRuleError); ok {
+		return 0, nil
+	}
+	if err != nil || len(picked) == 0 {
+		return 0, err
+	}
+
+	ids := make([]string, len(picked))
+	for i, u := range picked {
+		ids[i] = u.ID
+	}
+	if err := s.prRepo.AssignReviewers(ctx, pr.ID, ids); err != nil {
+		return 0, err
+	}
+	if left := entry.Slots - len(picked); left > 0 {
+		return len(picked), s.queueRepo.SetSlots(ctx, pr.ID, left)
+	}
+	return len(picked), s.queueRepo.Dequeue(ctx, pr.ID)
+}
+
+// drainAfter queues a QueueDrainJob once capacity may have been freed, so
+// the drain runs outside the request. Nothing is queued while the review
+// queue is empty, and changes that come while a drain is already waiting
+// share it. Failures are logged rather than returned, as the triggering
+// change already happened; the periodic drain catches up.
+func (s *PullRequestService) drainAfter(ctx context.Context, cause string) {
+	empty, err := s.queueRepo.IsEmpty(ctx)
+	if err == nil && empty {
+		return
+	}
+	if err == nil {
+		_, err = s.jobs.EnqueueOnce(ctx, QueueDrainJob, nil, time.Now())
+	}
+	if err != nil {
+		log.Printf("Failed to queue a review queue drain after %s: %v", cause, err)
+	}
+}
+
+func (s *PullRequestService) ListQueue(ctx context.Context) ([]domain.QueueEntry, error) {
+	return s.queueRepo.List(ctx, s.opts.QueueOrder == QueueOrderPriority)
+}
+
+// SetMaxOpenReviews sets the user's own cap; nil inherits the team's.
+func (s *UserService) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*domain.User, error) {
+	if limit != nil && *limit < 0 {
+		return nil, InvalidCapacityError{}
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
+		return nil, err
+	}
+	if err := s.userRepo.SetMaxOpenReviews(ctx, userID, limit); err != nil {
+		return nil, err
+	}
+	s.prService.drainAfter(ctx, "capacity change of "+userID)
+	return s.userRepo.GetUserByID(ctx, userID)
+}
+
+// SetMaxOpenReviews sets the cap for members of the team that have none of
+// their own; nil falls back to the configured default.
+func (s *TeamService) SetMaxOpenReviews(ctx context.Context, teamName string, limit *int) (*domain.Team, error) {
+	if limit != nil && *limit < 0 {
+		return nil, InvalidCapacityError{}
+	}
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, TeamNotFoundError{}
+	}
+	if err := s.teamRepo.SetMaxOpenReviews(ctx, team.ID, limit); err != nil {
+		return nil, err
+	}
+	team.MaxOpenReviews = limit
+	s.prService.drainAfter(ctx, "capacity change of team "+team.Name)
+	return team, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+type fakeQueueRepo struct {
+	repository.ReviewQueueRepository
+	entries    map[string]bool
+	dequeueErr error
+}
+
+func (r *fakeQueueRepo) IsEmpty(context.Context) (bool, error) {
+	return len(r.entries) == 0, nil
+}
+
+func (r *fakeQueueRepo) Dequeue(_ context.Context, prID string) error {
+	if r.dequeueErr != nil {
+		return r.dequeueErr
+	}
+	delete(r.entries, prID)
+	return nil
+}
+
+// fakeJobs coalesces like the scheduler: a job waits until run is called.
+type fakeJobs struct {
+	waiting map[string]bool
+	created int
+}
+
+func (j *fakeJobs) EnqueueOnce(_ context.Context, name string, _ []byte, runAt time.Time) (*domain.DelayedJob, error) {
+	if j.waiting[name] {
+		return nil, nil
+	}
+	j.waiting[name] = true
+	j.created++
+	return &domain.DelayedJob{Name: name, RunAt: runAt}, nil
+}
+
+// fakeTx runs fn and, when it fails, restores what the merge changed.
+type fakeTx struct {
+	prs *fakeMergePRRepo
+}
+
+func (t fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	before := *t.prs.pr
+	if err := fn(ctx); err != nil {
+		*t.prs.pr = before
+		return err
+	}
+	return nil
+}
+
+type fakeMergePRRepo struct {
+	repository.PullRequestRepository
+	pr *domain.PullRequest
+}
+
+func (r *fakeMergePRRepo) GetByID(context.Context, string) (*domain.PullRequest, error) {
+	pr := *r.pr
+	return &pr, nil
+}
+
+func (r *fakeMergePRRepo) Merge(_ context.Context, _ string, at time.Time) error {
+	r.pr.Status = StatusMerged
+	r.pr.MergedAt = &at
+	return nil
+}
+
+func TestDrainAfterSkipsEmptyQueueAndCoalesces(t *testing.T) {
+	queue := &fakeQueueRepo{entries: map[string]bool{}}
+	jobs := &fakeJobs{waiting: map[string]bool{}}
+	s := NewPullRequestService(nil, nil, nil, nil, queue, nil, nil, jobs, AssignmentOptions{})
+
+	s.drainAfter(context.Background(), "merge of pr-1")
+	if jobs.created != 0 {
+		t.Fatalf("queued %d drains for an empty queue, want none", jobs.created)
+	}
+
+	queue.entries["pr-2"] = true
+	for i := 0; i < 3; i++ {
+		s.drainAfter(context.Background(), "merge")
+	}
+	if jobs.created != 1 {
+		t.Errorf("queued %d drains, want one shared by all changes", jobs.created)
+	}
+}
+
+func TestMergeKeepsPRQueuedWhenDequeueFails(t *testing.T) {
+	prs := &fakeMergePRRepo{pr: &domain.PullRequest{ID: "pr-1", Status: "OPEN", PendingReviewers: 1}}
+	queue := &fakeQueueRepo{entries: map[string]bool{"pr-1": true}, dequeueErr: errors.New("connection reset")}
+	jobs := &fakeJobs{waiting: map[string]bool{}}
+	s := NewPullRequestService(prs, nil, nil, nil, queue, nil, fakeTx{prs: prs}, jobs, AssignmentOptions{})
+
+	if _, err := s.MergePullRequest(context.Background(), "pr-1"); err == nil {
+		t.Fatal("merge succeeded, want the dequeue error")
+	}
+	if prs.pr.Status == StatusMerged {
+		t.Error("PR merged although it could not be dequeued")
+	}
+
+	queue.dequeueErr = nil
+	pr, err := s.MergePullRequest(context.Background(), "pr-1")
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if pr.Status != StatusMerged || pr.PendingReviewers != 0 || queue.entries["pr-1"] {
+		t.Errorf("after merge: status %s, pending %d, queued %v", pr.Status, pr.PendingReviewers, queue.entries["pr-1"])
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"io"
+	"reviewer_service/internal/codeowners"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+	"strings"
+)
+
+type CodeOwnersService struct {
+	codeOwnersRepo repository.CodeOwnersRepository
+	teamRepo       repository.TeamRepository
+	userRepo       repository.UserRepository
+}
+
+func NewCodeOwnersService(codeOwnersRepo repository.CodeOwnersRepository, teamRepo repository.TeamRepository, userRepo repository.UserRepository) *CodeOwnersService {
+	return &CodeOwnersService{codeOwnersRepo: codeOwnersRepo, teamRepo: teamRepo, userRepo: userRepo}
+}
+
+type InvalidCodeOwnersError struct {
+	Reason string
+}
+
+func (e InvalidCodeOwnersError) Error() string { return e.Reason }
+
+type CodeOwnersResult struct {
+	TeamName string
+	Rules    []domain.CodeOwnerRule
+	// UnknownOwners are owners that match no user ID or username, such as
+	// @org/team handles or e-mail addresses. They are dropped from the rules.
+	UnknownOwners []string
+	// SkippedPatterns are rules whose owners are all unknown. They are not
+	// stored, as they would otherwise turn into rules without owners and
+	// override earlier rules for the same files.
+	SkippedPatterns []string
+}
+
+// SetRules replaces the code owner rules of teamName. Owners are user IDs
+// or usernames, optionally prefixed with "@".
+func (s *CodeOwnersService) SetRules(ctx context.Context, teamName string, rules []codeowners.Rule) (*CodeOwnersResult, error) {
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, TeamNotFoundError{}
+	}
+
+	var names []string
+	for _, rule := range rules {
+		if _, err := codeowners.Compile(rule.Pattern); err != nil {
+			return nil, InvalidCodeOwnersError{Reason: "invalid pattern " + rule.Pattern}
+		}
+		for _, owner := range rule.Owners {
+			names = append(names, strings.TrimPrefix(owner, "@"))
+		}
+	}
+	resolved, err := s.userRepo.ResolveUsers(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CodeOwnersResult{TeamName: team.Name, Rules: make([]domain.CodeOwnerRule, 0, len(rules))}
+	unknown := make(map[string]bool)
+	for _, rule := range rules {
+		stored := domain.CodeOwnerRule{Pattern: rule.Pattern, OwnerIDs: []string{}}
+		for _, owner := range rule.Owners {
+			id, ok := resolved[strings.TrimPrefix(owner, "@")]
+			if !ok {
+				if !unknown[owner] {
+					unknown[owner] = true
+					result.UnknownOwners = append(result.UnknownOwners, owner)
+				}
+				continue
+			}
+			stored.OwnerIDs = append(stored.OwnerIDs, id)
+		}
+		if len(rule.Owners) > 0 && len(stored.OwnerIDs) == 0 {
+			result.SkippedPatterns = append(result.SkippedPatterns, rule.Pattern)
+			continue
+		}
+		result.Rules = append(result.Rules, stored)
+	}
+
+	if err := s.codeOwnersRepo.ReplaceRules(ctx, team.ID, result.Rules); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportFile replaces the rules of teamName with a CODEOWNERS file.
+func (s *CodeOwnersService) ImportFile(ctx context.Context, teamName string, r io.Reader) (*CodeOwnersResult, error) {
+	rules, err := codeowners.Parse(r)
+	if err != nil {
+		return nil, InvalidCodeOwnersError{Reason: err.Error()}
+	}
+	return s.SetRules(ctx, teamName, rules)
+}
+
+func (s *CodeOwnersService) GetRules(ctx context.Context, teamName string) ([]domain.CodeOwnerRule, error) {
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, TeamNotFoundError{}
+	}
+	return s.codeOwnersRepo.GetRules(ctx, team.ID)
+}
+
+// preferCodeOwners moves the owners of files, per teamID's rules, ahead of
+// the rest of their tier. Owners outside the tiers are not added, so they
+// still have to be eligible for the PR.
+func (s *PullRequestService) preferCodeOwners(ctx context.Context, teamID int64, files []string, tiers [][]domain.User) ([][]domain.User, error) {
+	if len(files) == 0 || len(tiers) == 0 {
+		return tiers, nil
+	}
+	rules, err := s.codeOwnersRepo.GetRules(ctx, teamID)
+	if err != nil || len(rules) == 0 {
+		return tiers, err
+	}
+
+	parsed := make([]codeowners.Rule, len(rules))
+	for i, rule := range rules {
+		parsed[i] = codeowners.Rule{Pattern: rule.Pattern, Owners: rule.OwnerIDs}
+	}
+	matcher, err := codeowners.NewMatcher(parsed)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]bool)
+	for _, file := range files {
+		for _, id := range matcher.Owners(file) {
+			owners[id] = true
+		}
+	}
+	if len(owners) == 0 {
+		return tiers, nil
+	}
+
+	return preferFirst(tiers, owners), nil
+}
+
+// preferFirst splits every tier into the candidates in ids followed by the
+// others, so they go first without jumping ahead of an earlier escalation
+// tier. Tiers are returned unchanged when none of them is a candidate.
+func preferFirst(tiers [][]domain.User, ids map[string]bool) [][]domain.User {
+	found := false
+	out := make([][]domain.User, 0, 2*len(tiers))
+	for _, tier := range tiers {
+		var preferred, others []domain.User
+		for _, u := range tier {
+			if ids[u.ID] {
+				preferred = append(preferred, u)
+			} else {
+				others = append(others, u)
+			}
+		}
+		if len(preferred) > 0 {
+			found = true
+			out = append(out, preferred)
+		}
+		if len(others) > 0 {
+			out = append(out, others)
+		}
+	}
+	if !found {
+		return tiers
+	}
+	return out
+}
+
+// normalizePaths trims changed file paths to the slash-separated form the
+// rules match against and drops blanks and duplicates.
+func normalizePaths(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	var out []string
+	for _, p := range paths {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "/")
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"reviewer_service/internal/codeowners"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+type fakeTeamRepo struct {
+	repository.TeamRepository
+	teams map[string]*domain.Team
+}
+
+func (r *fakeTeamRepo) GetByName(_ context.Context, name string) (*domain.Team, error) {
+	return r.teams[name], nil
+}
+
+type fakeResolveUserRepo struct {
+	repository.UserRepository
+	ids map[string]string
+}
+
+func (r *fakeResolveUserRepo) ResolveUsers(_ context.Context, names []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, name := range names {
+		if id, ok := r.ids[name]; ok {
+			out[name] = id
+		}
+	}
+	return out, nil
+}
+
+type fakeCodeOwnersRepo struct {
+	repository.CodeOwnersRepository
+	stored []domain.CodeOwnerRule
+}
+
+func (r *fakeCodeOwnersRepo) ReplaceRules(_ context.Context, _ int64, rules []domain.CodeOwnerRule) error {
+	r.stored = rules
+	return nil
+}
+
+func TestSetRulesSkipsRulesWithOnlyUnknownOwners(t *testing.T) {
+	repo := &fakeCodeOwnersRepo{}
+	s := NewCodeOwnersService(repo,
+		&fakeTeamRepo{teams: map[string]*domain.Team{"backend": {ID: 1, Name: "backend"}}},
+		&fakeResolveUserRepo{ids: map[string]string{"alice": "u1", "bob": "u2"}})
+
+	res, err := s.SetRules(context.Background(), "backend", []codeowners.Rule{
+		{Pattern: "*", Owners: []string{"@alice"}},
+		{Pattern: "/db/**", Owners: []string{"@org/dba", "dba@example.com"}},
+		{Pattern: "*.go", Owners: []string{"@bob", "@org/gophers"}},
+		{Pattern: "/vendor/", Owners: nil},
+	})
+	if err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+
+	want := []domain.CodeOwnerRule{
+		{Pattern: "*", OwnerIDs: []string{"u1"}},
+		{Pattern: "*.go", OwnerIDs: []string{"u2"}},
+		{Pattern: "/vendor/", OwnerIDs: []string{}},
+	}
+	if !reflect.DeepEqual(repo.stored, want) {
+		t.Errorf("stored %+v, want %+v", repo.stored, want)
+	}
+	if !reflect.DeepEqual(res.SkippedPatterns, []string{"/db/**"}) {
+		t.Errorf("skipped %q, want /db/**", res.SkippedPatterns)
+	}
+	if !reflect.DeepEqual(res.UnknownOwners, []string{"@org/dba", "dba@example.com", "@org/gophers"}) {
+		t.Errorf("unknown owners %q", res.UnknownOwners)
+	}
+}
+
+func userIDs(tiers [][]domain.User) [][]string {
+	out := make([][]string, len(tiers))
+	for i, tier := range tiers {
+		for _, u := range tier {
+			out[i] = append(out[i], u.ID)
+		}
+	}
+	return out
+}
+
+func usersOf(ids ...string) []domain.User {
+	users := make([]domain.User, len(ids))
+	for i, id := range ids {
+		users[i] = domain.User{ID: id}
+	}
+	return users
+}
+
+func TestPreferFirstKeepsEscalationTiers(t *testing.T) {
+	tiers := [][]domain.User{usersOf("team-a", "team-b"), usersOf("parent-a", "parent-b")}
+
+	got := userIDs(preferFirst(tiers, map[string]bool{"parent-a": true, "team-b": true}))
+	want := [][]string{{"team-b"}, {"team-a"}, {"parent-a"}, {"parent-b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tiers %v, want %v", got, want)
+	}
+
+	got = userIDs(preferFirst(tiers, map[string]bool{"parent-a": true}))
+	want = [][]string{{"team-a", "team-b"}, {"parent-a"}, {"parent-b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parent owner jumped ahead: tiers %v, want %v", got, want)
+	}
+
+	if got := userIDs(preferFirst(tiers, map[string]bool{"nobody": true})); !reflect.DeepEqual(got, userIDs(tiers)) {
+		t.Errorf("tiers changed without candidates: %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"reviewer_service/internal/domain"
+)
+
+// candidateTiers returns eligible reviewers for teamID in escalation order:
+// the team itself, then for each level up to EscalationDepth the siblings of
+// the team on the path and then the parent's own members. Users in exclude
+// are skipped and each user appears only in the first tier that has them.
+func (s *PullRequestService) candidateTiers(ctx context.Context, teamID int64, exclude []string) ([][]domain.User, error) {
+	seen := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		seen[id] = true
+	}
+
+	var tiers [][]domain.User
+	addTier := func(teamIDs ...int64) error {
+		var tier []domain.User
+		for _, id := range teamIDs {
+			users, err := s.userRepo.GetActiveUsersInTeamExcluding(ctx, id, exclude)
+			if err != nil {
+				return err
+			}
+			for _, u := range users {
+				if !seen[u.ID] {
+					seen[u.ID] = true
+					tier = append(tier, u)
+				}
+			}
+		}
+		if len(tier) > 0 {
+			tiers = append(tiers, tier)
+		}
+		return nil
+	}
+
+	if err := addTier(teamID); err != nil {
+		return nil, err
+	}
+	if s.opts.EscalationDepth <= 0 {
+		return tiers, nil
+	}
+
+	current, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	visited := map[int64]bool{teamID: true}
+	for level := 0; level < s.opts.EscalationDepth && current.ParentID != nil; level++ {
+		parentID := *current.ParentID
+		if visited[parentID] {
+			break
+		}
+
+		siblings, err := s.teamRepo.GetChildren(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		var siblingIDs []int64
+		for _, sibling := range siblings {
+			if !visited[sibling.ID] {
+				visited[sibling.ID] = true
+				siblingIDs = append(siblingIDs, sibling.ID)
+			}
+		}
+		if err := addTier(siblingIDs...); err != nil {
+			return nil, err
+		}
+		if err := addTier(parentID); err != nil {
+			return nil, err
+		}
+		visited[parentID] = true
+
+		current, err = s.teamRepo.GetByID(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tiers, nil
+}
+
+// pickEscalating picks up to n reviewers of pr for teamID, exhausting each
+// escalation tier before moving on to the next one and applying the
+// assignment rules. Besides exclude, the PR's authors, anyone excluded from
+// reviewing them and the PR's shadow are never picked. Candidates at
+// capacity are skipped. Within each escalation tier earlier reviewers of
+// pr's feature go first, then code owners of its changed files; reviewers
+// over the rotation limit are dropped, or go last in RotationSoft mode. See selectReviewers for kept, required skills and the returned
+// codes; CapacityReached is added when skipping candidates left slots
+// unfilled.
+func (s *PullRequestService) pickEscalating(ctx context.Context, teamID int64, pr *domain.PullRequest, exclude []string, kept []domain.User, n int) ([]domain.User, []string, error) {
+	conflicts, err := s.conflictsOf(ctx, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pr.Shadow != nil {
+		exclude = append(exclude, pr.Shadow.ReviewerID)
+	}
+	tiers, err := s.candidateTiers(ctx, teamID, append(conflicts, exclude...))
+	if err != nil {
+		return nil, nil, err
+	}
+	tiers, skipped, err := s.dropAtCapacity(ctx, tiers)
+	if err != nil {
+		return nil, nil, err
+	}
+	tiers, err = s.preferFeatureReviewers(ctx, pr, tiers)
+	if err != nil {
+		return nil, nil, err
+	}
+	tiers, err = s.preferCodeOwners(ctx, teamID, pr.ChangedFiles, tiers)
+	if err != nil {
+		return nil, nil, err
+	}
+	tiers, err = s.limitRotated(ctx, pr, tiers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	picked, warnings, err := s.selectReviewers(ctx, tiers, kept, n, pr.RequiredSkills)
+	if err != nil {
+		return nil, warnings, err
+	}
+	if skipped && len(picked) < n {
+		warnings = append(warnings, CapacityReached)
+	}
+	return picked, warnings, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+	"strings"
+)
+
+type ExclusionService struct {
+	exclusionRepo repository.ExclusionRepository
+	userRepo      repository.UserRepository
+}
+
+func NewExclusionService(exclusionRepo repository.ExclusionRepository, userRepo repository.UserRepository) *ExclusionService {
+	return &ExclusionService{exclusionRepo: exclusionRepo, userRepo: userRepo}
+}
+
+type InvalidExclusionError struct {
+	Reason string
+}
+
+func (e InvalidExclusionError) Error() string { return e.Reason }
+
+type ExclusionNotFoundError struct{}
+
+func (e ExclusionNotFoundError) Error() string { return "exclusion not found" }
+
+// AddExclusion keeps exclusion.ExcludedUserID off the PRs of
+// exclusion.UserID, and the other way round when it is mutual. Reviewers
+// already assigned are left in place.
+func (s *ExclusionService) AddExclusion(ctx context.Context, exclusion *domain.ReviewerExclusion) error {
+	if exclusion.UserID == exclusion.ExcludedUserID {
+		return InvalidExclusionError{Reason: "a user cannot be excluded from their own PRs"}
+	}
+	exclusion.Reason = strings.TrimSpace(exclusion.Reason)
+	for _, id := range []string{exclusion.UserID, exclusion.ExcludedUserID} {
+		if _, err := s.userRepo.GetUserByID(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+	}
+	return s.exclusionRepo.Add(ctx, *exclusion)
+}
+
+func (s *ExclusionService) RemoveExclusion(ctx context.Context, userID, excludedUserID string) error {
+	removed, err := s.exclusionRepo.Remove(ctx, userID, excludedUserID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ExclusionNotFoundError{}
+	}
+	return nil
+}
+
+// ListExclusions returns the exclusions userID is on either side of.
+func (s *ExclusionService) ListExclusions(ctx context.Context, userID string) ([]domain.ReviewerExclusion, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
+		return nil, err
+	}
+	return s.exclusionRepo.ListForUser(ctx, userID)
+}
+
+// conflictsOf returns the authors of pr and everyone excluded from
+// reviewing their PRs.
+func (s *PullRequestService) conflictsOf(ctx context.Context, pr *domain.PullRequest) ([]string, error) {
+	authors := append([]string{pr.AuthorID}, pr.CoAuthors...)
+	excluded, err := s.exclusionRepo.ExcludedReviewers(ctx, authors)
+	if err != nil {
+		return nil, err
+	}
+	return append(authors, excluded...), nil
+}
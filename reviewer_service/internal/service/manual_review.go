@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+	"slices"
+)
+
+const (
+	ReviewerInactive        = "REVIEWER_INACTIVE"
+	ReviewerNotTeamMember   = "NOT_TEAM_MEMBER"
+	ReviewerConflict        = "CONFLICT_OF_INTEREST"
+	ReviewerAlreadyAssigned = "ALREADY_ASSIGNED"
+	ReviewerUnavailable     = "REVIEWER_UNAVAILABLE"
+	ReviewerTeamArchived    = "TEAM_ARCHIVED"
+)
+
+// InvalidReviewerError rejects a manually chosen reviewer; Code is one of
+// the Reviewer* constants.
+type InvalidReviewerError struct {
+	Code string
+}
+
+func (e InvalidReviewerError) Error() string {
+	switch e.Code {
+	case ReviewerInactive:
+		return "reviewer is not active"
+	case ReviewerNotTeamMember:
+		return "reviewer is not a member of the PR's team"
+	case ReviewerConflict:
+		return "reviewer is an author of the PR or excluded from reviewing it"
+	case ReviewerAlreadyAssigned:
+		return "reviewer is already assigned to this PR"
+	case ReviewerUnavailable:
+		return "reviewer is absent right now"
+	case ReviewerTeamArchived:
+		return "the PR's team is archived"
+	}
+	return "invalid reviewer"
+}
+
+type InvalidActorError struct{}
+
+func (e InvalidActorError) Error() string { return "actor must be a user ID or a configured bot" }
+
+// checkActor validates the self-reported actor of a manual change: the
+// service does not authenticate callers, so it can only require a known
+// user or bot.
+func (s *PullRequestService) checkActor(ctx context.Context, actor string) error {
+	if slices.Contains(s.opts.Bots, actor) {
+		return nil
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, actor); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return InvalidActorError{}
+		}
+		return err
+	}
+	return nil
+}
+
+// openPR loads a PR that reviewers may still be changed on.
+func (s *PullRequestService) openPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, AuthorNotFoundError{}
+		}
+		return nil, err
+	}
+	if pr.Status == StatusMerged {
+		return nil, PRMergedError{}
+	}
+	return pr, nil
+}
+
+// checkReviewer validates a manually chosen reviewer of pr: an active,
+// currently available member of the PR's team who is not yet on it and is
+// neither an author nor excluded from reviewing them. The team must not be
+// archived. Capacity and assignment rules do not apply.
+func (s *PullRequestService) checkReviewer(ctx context.Context, pr *domain.PullRequest, userID string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserNotFoundError{}
+		}
+		return err
+	}
+	if !user.IsActive {
+		return InvalidReviewerError{Code: ReviewerInactive}
+	}
+	if slices.Contains(pr.AssignedReviewers, userID) || (pr.Shadow != nil && pr.Shadow.ReviewerID == userID) {
+		return InvalidReviewerError{Code: ReviewerAlreadyAssigned}
+	}
+
+	teamID, err := s.reviewTeamID(ctx, pr, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	member, err := s.userRepo.IsMember(ctx, userID, teamID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return InvalidReviewerError{Code: ReviewerNotTeamMember}
+	}
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if team.ArchivedAt != nil {
+		return InvalidReviewerError{Code: ReviewerTeamArchived}
+	}
+	available, err := s.userRepo.IsAvailable(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return InvalidReviewerError{Code: ReviewerUnavailable}
+	}
+
+	conflicts, err := s.conflictsOf(ctx, pr)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(conflicts, userID) {
+		return InvalidReviewerError{Code: ReviewerConflict}
+	}
+	return nil
+}
+
+func (s *PullRequestService) recordReviewerEvent(ctx context.Context, prID, action, reviewerID, previousID, actor string) error {
+	return s.prRepo.RecordReviewerEvent(ctx, &domain.ReviewerEvent{
+		PullRequestID:      prID,
+		Action:             action,
+		ReviewerID:         reviewerID,
+		PreviousReviewerID: previousID,
+		Actor:              actor,
+	})
+}
+
+// AddReviewer assigns userID to the PR on behalf of actor. A reviewer the
+// PR was queued for counts against its pending slots.
+func (s *PullRequestService) AddReviewer(ctx context.Context, prID, userID, actor string) (*domain.PullRequest, error) {
+	if err := s.checkActor(ctx, actor); err != nil {
+		return nil, err
+	}
+	var pr *domain.PullRequest
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if pr, err = s.openPR(ctx, prID); err != nil {
+			return err
+		}
+		if err := s.checkReviewer(ctx, pr, userID); err != nil {
+			return err
+		}
+		if err := s.prRepo.AssignReviewers(ctx, prID, []string{userID}); err != nil {
+			return err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, userID)
+
+		switch {
+		case pr.PendingReviewers > 1:
+			pr.PendingReviewers--
+			err = s.queueRepo.SetSlots(ctx, prID, pr.PendingReviewers)
+		case pr.PendingReviewers == 1:
+			pr.PendingReviewers = 0
+			err = s.queueRepo.Dequeue(ctx, prID)
+		}
+		if err != nil {
+			return err
+		}
+		return s.recordReviewerEvent(ctx, prID, domain.ReviewerAdded, userID, "", actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// RemoveReviewer unassigns userID without a replacement on behalf of actor.
+func (s *PullRequestService) RemoveReviewer(ctx context.Context, prID, userID, actor string) (*domain.PullRequest, error) {
+	if err := s.checkActor(ctx, actor); err != nil {
+		return nil, err
+	}
+	var pr *domain.PullRequest
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if pr, err = s.openPR(ctx, prID); err != nil {
+			return err
+		}
+		removed, err := s.prRepo.RemoveReviewer(ctx, prID, userID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return NotAssignedError{}
+		}
+		pr.AssignedReviewers = otherReviewers(pr.AssignedReviewers, userID)
+		if pr.Shadow != nil && pr.Shadow.MentorID == userID {
+			pr.Shadow.MentorID = ""
+		}
+		return s.recordReviewerEvent(ctx, prID, domain.ReviewerRemoved, userID, "", actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.drainAfter(ctx, "removal from "+prID)
+	return pr, nil
+}
+
+// ReassignTo replaces oldReviewerID with newReviewerID on behalf of actor.
+func (s *PullRequestService) ReassignTo(ctx context.Context, prID, oldReviewerID, newReviewerID, actor string) (*domain.PullRequest, error) {
+	if err := s.checkActor(ctx, actor); err != nil {
+		return nil, err
+	}
+	var pr *domain.PullRequest
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if pr, err = s.openPR(ctx, prID); err != nil {
+			return err
+		}
+		if !slices.Contains(pr.AssignedReviewers, oldReviewerID) {
+			return NotAssignedError{}
+		}
+		if err := s.checkReviewer(ctx, pr, newReviewerID); err != nil {
+			return err
+		}
+		if err := s.prRepo.ReplaceReviewer(ctx, prID, oldReviewerID, newReviewerID); err != nil {
+			return err
+		}
+		for i, id := range pr.AssignedReviewers {
+			if id == oldReviewerID {
+				pr.AssignedReviewers[i] = newReviewerID
+			}
+		}
+		if pr.Shadow != nil && pr.Shadow.MentorID == oldReviewerID {
+			pr.Shadow.MentorID = newReviewerID
+		}
+		return s.recordReviewerEvent(ctx, prID, domain.ReviewerReassigned, newReviewerID, oldReviewerID, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.drainAfter(ctx, "reassignment on "+prID)
+	return pr, nil
+}
+
+// GetReviewerEvents returns the manual reviewer changes of a PR, oldest
+// first.
+func (s *PullRequestService) GetReviewerEvents(ctx context.Context, prID string) ([]domain.ReviewerEvent, error) {
+	if _, err := s.prRepo.GetByID(ctx, prID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, AuthorNotFoundError{}
+		}
+		return nil, err
+	}
+	return s.prRepo.GetReviewerEvents(ctx, prID)
+}
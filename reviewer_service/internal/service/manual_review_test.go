@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/repository"
+)
+
+type fakeReviewerUserRepo struct {
+	fakeUserRepo
+	absent map[string]bool
+}
+
+func (r *fakeReviewerUserRepo) IsMember(_ context.Context, userID string, _ int64) (bool, error) {
+	_, ok := r.users[userID]
+	return ok, nil
+}
+
+func (r *fakeReviewerUserRepo) IsAvailable(_ context.Context, userID string) (bool, error) {
+	return !r.absent[userID], nil
+}
+
+type fakeTeamByIDRepo struct {
+	repository.TeamRepository
+	team *domain.Team
+}
+
+func (r *fakeTeamByIDRepo) GetByID(context.Context, int64) (*domain.Team, error) {
+	return r.team, nil
+}
+
+type fakeExclusionRepo struct {
+	repository.ExclusionRepository
+}
+
+func (r fakeExclusionRepo) ExcludedReviewers(context.Context, []string) ([]string, error) {
+	return nil, nil
+}
+
+func TestCheckReviewer(t *testing.T) {
+	archived := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		reviewer string
+		team     domain.Team
+		want     string
+	}{
+		{"available member", "u2", domain.Team{ID: 1}, ""},
+		{"inactive", "u3", domain.Team{ID: 1}, ReviewerInactive},
+		{"absent", "u4", domain.Team{ID: 1}, ReviewerUnavailable},
+		{"archived team", "u2", domain.Team{ID: 1, ArchivedAt: &archived}, ReviewerTeamArchived},
+		{"author", "u1", domain.Team{ID: 1}, ReviewerConflict},
+		{"already assigned", "u5", domain.Team{ID: 1}, ReviewerAlreadyAssigned},
+	}
+	users := &fakeReviewerUserRepo{
+		fakeUserRepo: fakeUserRepo{users: map[string]*domain.User{
+			"u1": {ID: "u1", IsActive: true},
+			"u2": {ID: "u2", IsActive: true},
+			"u3": {ID: "u3"},
+			"u4": {ID: "u4", IsActive: true},
+			"u5": {ID: "u5", IsActive: true},
+		}},
+		absent: map[string]bool{"u4": true},
+	}
+	for _, tc := range tests {
+		team := tc.team
+		s := NewPullRequestService(nil, users, &fakeTeamByIDRepo{team: &team}, nil, nil, fakeExclusionRepo{}, nil, nil, AssignmentOptions{})
+		pr := &domain.PullRequest{ID: "pr-1", AuthorID: "u1", TeamID: 1, AssignedReviewers: []string{"u5"}}
+
+		err := s.checkReviewer(context.Background(), pr, tc.reviewer)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		if e, ok := err.(InvalidReviewerError); !ok || e.Code != tc.want {
+			t.Errorf("%s: error %v, want %s", tc.name, err, tc.want)
+		}
+	}
+}
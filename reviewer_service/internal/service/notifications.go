@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/mail"
+	"net/url"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/notify"
+	"reviewer_service/internal/repository"
+	"strings"
+	"time"
+)
+
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	prRepo           repository.PullRequestRepository
+	userRepo         repository.UserRepository
+	notifier         notify.Notifier
+	defaultMode      string
+	webhookHosts     map[string]bool
+	now              func() time.Time
+}
+
+// NewNotificationService creates the service. Personal Slack webhooks must
+// use https and point to one of webhookHosts.
+func NewNotificationService(notificationRepo repository.NotificationRepository, prRepo repository.PullRequestRepository, userRepo repository.UserRepository, notifier notify.Notifier, defaultMode string, webhookHosts []string) *NotificationService {
+	hosts := make(map[string]bool, len(webhookHosts))
+	for _, h := range webhookHosts {
+		hosts[strings.ToLower(h)] = true
+	}
+	return &NotificationService{notificationRepo: notificationRepo, prRepo: prRepo, userRepo: userRepo, notifier: notifier, defaultMode: defaultMode, webhookHosts: hosts, now: time.Now}
+}
+
+type InvalidNotificationPreferencesError struct {
+	Reason string
+}
+
+func (e InvalidNotificationPreferencesError) Error() string { return e.Reason }
+
+func validNotifyMode(mode string) bool {
+	return mode == domain.NotifyInstant || mode == domain.NotifyDigest || mode == domain.NotifyOff
+}
+
+// SetPreferences replaces the user's notification mode and Slack contact.
+func (s *NotificationService) SetPreferences(ctx context.Context, prefs domain.NotificationPreferences) (*domain.NotificationPreferences, error) {
+	if !validNotifyMode(prefs.Mode) {
+		return nil, InvalidNotificationPreferencesError{Reason: "mode must be instant, digest or off"}
+	}
+	if prefs.SlackWebhookURL != "" && !s.allowedWebhook(prefs.SlackWebhookURL) {
+		return nil, InvalidNotificationPreferencesError{Reason: "slack_webhook_url must be an https URL on an allowed host"}
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, prefs.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
+		return nil, err
+	}
+	if err := s.notificationRepo.SetPreferences(ctx, &prefs); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, prefs.UserID)
+}
+
+// allowedWebhook reports whether raw is an https URL without credentials on
+// an allowed host and the default port, so personal webhooks cannot make
+// the service post to internal addresses.
+func (s *NotificationService) allowedWebhook(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil || (u.Port() != "" && u.Port() != "443") {
+		return false
+	}
+	return s.webhookHosts[strings.ToLower(u.Hostname())]
+}
+
+// GetPreferences returns the user's preferences, with the default mode if
+// they never set any.
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, []string{userID}, s.defaultMode)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefs) == 0 {
+		return nil, UserNotFoundError{}
+	}
+	return &prefs[0], nil
+}
+
+// HandleEvent notifies reviewers assigned or handed a review by an outbox
+// event. Delivery failures are only logged, as a retry would notify again
+// through the channels that succeeded; database errors are returned and
+// retry the event on this sink only.
+func (s *NotificationService) HandleEvent(ctx context.Context, event domain.OutboxEvent) error {
+	var payload struct {
+		ReviewerIDs   []string `json:"reviewer_ids"`
+		OldReviewerID string   `json:"old_reviewer_id"`
+		NewReviewerID string   `json:"new_reviewer_id"`
+	}
+	var kind string
+	switch event.Type {
+	case domain.EventReviewersAssigned:
+		kind = notify.KindAssigned
+	case domain.EventReviewerReplaced:
+		kind = notify.KindReassigned
+	default:
+		return nil
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		log.Printf("Event %d: bad payload: %v", event.ID, err)
+		return nil
+	}
+	recipients := payload.ReviewerIDs
+	if kind == notify.KindReassigned {
+		recipients = []string{payload.NewReviewerID}
+	}
+
+	pr, err := s.prRepo.GetByID(ctx, event.AggregateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	// The event may be published long after the fact.
+	if pr.Status != "OPEN" {
+		return nil
+	}
+
+	s.notify(ctx, notify.Message{
+		Kind:          kind,
+		Recipients:    recipients,
+		PullRequestID: pr.ID,
+		Data: map[string]interface{}{
+			"PullRequestID":      pr.ID,
+			"Title":              pr.Title,
+			"AuthorID":           pr.AuthorID,
+			"ReplacedReviewerID": payload.OldReviewerID,
+		},
+	})
+	return nil
+}
+
+// DigestResult counts the digests handled by one SendDigests run.
+type DigestResult struct {
+	Sent    int
+	Skipped int
+}
+
+// SendDigests sends digest-mode users their pending reviews once a day,
+// after their working day has started in their own timezone. Users with
+// nothing to review are marked as done for the day without a message.
+func (s *NotificationService) SendDigests(ctx context.Context) (*DigestResult, error) {
+	recipients, err := s.notificationRepo.ListDigestRecipients(ctx, s.defaultMode)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DigestResult{}
+	now := s.now()
+	for _, rcpt := range recipients {
+		start, ok := rcpt.Schedule.DayStart(now)
+		if !ok || now.Before(start) || (rcpt.LastDigestAt != nil && !rcpt.LastDigestAt.Before(start)) {
+			continue
+		}
+
+		prs, err := s.prRepo.GetPRsByReviewer(ctx, rcpt.UserID)
+		if err != nil {
+			return result, err
+		}
+		var open []*domain.PullRequest
+		for _, pr := range prs {
+			if pr.Status == "OPEN" {
+				open = append(open, pr)
+			}
+		}
+
+		if len(open) > 0 {
+			s.notify(ctx, notify.Message{
+				Kind:       notify.KindDigest,
+				Recipients: []string{rcpt.UserID},
+				Data: map[string]interface{}{
+					"UserID":       rcpt.UserID,
+					"Username":     rcpt.Username,
+					"PullRequests": open,
+				},
+			})
+			result.Sent++
+		} else {
+			result.Skipped++
+		}
+		if err := s.notificationRepo.MarkDigestSent(ctx, rcpt.UserID, now); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// SummaryResult counts the summaries handled by one SendWeeklySummaries
+// run.
+type SummaryResult struct {
+	Sent    int
+	Skipped int
+}
+
+// SendWeeklySummaries sends every user who has not turned notifications off
+// the PRs merged after their review within the last period and the PRs
+// still waiting for them. Users with neither get nothing.
+func (s *NotificationService) SendWeeklySummaries(ctx context.Context, period time.Duration) (*SummaryResult, error) {
+	recipients, err := s.notificationRepo.ListActive(ctx, s.defaultMode)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SummaryResult{}
+	since := s.now().Add(-period)
+	for _, rcpt := range recipients {
+		prs, err := s.prRepo.GetPRsByReviewer(ctx, rcpt.UserID)
+		if err != nil {
+			return result, err
+		}
+		var pending, reviewed []*domain.PullRequest
+		for _, pr := range prs {
+			switch {
+			case pr.Status == "OPEN":
+				pending = append(pending, pr)
+			case pr.MergedAt != nil && pr.MergedAt.After(since):
+				reviewed = append(reviewed, pr)
+			}
+		}
+		if len(pending)+len(reviewed) == 0 {
+			result.Skipped++
+			continue
+		}
+
+		s.notify(ctx, notify.Message{
+			Kind:       notify.KindWeeklySummary,
+			Recipients: []string{rcpt.UserID},
+			Data: map[string]interface{}{
+				"UserID":   rcpt.UserID,
+				"Username": rcpt.Username,
+				"Since":    since,
+				"Pending":  pending,
+				"Reviewed": reviewed,
+			},
+		})
+		result.Sent++
+	}
+	return result, nil
+}
+
+type UnsubscribeTokenNotFoundError struct{}
+
+func (e UnsubscribeTokenNotFoundError) Error() string { return "unsubscribe token not found" }
+
+// SetEmail sets the address the user's emails go to, with a new
+// unsubscribe token; an empty email stops emails altogether.
+func (s *NotificationService) SetEmail(ctx context.Context, userID, email string) (*domain.NotificationPreferences, error) {
+	var token string
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return nil, InvalidNotificationPreferencesError{Reason: "email must be a plain address such as dev@example.com"}
+		}
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		token = hex.EncodeToString(b)
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
+		return nil, err
+	}
+	if err := s.notificationRepo.SetEmail(ctx, userID, email, token); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// Unsubscribe stops emails to the user the token was issued to.
+func (s *NotificationService) Unsubscribe(ctx context.Context, token string) error {
+	ok, err := s.notificationRepo.Unsubscribe(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return UnsubscribeTokenNotFoundError{}
+	}
+	return nil
+}
+
+// notify logs delivery failures; a lost notification must not stop the job.
+func (s *NotificationService) notify(ctx context.Context, msg notify.Message) {
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		log.Printf("Notification %s for %s failed: %v", msg.Kind, msg.PullRequestID, err)
+	}
+}
@@ -1,22 +1,29 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"math/rand"
 	"reviewer_service/internal/domain"
 	"reviewer_service/internal/repository"
+	"strings"
 	"time"
 )
 
 type PullRequestService struct {
-	prRepo   repository.PullRequestRepository
-	userRepo repository.UserRepository
-	teamRepo repository.TeamRepository
+	prRepo         repository.PullRequestRepository
+	userRepo       repository.UserRepository
+	teamRepo       repository.TeamRepository
+	codeOwnersRepo repository.CodeOwnersRepository
+	queueRepo      repository.ReviewQueueRepository
+	exclusionRepo  repository.ExclusionRepository
+	tx             repository.TxManager
+	jobs           JobQueue
+	opts           AssignmentOptions
 }
 
-func NewPullRequestService(prRepo repository.PullRequestRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository) *PullRequestService {
-	return &PullRequestService{prRepo: prRepo, userRepo: userRepo, teamRepo: teamRepo}
+func NewPullRequestService(prRepo repository.PullRequestRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository, codeOwnersRepo repository.CodeOwnersRepository, queueRepo repository.ReviewQueueRepository, exclusionRepo repository.ExclusionRepository, tx repository.TxManager, jobs JobQueue, opts AssignmentOptions) *PullRequestService {
+	return &PullRequestService{prRepo: prRepo, userRepo: userRepo, teamRepo: teamRepo, codeOwnersRepo: codeOwnersRepo, queueRepo: queueRepo, exclusionRepo: exclusionRepo, tx: tx, jobs: jobs, opts: opts}
 }
 
 type PullRequestExistsError struct{}
@@ -27,6 +34,16 @@ type AuthorNotFoundError struct{}
 
 func (e AuthorNotFoundError) Error() string { return "author not found" }
 
+type AuthorNotInTeamError struct{}
+
+func (e AuthorNotInTeamError) Error() string { return "author is not a member of the team" }
+
+type CoAuthorNotFoundError struct {
+	UserID string
+}
+
+func (e CoAuthorNotFoundError) Error() string { return "co-author " + e.UserID + " not found" }
+
 type PRMergedError struct{}
 
 func (e PRMergedError) Error() string { return "cannot reassign on merged PR" }
@@ -39,58 +56,155 @@ type NoCandidateError struct{}
 
 func (e NoCandidateError) Error() string { return "no active replacement candidate in team" }
 
-func (s *PullRequestService) CreatePullRequest(id, name, authorID string) (*domain.PullRequest, error) {
-	existing, _ := s.prRepo.GetByID(id)
+// NewPullRequest is the input of CreatePullRequest.
+type NewPullRequest struct {
+	ID       string
+	Name     string
+	AuthorID string
+	// CoAuthors are excluded from review like the author.
+	CoAuthors []string
+	// TeamName defaults to the author's primary team.
+	TeamName string
+	// Feature is a branch name or feature tag shared by follow-up PRs.
+	Feature      string
+	ChangedFiles []string
+	// RequiredSkills are skill tags, or labels, the reviewers should cover.
+	RequiredSkills []string
+	// Priority orders the review queue when it drains by priority.
+	Priority int
+}
+
+// CreatePullRequest opens a PR for the requested team, which defaults to
+// the author's primary team. Reviewers are drawn from that team.
+func (s *PullRequestService) CreatePullRequest(ctx context.Context, req NewPullRequest) (*domain.PullRequest, error) {
+	existing, err := s.prRepo.GetByID(ctx, req.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
 	if existing != nil {
 		return nil, PullRequestExistsError{}
 	}
 
-	teamID, err := s.userRepo.GetTeamIDByUserID(authorID)
+	required, err := normalizeSkills(req.RequiredSkills)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, AuthorNotFoundError{}
-		}
 		return nil, err
 	}
-
-	candidates, err := s.userRepo.GetActiveUsersInTeamExcluding(teamID, authorID)
+	team, err := s.resolvePRTeam(ctx, req.AuthorID, req.TeamName)
 	if err != nil {
 		return nil, err
 	}
-
-	var reviewers []string
-	for i, user := range candidates {
-		if i >= 2 {
-			break
-		}
-		reviewers = append(reviewers, user.ID)
+	coAuthors, err := s.resolveCoAuthors(ctx, req.AuthorID, req.CoAuthors)
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
 	pr := &domain.PullRequest{
-		ID:                id,
-		Title:             name,
-		AuthorID:          authorID,
-		Status:            "OPEN",
-		AssignedReviewers: reviewers,
-		CreatedAt:         &now,
-		MergedAt:          nil,
+		ID:             req.ID,
+		Title:          req.Name,
+		AuthorID:       req.AuthorID,
+		CoAuthors:      coAuthors,
+		Feature:        normalizeFeature(req.Feature),
+		TeamID:         team.ID,
+		TeamName:       team.Name,
+		Status:         "OPEN",
+		ChangedFiles:   normalizePaths(req.ChangedFiles),
+		RequiredSkills: required,
+		CreatedAt:      &now,
+		MergedAt:       nil,
 	}
 
-	if err := s.prRepo.Create(pr); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		picked, warnings, err := s.pickEscalating(ctx, team.ID, pr, nil, nil, s.opts.DefaultReviewers)
+		if err != nil {
+			return err
+		}
+
+		var reviewers []string
+		for _, user := range picked {
+			reviewers = append(reviewers, user.ID)
+		}
+		pr.AssignedReviewers = reviewers
+		pr.Warnings = warnings
+		pr.UnmetSkills = unmetSkills(required, picked)
+
+		if err := s.prRepo.Create(ctx, pr); err != nil {
+			return err
+		}
+		if err := s.prRepo.AssignReviewers(ctx, pr.ID, reviewers); err != nil {
+			return err
+		}
+		if err := s.assignShadow(ctx, team.ID, pr, picked); err != nil {
+			return err
+		}
+
+		if missing := s.opts.DefaultReviewers - len(picked); missing > 0 && hasWarning(warnings, CapacityReached) {
+			pr.PendingReviewers = missing
+			return s.queueRepo.Enqueue(ctx, domain.QueueEntry{PullRequestID: pr.ID, Slots: missing, Priority: req.Priority})
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	if err := s.prRepo.AssignReviewers(pr.ID, reviewers); err != nil {
+	return pr, nil
+}
+
+func (s *PullRequestService) resolvePRTeam(ctx context.Context, authorID, teamName string) (*domain.Team, error) {
+	primary, err := s.userRepo.GetTeamByUserID(ctx, authorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, AuthorNotFoundError{}
+		}
 		return nil, err
 	}
+	if teamName == "" || teamName == primary.Name {
+		return primary, nil
+	}
 
-	return pr, nil
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, TeamNotFoundError{}
+	}
+	member, err := s.userRepo.IsMember(ctx, authorID, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, AuthorNotInTeamError{}
+	}
+	return team, nil
+}
+
+// resolveCoAuthors drops blanks, duplicates and the author from ids and
+// checks that the rest exist.
+func (s *PullRequestService) resolveCoAuthors(ctx context.Context, authorID string, ids []string) ([]string, error) {
+	seen := map[string]bool{authorID: true}
+	var coAuthors []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := s.userRepo.GetUserByID(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, CoAuthorNotFoundError{UserID: id}
+			}
+			return nil, err
+		}
+		coAuthors = append(coAuthors, id)
+	}
+	return coAuthors, nil
 }
 
 const StatusMerged = "MERGED"
 
-func (s *PullRequestService) MergePullRequest(prID string) (*domain.PullRequest, error) {
-	pr, err := s.prRepo.GetByID(prID)
+func (s *PullRequestService) MergePullRequest(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, AuthorNotFoundError{}
@@ -106,18 +220,29 @@ func (s *PullRequestService) MergePullRequest(prID string) (*domain.PullRequest,
 	}
 
 	now := time.Now()
-	if err := s.prRepo.Merge(prID, now); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.prRepo.Merge(ctx, prID, now); err != nil {
+			return err
+		}
+		if pr.PendingReviewers > 0 {
+			return s.queueRepo.Dequeue(ctx, prID)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	pr.Status = "MERGED"
 	pr.MergedAt = &now
+	pr.PendingReviewers = 0
+	s.drainAfter(ctx, "merge of "+prID)
 
 	return pr, nil
 }
 
-func (s *PullRequestService) ReassignReviewer(prID, oldReviewerID string) (newReviewerID string, pr *domain.PullRequest, err error) {
-	pr, err = s.prRepo.GetByID(prID)
+func (s *PullRequestService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (newReviewerID string, pr *domain.PullRequest, err error) {
+	pr, err = s.prRepo.GetByID(ctx, prID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", nil, AuthorNotFoundError{}
@@ -132,7 +257,7 @@ func (s *PullRequestService) ReassignReviewer(prID, oldReviewerID string) (newRe
 		return "", nil, PRMergedError{}
 	}
 
-	reviewers, err := s.prRepo.GetReviewers(prID)
+	reviewers, err := s.prRepo.GetReviewers(ctx, prID)
 	if err != nil {
 		return "", nil, err
 	}
@@ -148,25 +273,27 @@ func (s *PullRequestService) ReassignReviewer(prID, oldReviewerID string) (newRe
 		return "", nil, NotAssignedError{}
 	}
 
-	team, err := s.userRepo.GetTeamByUserID(oldReviewerID)
+	teamID, err := s.reviewTeamID(ctx, pr, oldReviewerID)
 	if err != nil {
 		return "", nil, err
 	}
 
-	candidates, err := s.userRepo.GetActiveUsersInTeamExcluding(team.ID, oldReviewerID)
+	others := otherReviewers(reviewers, oldReviewerID)
+	kept, err := s.reviewerProfiles(ctx, teamID, others)
 	if err != nil {
 		return "", nil, err
 	}
-
-	if len(candidates) == 0 {
+	exclude := append([]string{oldReviewerID}, others...)
+	picked, warnings, err := s.pickEscalating(ctx, teamID, pr, exclude, kept, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(picked) == 0 {
 		return "", nil, NoCandidateError{}
 	}
+	newReviewerID = picked[0].ID
 
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	newReviewer := candidates[rng.Intn(len(candidates))]
-	newReviewerID = newReviewer.ID
-
-	if err := s.prRepo.ReplaceReviewer(prID, oldReviewerID, newReviewerID); err != nil {
+	if err := s.prRepo.ReplaceReviewer(ctx, prID, oldReviewerID, newReviewerID); err != nil {
 		return "", nil, err
 	}
 
@@ -176,12 +303,92 @@ func (s *PullRequestService) ReassignReviewer(prID, oldReviewerID string) (newRe
 			break
 		}
 	}
+	pr.Warnings = warnings
+	pr.UnmetSkills = unmetSkills(pr.RequiredSkills, append(kept, picked[0]))
+	s.drainAfter(ctx, "reassignment on "+prID)
 
 	return newReviewerID, pr, nil
 }
 
-func (s *PullRequestService) GetReviewPRs(userID string) ([]*domain.PullRequest, error) {
-	_, err := s.userRepo.GetTeamIDByUserID(userID)
+// reviewTeamID returns the team replacement reviewers come from: the PR's
+// team, or fallbackUserID's primary team when the PR's team was deleted.
+func (s *PullRequestService) reviewTeamID(ctx context.Context, pr *domain.PullRequest, fallbackUserID string) (int64, error) {
+	if pr.TeamID != 0 {
+		return pr.TeamID, nil
+	}
+	return s.userRepo.GetTeamIDByUserID(ctx, fallbackUserID)
+}
+
+// replaceWithinTeam swaps oldReviewerID on pr for an eligible member of
+// teamID, escalating up the hierarchy if needed and skipping the authors and
+// anyone already reviewing. It returns "" when nobody qualifies, including
+// when the assignment rules reject every candidate.
+func (s *PullRequestService) replaceWithinTeam(ctx context.Context, pr *domain.PullRequest, oldReviewerID string, teamID int64, current []string) (string, error) {
+	others := otherReviewers(current, oldReviewerID)
+	kept, err := s.reviewerProfiles(ctx, teamID, others)
+	if err != nil {
+		return "", err
+	}
+	if err := s.loadAssignmentDetails(ctx, pr); err != nil {
+		return "", err
+	}
+	exclude := append([]string{oldReviewerID}, others...)
+	picked, _, err := s.pickEscalating(ctx, teamID, pr, exclude, kept, 1)
+	if _, ok := err.(AssignmentRuleError); ok {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(picked) == 0 {
+		return "", nil
+	}
+	if err := s.prRepo.ReplaceReviewer(ctx, pr.ID, oldReviewerID, picked[0].ID); err != nil {
+		return "", err
+	}
+	return picked[0].ID, nil
+}
+
+// loadAssignmentDetails fills the changed files, required skills,
+// co-authors and shadow of a PR that was loaded in bulk without them.
+func (s *PullRequestService) loadAssignmentDetails(ctx context.Context, pr *domain.PullRequest) error {
+	var err error
+	if pr.ChangedFiles == nil {
+		if pr.ChangedFiles, err = s.prRepo.GetChangedFiles(ctx, pr.ID); err != nil {
+			return err
+		}
+	}
+	if pr.RequiredSkills == nil {
+		if pr.RequiredSkills, err = s.prRepo.GetRequiredSkills(ctx, pr.ID); err != nil {
+			return err
+		}
+	}
+	if pr.CoAuthors == nil {
+		if pr.CoAuthors, err = s.prRepo.GetCoAuthors(ctx, pr.ID); err != nil {
+			return err
+		}
+	}
+	if pr.Shadow == nil {
+		pr.Shadow, err = s.prRepo.GetShadow(ctx, pr.ID)
+	}
+	return err
+}
+
+// otherReviewers returns reviewers without excludeID and without duplicates.
+func otherReviewers(reviewers []string, excludeID string) []string {
+	seen := map[string]bool{excludeID: true}
+	var out []string
+	for _, id := range reviewers {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (s *PullRequestService) GetReviewPRs(ctx context.Context, userID string) ([]*domain.PullRequest, error) {
+	_, err := s.userRepo.GetTeamIDByUserID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, AuthorNotFoundError{}
@@ -189,13 +396,61 @@ func (s *PullRequestService) GetReviewPRs(userID string) ([]*domain.PullRequest,
 		return nil, err
 	}
 
-	prs, err := s.prRepo.GetPRsByReviewer(userID)
+	prs, err := s.prRepo.GetPRsByReviewer(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	return prs, nil
 }
 
-func (s *PullRequestService) GetReviewStats() (map[string]int, error) {
-	return s.prRepo.GetReviewStats()
+func (s *PullRequestService) GetReviewStats(ctx context.Context) (map[string]int, error) {
+	return s.prRepo.GetReviewStats(ctx)
+}
+
+type TeamStatsEntry struct {
+	Team *domain.Team
+	// Own covers PRs of the team itself, Total adds all descendant teams.
+	Own   domain.TeamStats
+	Total domain.TeamStats
+}
+
+// GetTeamStats returns per-team PR statistics rolled up along the hierarchy.
+func (s *PullRequestService) GetTeamStats(ctx context.Context) ([]TeamStatsEntry, error) {
+	teams, err := s.teamRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.prRepo.GetTeamStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int64][]int64)
+	for _, t := range teams {
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t.ID)
+		}
+	}
+
+	var total func(id int64, visited map[int64]bool) domain.TeamStats
+	total = func(id int64, visited map[int64]bool) domain.TeamStats {
+		visited[id] = true
+		sum := own[id]
+		for _, child := range children[id] {
+			if !visited[child] {
+				sum.Add(total(child, visited))
+			}
+		}
+		return sum
+	}
+
+	entries := make([]TeamStatsEntry, 0, len(teams))
+	for _, t := range teams {
+		entries = append(entries, TeamStatsEntry{
+			Team:  t,
+			Own:   own[t.ID],
+			Total: total(t.ID, make(map[int64]bool)),
+		})
+	}
+	return entries, nil
 }
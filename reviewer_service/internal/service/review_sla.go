@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"log"
+	"reviewer_service/internal/domain"
+	"reviewer_service/internal/notify"
+	"reviewer_service/internal/repository"
+	"time"
+)
+
+const (
+	EscalationReassign = "reassign"
+	EscalationAddLead  = "add_lead"
+)
+
+// SLAOptions are counted in the reviewer's working hours from their
+// assignment; teams may override ReviewTime and EscalationAfter.
+type SLAOptions struct {
+	// ReviewTime is when a review becomes overdue; 0 disables the SLA.
+	ReviewTime time.Duration
+	// EscalationAfter is when an overdue review is escalated with
+	// EscalationAction; 0 disables escalation.
+	EscalationAfter  time.Duration
+	EscalationAction string
+}
+
+type SLAService struct {
+	prRepo    repository.PullRequestRepository
+	userRepo  repository.UserRepository
+	teamRepo  repository.TeamRepository
+	prService *PullRequestService
+	notifier  notify.Notifier
+	tx        repository.TxManager
+	opts      SLAOptions
+}
+
+func NewSLAService(prRepo repository.PullRequestRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository, prService *PullRequestService, notifier notify.Notifier, tx repository.TxManager, opts SLAOptions) *SLAService {
+	return &SLAService{prRepo: prRepo, userRepo: userRepo, teamRepo: teamRepo, prService: prService, notifier: notifier, tx: tx, opts: opts}
+}
+
+type InvalidSLAError struct {
+	Reason string
+}
+
+func (e InvalidSLAError) Error() string { return e.Reason }
+
+type OverdueReview struct {
+	Assignment domain.ReviewAssignment
+	// Elapsed is counted in the reviewer's working hours.
+	Elapsed time.Duration
+	DueAt   time.Time
+}
+
+// slaClock evaluates assignments at a fixed time, caching schedules and
+// team thresholds across them.
+type slaClock struct {
+	s         *SLAService
+	now       time.Time
+	schedules map[string]domain.WorkSchedule
+	teams     map[int64][2]time.Duration
+}
+
+func (s *SLAService) newClock() *slaClock {
+	return &slaClock{s: s, now: time.Now(), schedules: map[string]domain.WorkSchedule{}, teams: map[int64][2]time.Duration{}}
+}
+
+// thresholds returns the review SLA and escalation threshold of teamID.
+func (c *slaClock) thresholds(ctx context.Context, teamID int64) (time.Duration, time.Duration, error) {
+	if t, ok := c.teams[teamID]; ok {
+		return t[0], t[1], nil
+	}
+	sla, after := c.s.opts.ReviewTime, c.s.opts.EscalationAfter
+	if teamID != 0 {
+		team, err := c.s.teamRepo.GetByID(ctx, teamID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if team.ReviewSLA != nil {
+			sla = *team.ReviewSLA
+		}
+		if team.EscalationAfter != nil {
+			after = *team.EscalationAfter
+		}
+	}
+	c.teams[teamID] = [2]time.Duration{sla, after}
+	return sla, after, nil
+}
+
+func (c *slaClock) schedule(ctx context.Context, userID string) (domain.WorkSchedule, error) {
+	if schedule, ok := c.schedules[userID]; ok {
+		return schedule, nil
+	}
+	user, err := c.s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.WorkSchedule{}, err
+	}
+	c.schedules[userID] = user.Schedule
+	return user.Schedule, nil
+}
+
+// ListOverdue returns open reviews past their SLA, of teamName or of all
+// teams when it is empty.
+func (s *SLAService) ListOverdue(ctx context.Context, teamName string) ([]OverdueReview, error) {
+	var teamID int64
+	if teamName != "" {
+		team, err := s.teamRepo.GetByName(ctx, teamName)
+		if err != nil {
+			return nil, err
+		}
+		if team == nil {
+			return nil, TeamNotFoundError{}
+		}
+		teamID = team.ID
+	}
+	assignments, err := s.prRepo.ListOpenAssignments(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	clock := s.newClock()
+	var overdue []OverdueReview
+	for _, a := range assignments {
+		sla, _, err := clock.thresholds(ctx, a.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if sla <= 0 {
+			continue
+		}
+		schedule, err := clock.schedule(ctx, a.ReviewerID)
+		if err != nil {
+			return nil, err
+		}
+		if elapsed := schedule.BusinessDuration(a.AssignedAt, clock.now); elapsed >= sla {
+			overdue = append(overdue, OverdueReview{Assignment: a, Elapsed: elapsed, DueAt: schedule.AddBusiness(a.AssignedAt, sla)})
+		}
+	}
+	return overdue, nil
+}
+
+type SLAResult struct {
+	Breached  int
+	Escalated int
+}
+
+// CheckReviewSLAs marks newly overdue reviews and notifies their reviewers,
+// then escalates reviews past the escalation threshold: the reviewer is
+// replaced, or a lead of the PR's team is added. A PR where reassignment
+// finds nobody is flagged as needing attention.
+func (s *SLAService) CheckReviewSLAs(ctx context.Context) (*SLAResult, error) {
+	assignments, err := s.prRepo.ListOpenAssignments(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SLAResult{}
+	clock := s.newClock()
+	for _, a := range assignments {
+		sla, after, err := clock.thresholds(ctx, a.TeamID)
+		if err != nil {
+			return result, err
+		}
+		schedule, err := clock.schedule(ctx, a.ReviewerID)
+		if err != nil {
+			return result, err
+		}
+		elapsed := schedule.BusinessDuration(a.AssignedAt, clock.now)
+
+		if sla > 0 && elapsed >= sla && a.BreachedAt == nil {
+			if err := s.prRepo.MarkBreached(ctx, a.PullRequestID, a.ReviewerID, clock.now); err != nil {
+				return result, err
+			}
+			result.Breached++
+			s.notify(ctx, notify.Message{
+				Kind:          notify.KindSLABreached,
+				Recipients:    []string{a.ReviewerID},
+				PullRequestID: a.PullRequestID,
+				Data: map[string]interface{}{
+					"PullRequestID": a.PullRequestID,
+					"Title":         a.Title,
+					"Elapsed":       elapsed.Round(time.Minute),
+					"SLA":           sla,
+				},
+			})
+		}
+
+		if after > 0 && elapsed >= after && a.EscalatedAt == nil {
+			var added string
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				var err error
+				added, err = s.escalate(ctx, a, clock.now)
+				return err
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Escalated++
+
+			recipients := []string{a.ReviewerID, a.AuthorID}
+			if added != "" {
+				recipients = append(recipients, added)
+			}
+			s.notify(ctx, notify.Message{
+				Kind:          notify.KindEscalated,
+				Recipients:    recipients,
+				PullRequestID: a.PullRequestID,
+				Data: map[string]interface{}{
+					"PullRequestID": a.PullRequestID,
+					"Title":         a.Title,
+					"Elapsed":       elapsed.Round(time.Minute),
+					"ReviewerID":    a.ReviewerID,
+					"AddedID":       added,
+				},
+			})
+		}
+	}
+	return result, nil
+}
+
+// escalate applies the escalation action to an overdue assignment and
+// returns the reviewer added, if any.
+func (s *SLAService) escalate(ctx context.Context, a domain.ReviewAssignment, now time.Time) (string, error) {
+	pr, err := s.prRepo.GetByID(ctx, a.PullRequestID)
+	if err != nil {
+		return "", err
+	}
+	teamID, err := s.prService.reviewTeamID(ctx, pr, a.ReviewerID)
+	if err != nil {
+		return "", err
+	}
+
+	if s.opts.EscalationAction == EscalationAddLead {
+		lead, err := s.pickLead(ctx, teamID, pr)
+		if err != nil {
+			return "", err
+		}
+		if lead != "" {
+			if err := s.prRepo.AssignReviewers(ctx, pr.ID, []string{lead}); err != nil {
+				return "", err
+			}
+		}
+		return lead, s.prRepo.MarkEscalated(ctx, pr.ID, a.ReviewerID, now)
+	}
+
+	newID, err := s.prService.replaceWithinTeam(ctx, pr, a.ReviewerID, teamID, pr.AssignedReviewers)
+	if err != nil || newID != "" {
+		return newID, err
+	}
+	if err := s.prRepo.SetNeedsAttention(ctx, pr.ID, "no replacement for overdue reviewer "+a.ReviewerID); err != nil {
+		return "", err
+	}
+	return "", s.prRepo.MarkEscalated(ctx, pr.ID, a.ReviewerID, now)
+}
+
+// pickLead returns an available lead of teamID who may review pr and is not
+// on it yet, or "" if there is none.
+func (s *SLAService) pickLead(ctx context.Context, teamID int64, pr *domain.PullRequest) (string, error) {
+	exclude, err := s.prService.conflictsOf(ctx, pr)
+	if err != nil {
+		return "", err
+	}
+	exclude = append(exclude, pr.AssignedReviewers...)
+	if pr.Shadow != nil {
+		exclude = append(exclude, pr.Shadow.ReviewerID)
+	}
+	users, err := s.userRepo.GetActiveUsersInTeamExcluding(ctx, teamID, exclude)
+	if err != nil {
+		return "", err
+	}
+	var leads []domain.User
+	for _, u := range users {
+		if u.Role == RoleLead {
+			leads = append(leads, u)
+		}
+	}
+	if len(leads) == 0 {
+		return "", nil
+	}
+	picked, err := s.prService.pickReviewers(ctx, leads, 1)
+	if err != nil {
+		return "", err
+	}
+	return picked[0].ID, nil
+}
+
+// notify logs delivery failures; a lost notification must not stop the job.
+func (s *SLAService) notify(ctx context.Context, msg notify.Message) {
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		log.Printf("Notification %s for %s failed: %v", msg.Kind, msg.PullRequestID, err)
+	}
+}
+
+// SetTeamSLA sets the team's review SLA and escalation threshold; nil
+// falls back to the configured value and 0 disables it for the team. With
+// the fallbacks applied, escalation must be disabled or come no earlier
+// than the SLA, as only overdue reviews are escalated.
+func (s *SLAService) SetTeamSLA(ctx context.Context, teamName string, sla, escalationAfter *time.Duration) (*domain.Team, error) {
+	if (sla != nil && *sla < 0) || (escalationAfter != nil && *escalationAfter < 0) {
+		return nil, InvalidSLAError{Reason: "review_sla and escalation_after must not be negative"}
+	}
+	effectiveSLA, effectiveAfter := s.opts.ReviewTime, s.opts.EscalationAfter
+	if sla != nil {
+		effectiveSLA = *sla
+	}
+	if escalationAfter != nil {
+		effectiveAfter = *escalationAfter
+	}
+	if effectiveAfter > 0 && effectiveSLA == 0 {
+		return nil, InvalidSLAError{Reason: "escalation_after needs a review SLA; set escalation_after to 0 or review_sla above 0"}
+	}
+	if effectiveAfter > 0 && effectiveAfter < effectiveSLA {
+		return nil, InvalidSLAError{Reason: "escalation_after must be 0 or at least the review SLA (" + effectiveSLA.String() + ")"}
+	}
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, TeamNotFoundError{}
+	}
+	if err := s.teamRepo.SetReviewSLA(ctx, team.ID, sla, escalationAfter); err != nil {
+		return nil, err
+	}
+	team.ReviewSLA = sla
+	team.EscalationAfter = escalationAfter
+	return team, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reviewer_service/internal/domain"
+)
+
+type fakeSLATeamRepo struct {
+	fakeTeamRepo
+	saved bool
+}
+
+func (r *fakeSLATeamRepo) SetReviewSLA(context.Context, int64, *time.Duration, *time.Duration) error {
+	r.saved = true
+	return nil
+}
+
+func TestSetTeamSLAValidatesEscalation(t *testing.T) {
+	d := func(v time.Duration) *time.Duration { return &v }
+	tests := []struct {
+		name            string
+		opts            SLAOptions
+		sla, escalation *time.Duration
+		ok              bool
+	}{
+		{"both set", SLAOptions{}, d(8 * time.Hour), d(12 * time.Hour), true},
+		{"escalation at the sla", SLAOptions{}, d(8 * time.Hour), d(8 * time.Hour), true},
+		{"escalation disabled", SLAOptions{ReviewTime: 8 * time.Hour, EscalationAfter: 16 * time.Hour}, d(4 * time.Hour), d(0), true},
+		{"sla and escalation disabled", SLAOptions{ReviewTime: 8 * time.Hour}, d(0), d(0), true},
+		{"inherits both", SLAOptions{ReviewTime: 8 * time.Hour, EscalationAfter: 16 * time.Hour}, nil, nil, true},
+		{"escalation before the sla", SLAOptions{}, d(8 * time.Hour), d(4 * time.Hour), false},
+		{"escalation before the inherited sla", SLAOptions{ReviewTime: 8 * time.Hour}, nil, d(4 * time.Hour), false},
+		{"sla above the inherited escalation", SLAOptions{ReviewTime: 8 * time.Hour, EscalationAfter: 16 * time.Hour}, d(24 * time.Hour), nil, false},
+		{"escalation without an sla", SLAOptions{ReviewTime: 8 * time.Hour}, d(0), d(4 * time.Hour), false},
+		{"negative sla", SLAOptions{}, d(-time.Hour), nil, false},
+	}
+	for _, tc := range tests {
+		teams := &fakeSLATeamRepo{fakeTeamRepo: fakeTeamRepo{teams: map[string]*domain.Team{"backend": {ID: 1, Name: "backend"}}}}
+		s := NewSLAService(nil, nil, teams, nil, nil, nil, tc.opts)
+
+		_, err := s.SetTeamSLA(context.Background(), "backend", tc.sla, tc.escalation)
+		if _, invalid := err.(InvalidSLAError); tc.ok && err != nil || !tc.ok && !invalid {
+			t.Errorf("%s: error %v, want ok %v", tc.name, err, tc.ok)
+		}
+		if teams.saved != tc.ok {
+			t.Errorf("%s: saved %v, want %v", tc.name, teams.saved, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"reviewer_service/internal/domain"
+)
+
+const (
+	RoleLead   = "lead"
+	RoleSenior = "senior"
+	RoleMember = "member"
+	RoleJunior = "junior"
+
+	RuleViolationReject = "reject"
+	RuleViolationWarn   = "warn"
+
+	// Codes reported when an assignment rule cannot be met.
+	RuleSeniorRequired = "SENIOR_REQUIRED"
+	RuleJuniorLimit    = "JUNIOR_LIMIT"
+)
+
+// AssignmentRules constrain the roles of the reviewers on a PR. Roles are
+// taken from the team the reviewer was drawn from.
+type AssignmentRules struct {
+	RequireSenior bool
+	// MaxJuniors caps juniors per PR; 0 means no cap.
+	MaxJuniors  int
+	OnViolation string
+}
+
+type InvalidRoleError struct{}
+
+func (e InvalidRoleError) Error() string { return "role must be lead, senior, member or junior" }
+
+// AssignmentRuleError is returned in reject mode when the reviewers cannot
+// satisfy the assignment rules.
+type AssignmentRuleError struct {
+	Code string
+}
+
+func (e AssignmentRuleError) Error() string { return "assignment rule not met: " + e.Code }
+
+func ValidRole(role string) bool {
+	switch role {
+	case RoleLead, RoleSenior, RoleMember, RoleJunior:
+		return true
+	}
+	return false
+}
+
+func isSeniorRole(role string) bool {
+	return role == RoleLead || role == RoleSenior
+}
+
+// selectReviewers picks up to n reviewers from the escalation tiers while
+// honouring the assignment rules. kept are reviewers that stay on the PR and
+// count towards the rules and skills. Candidates covering required skills
+// that are still missing go first. It returns the codes of rules that could
+// not be met; in reject mode that is an AssignmentRuleError instead.
+func (s *PullRequestService) selectReviewers(ctx context.Context, tiers [][]domain.User, kept []domain.User, n int, required []string) ([]domain.User, []string, error) {
+	if n <= 0 {
+		return nil, nil, nil
+	}
+	rules := s.opts.Rules
+	uncovered := make(map[string]bool)
+	for _, skill := range unmetSkills(required, kept) {
+		uncovered[skill] = true
+	}
+	coversMissing := func(u domain.User) bool {
+		for _, skill := range u.Skills {
+			if uncovered[skill] {
+				return true
+			}
+		}
+		return false
+	}
+
+	hasSenior := false
+	juniors := 0
+	for _, u := range kept {
+		if isSeniorRole(u.Role) {
+			hasSenior = true
+		}
+		if u.Role == RoleJunior {
+			juniors++
+		}
+	}
+
+	var picked []domain.User
+	used := make(map[string]bool)
+	pickOne := func(allowed func(domain.User) bool) (bool, error) {
+		for _, tier := range tiers {
+			var avail []domain.User
+			for _, u := range tier {
+				if !used[u.ID] && allowed(u) {
+					avail = append(avail, u)
+				}
+			}
+			if len(avail) == 0 {
+				continue
+			}
+			chosen, err := s.pickReviewers(ctx, avail, 1)
+			if err != nil {
+				return false, err
+			}
+			u := chosen[0]
+			picked = append(picked, u)
+			used[u.ID] = true
+			for _, skill := range u.Skills {
+				delete(uncovered, skill)
+			}
+			if isSeniorRole(u.Role) {
+				hasSenior = true
+			}
+			if u.Role == RoleJunior {
+				juniors++
+			}
+			return true, nil
+		}
+		return false, nil
+	}
+	fill := func(allowed func(domain.User) bool) error {
+		for len(picked) < n {
+			ok, err := pickOne(allowed)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+		}
+		return nil
+	}
+
+	var violations []string
+	if rules.RequireSenior && !hasSenior {
+		ok, err := pickOne(func(u domain.User) bool { return isSeniorRole(u.Role) && coversMissing(u) })
+		if err == nil && !ok {
+			ok, err = pickOne(func(u domain.User) bool { return isSeniorRole(u.Role) })
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			violations = append(violations, RuleSeniorRequired)
+		}
+	}
+
+	juniorAllowed := func(u domain.User) bool {
+		return u.Role != RoleJunior || rules.MaxJuniors == 0 || juniors < rules.MaxJuniors
+	}
+	for len(picked) < n && len(uncovered) > 0 {
+		ok, err := pickOne(func(u domain.User) bool { return juniorAllowed(u) && coversMissing(u) })
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := fill(juniorAllowed); err != nil {
+		return nil, nil, err
+	}
+
+	// Whatever is left unpicked now can only be juniors over the cap.
+	if len(picked) < n && rules.MaxJuniors > 0 && hasUnused(tiers, used) {
+		violations = append(violations, RuleJuniorLimit)
+		if rules.OnViolation != RuleViolationReject {
+			if err := fill(func(domain.User) bool { return true }); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if len(violations) > 0 && rules.OnViolation == RuleViolationReject {
+		return nil, violations, AssignmentRuleError{Code: violations[0]}
+	}
+	return picked, violations, nil
+}
+
+func hasUnused(tiers [][]domain.User, used map[string]bool) bool {
+	for _, tier := range tiers {
+		for _, u := range tier {
+			if !used[u.ID] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reviewerProfiles loads the roles of userIDs in teamID and their skills.
+// Users outside the team keep an empty role, which no rule counts.
+func (s *PullRequestService) reviewerProfiles(ctx context.Context, teamID int64, userIDs []string) ([]domain.User, error) {
+	skills, err := s.userRepo.GetSkills(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]domain.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		memberships, err := s.userRepo.GetMemberships(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		u := domain.User{ID: id, Skills: skills[id]}
+		for _, m := range memberships {
+			if m.TeamID == teamID {
+				u.Role = m.Role
+				break
+			}
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
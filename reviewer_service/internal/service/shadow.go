@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+)
+
+// assignShadow adds an opted-in junior of the nearest escalation tier as a
+// shadow of pr, mentored by a senior or lead among reviewers if there is
+// one. Shadows ignore capacity and are never required for a merge.
+func (s *PullRequestService) assignShadow(ctx context.Context, teamID int64, pr *domain.PullRequest, reviewers []domain.User) error {
+	if !s.opts.Shadow || len(reviewers) == 0 {
+		return nil
+	}
+	exclude, err := s.conflictsOf(ctx, pr)
+	if err != nil {
+		return err
+	}
+	for _, u := range reviewers {
+		exclude = append(exclude, u.ID)
+	}
+	tiers, err := s.candidateTiers(ctx, teamID, exclude)
+	if err != nil {
+		return err
+	}
+
+	for _, tier := range tiers {
+		var juniors []domain.User
+		for _, u := range tier {
+			if u.Role == RoleJunior && u.ShadowOptIn {
+				juniors = append(juniors, u)
+			}
+		}
+		if len(juniors) == 0 {
+			continue
+		}
+		chosen, err := s.pickReviewers(ctx, juniors, 1)
+		if err != nil {
+			return err
+		}
+
+		mentor := reviewers[0]
+		for _, u := range reviewers {
+			if isSeniorRole(u.Role) {
+				mentor = u
+				break
+			}
+		}
+		shadow := domain.ShadowReviewer{ReviewerID: chosen[0].ID, MentorID: mentor.ID}
+		if err := s.prRepo.AssignShadow(ctx, pr.ID, shadow); err != nil {
+			return err
+		}
+		pr.Shadow = &shadow
+		return nil
+	}
+	return nil
+}
+
+// GetShadowStats counts shadow reviews per shadow reviewer.
+func (s *PullRequestService) GetShadowStats(ctx context.Context) (map[string]int, error) {
+	return s.prRepo.GetShadowStats(ctx)
+}
+
+// SetShadowOptIn makes the user eligible for shadow reviews in teams where
+// they are a junior.
+func (s *UserService) SetShadowOptIn(ctx context.Context, userID string, optIn bool) (*domain.User, error) {
+	var user *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+		if err := s.userRepo.SetShadowOptIn(ctx, userID, optIn); err != nil {
+			return err
+		}
+		var err error
+		user, err = s.userRepo.GetUserByID(ctx, userID)
+		return err
+	})
+	return user, err
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+	"sort"
+	"strings"
+)
+
+type InvalidSkillError struct{}
+
+func (e InvalidSkillError) Error() string { return "skills must be non-empty tags without spaces" }
+
+// normalizeSkills lower-cases and sorts skills and drops duplicates.
+func normalizeSkills(skills []string) ([]string, error) {
+	seen := make(map[string]bool, len(skills))
+	out := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		skill = strings.ToLower(strings.TrimSpace(skill))
+		if skill == "" || strings.ContainsAny(skill, " \t\n") {
+			return nil, InvalidSkillError{}
+		}
+		if !seen[skill] {
+			seen[skill] = true
+			out = append(out, skill)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// unmetSkills returns the required skills none of users has.
+func unmetSkills(required []string, users []domain.User) []string {
+	have := make(map[string]bool)
+	for _, u := range users {
+		for _, skill := range u.Skills {
+			have[skill] = true
+		}
+	}
+	var unmet []string
+	for _, skill := range required {
+		if !have[skill] {
+			unmet = append(unmet, skill)
+		}
+	}
+	return unmet
+}
+
+// SetSkills replaces the user's skill tags.
+func (s *UserService) SetSkills(ctx context.Context, userID string, skills []string) (*domain.User, error) {
+	skills, err := normalizeSkills(skills)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *domain.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+		if err := s.userRepo.SetSkills(ctx, userID, skills); err != nil {
+			return err
+		}
+		var err error
+		user, err = s.userRepo.GetUserByID(ctx, userID)
+		return err
+	})
+	return user, err
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"reviewer_service/internal/domain"
+	"sort"
+	"time"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyFirst       = "first"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+type AssignmentOptions struct {
+	DefaultReviewers int
+	Strategy         string
+	// EscalationDepth is how many levels up the team hierarchy to look for
+	// reviewers when the PR's team has too few.
+	EscalationDepth int
+	Rules           AssignmentRules
+	// PreferWorkingHours ranks reviewers who are at work, or start within
+	// WorkingHoursLookahead, ahead of the rest.
+	PreferWorkingHours    bool
+	WorkingHoursLookahead time.Duration
+	// ReviewSLA is the working time reviewers have to respond; 0 disables
+	// due dates on review clocks.
+	ReviewSLA time.Duration
+	// MaxOpenReviews is the default cap on open reviews per user; 0 means
+	// unlimited. QueueOrder is QueueOrderFIFO or QueueOrderPriority.
+	MaxOpenReviews int
+	QueueOrder     string
+	// Affinity prefers reviewers of earlier PRs with the same feature.
+	Affinity bool
+	// RotationLimit is how many of an author's PRs in a row, within
+	// RotationWindow, a reviewer may get; 0 disables the limit. RotationMode
+	// is RotationHard or RotationSoft.
+	RotationLimit  int
+	RotationWindow time.Duration
+	RotationMode   string
+	// Shadow adds an opted-in junior as a shadow reviewer to new PRs.
+	Shadow bool
+	// Bots are accepted as the actor of manual reviewer changes besides
+	// user IDs.
+	Bots []string
+}
+
+// pickReviewers selects up to n candidates according to the configured
+// strategy. The candidate slice is not modified.
+func (s *PullRequestService) pickReviewers(ctx context.Context, candidates []domain.User, n int) ([]domain.User, error) {
+	if n <= 0 || len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ordered := make([]domain.User, len(candidates))
+	copy(ordered, candidates)
+
+	switch s.opts.Strategy {
+	case StrategyFirst:
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].ID < ordered[j].ID })
+	case StrategyLeastLoaded:
+		ids := make([]string, len(ordered))
+		for i, u := range ordered {
+			ids[i] = u.ID
+		}
+		load, err := s.prRepo.CountOpenReviews(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rng.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+		sort.SliceStable(ordered, func(i, j int) bool { return load[ordered[i].ID] < load[ordered[j].ID] })
+	default:
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rng.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+	}
+
+	if s.opts.PreferWorkingHours {
+		now := time.Now()
+		horizon := now.Add(s.opts.WorkingHoursLookahead)
+		atWork := make(map[string]bool, len(ordered))
+		for _, u := range ordered {
+			atWork[u.ID] = !u.Schedule.NextStart(now).After(horizon)
+		}
+		sort.SliceStable(ordered, func(i, j int) bool { return atWork[ordered[i].ID] && !atWork[ordered[j].ID] })
+	}
+
+	if len(ordered) > n {
+		ordered = ordered[:n]
+	}
+	return ordered, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"reviewer_service/internal/domain"
+)
+
+type TeamCycleError struct{}
+
+func (e TeamCycleError) Error() string { return "parent team would create a cycle" }
+
+// SetParent places the team under parentName, or makes it top-level when
+// parentName is empty.
+func (s *TeamService) SetParent(ctx context.Context, name, parentName string) (*domain.Team, error) {
+	var team *domain.Team
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		team, err = s.GetTeam(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if parentName == "" {
+			team.ParentID = nil
+			team.ParentName = ""
+			return s.teamRepo.SetParent(ctx, team.ID, nil)
+		}
+
+		parent, err := s.resolveParent(ctx, team.ID, parentName)
+		if err != nil {
+			return err
+		}
+		team.ParentID = &parent.ID
+		team.ParentName = parent.Name
+		return s.teamRepo.SetParent(ctx, team.ID, &parent.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// resolveParent looks up parentName and rejects it if teamID is among its
+// ancestors (or is the parent itself).
+func (s *TeamService) resolveParent(ctx context.Context, teamID int64, parentName string) (*domain.Team, error) {
+	parent, err := s.teamRepo.GetByName(ctx, parentName)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, TeamNotFoundError{}
+	}
+
+	visited := make(map[int64]bool)
+	for ancestor := parent; ; {
+		if ancestor.ID == teamID || visited[ancestor.ID] {
+			return nil, TeamCycleError{}
+		}
+		visited[ancestor.ID] = true
+		if ancestor.ParentID == nil {
+			break
+		}
+		ancestor, err = s.teamRepo.GetByID(ctx, *ancestor.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parent, nil
+}
+
+// GetTeamTree returns the team with its members and all descendant teams.
+func (s *TeamService) GetTeamTree(ctx context.Context, name string) (*domain.Team, error) {
+	team, err := s.GetTeam(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadSubTeams(ctx, team, map[int64]bool{team.ID: true}); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *TeamService) loadSubTeams(ctx context.Context, team *domain.Team, visited map[int64]bool) error {
+	children, err := s.teamRepo.GetChildren(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if visited[child.ID] {
+			continue
+		}
+		visited[child.ID] = true
+
+		full, err := s.teamRepo.GetByName(ctx, child.Name)
+		if err != nil {
+			return err
+		}
+		if full == nil {
+			continue
+		}
+		if err := s.loadSubTeams(ctx, full, visited); err != nil {
+			return err
+		}
+		team.SubTeams = append(team.SubTeams, full)
+	}
+	return nil
+}
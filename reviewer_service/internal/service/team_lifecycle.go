@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"reviewer_service/internal/domain"
+)
+
+const (
+	// Open PR policies for ArchiveTeam.
+	OpenPRsReassign = "reassign"
+	OpenPRsFlag     = "flag"
+
+	// Member policies for DeleteTeam.
+	MembersReject = "reject"
+	MembersMove   = "move"
+	MembersDelete = "delete"
+
+	// Authored PR policies for DeleteTeam with MembersDelete.
+	PullRequestsReject = "reject"
+	PullRequestsDelete = "delete"
+)
+
+type ReviewerChange struct {
+	PullRequestID string
+	OldReviewerID string
+	NewReviewerID string
+}
+
+type ArchiveResult struct {
+	Team       *domain.Team
+	Reassigned []ReviewerChange
+	Flagged    []string
+}
+
+// TeamDeletePolicy spells out what happens to a team's users and their PRs.
+// Nothing is removed implicitly: the caller must opt in to moving or deleting
+// members. Deleting only removes users whose primary team this is; others
+// just lose the membership.
+type TeamDeletePolicy struct {
+	Members        string
+	TargetTeamName string
+	PullRequests   string
+}
+
+type DeleteResult struct {
+	MovedMembers   int
+	DeletedMembers int
+	Flagged        []string
+}
+
+func (s *TeamService) RenameTeam(ctx context.Context, name, newName string) (*domain.Team, error) {
+	var team *domain.Team
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		team, err = s.GetTeam(ctx, name)
+		if err != nil {
+			return err
+		}
+		if newName == name {
+			return nil
+		}
+
+		exists, err := s.teamRepo.Exists(ctx, newName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return TeamExistsError{}
+		}
+
+		if err := s.teamRepo.Rename(ctx, team.ID, newName); err != nil {
+			return err
+		}
+		team.Name = newName
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// ArchiveTeam makes the team's members ineligible for review through it. Their
+// reviewer slots on the team's open PRs, and on any PR when they belong to no
+// other team, are either handed to an eligible member of the PR's team
+// (reassign) or marked as needing attention (flag). Slots that cannot be
+// reassigned are flagged as well.
+func (s *TeamService) ArchiveTeam(ctx context.Context, name, openPRPolicy string) (*ArchiveResult, error) {
+	if openPRPolicy == "" {
+		openPRPolicy = OpenPRsReassign
+	}
+	if openPRPolicy != OpenPRsReassign && openPRPolicy != OpenPRsFlag {
+		return nil, InvalidPolicyError{Reason: "open_prs must be reassign or flag"}
+	}
+
+	result := &ArchiveResult{}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.GetTeam(ctx, name)
+		if err != nil {
+			return err
+		}
+		if team.IsArchived() {
+			result.Team = team
+			return nil
+		}
+
+		if err := s.teamRepo.SetArchived(ctx, team.ID, true); err != nil {
+			return err
+		}
+		team, err = s.teamRepo.GetByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		result.Team = team
+
+		memberIDs := make([]string, len(team.Members))
+		isMember := make(map[string]bool, len(team.Members))
+		hasOtherTeam := make(map[string]bool, len(team.Members))
+		for i, m := range team.Members {
+			memberIDs[i] = m.ID
+			isMember[m.ID] = true
+			memberships, err := s.userRepo.GetMemberships(ctx, m.ID)
+			if err != nil {
+				return err
+			}
+			hasOtherTeam[m.ID] = len(memberships) > 1
+		}
+
+		openPRs, err := s.prRepo.GetOpenPRsWithReviewers(ctx, memberIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, pr := range openPRs {
+			reviewers, err := s.prRepo.GetReviewers(ctx, pr.ID)
+			if err != nil {
+				return err
+			}
+
+			flagged := false
+			for _, reviewerID := range reviewers {
+				if !isMember[reviewerID] || (pr.TeamID != team.ID && hasOtherTeam[reviewerID]) {
+					continue
+				}
+				if openPRPolicy == OpenPRsReassign {
+					newID, err := s.replaceFromPRTeam(ctx, pr, reviewerID, reviewers)
+					if err != nil {
+						return err
+					}
+					if newID != "" {
+						result.Reassigned = append(result.Reassigned, ReviewerChange{
+							PullRequestID: pr.ID,
+							OldReviewerID: reviewerID,
+							NewReviewerID: newID,
+						})
+						reviewers = append(reviewers, newID)
+						continue
+					}
+				}
+				flagged = true
+			}
+
+			if flagged {
+				if err := s.prRepo.SetNeedsAttention(ctx, pr.ID, "reviewer team "+team.Name+" archived"); err != nil {
+					return err
+				}
+				result.Flagged = append(result.Flagged, pr.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replaceFromPRTeam swaps reviewerID for an eligible member of the PR's
+// team, falling back to the author's primary team. It returns "" when no
+// candidate is available.
+func (s *TeamService) replaceFromPRTeam(ctx context.Context, pr *domain.PullRequest, reviewerID string, current []string) (string, error) {
+	teamID, err := s.prService.reviewTeamID(ctx, pr, pr.AuthorID)
+	if err != nil {
+		return "", err
+	}
+	return s.prService.replaceWithinTeam(ctx, pr, reviewerID, teamID, current)
+}
+
+func (s *TeamService) UnarchiveTeam(ctx context.Context, name string) (*domain.Team, error) {
+	team, err := s.GetTeam(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.teamRepo.SetArchived(ctx, team.ID, false); err != nil {
+		return nil, err
+	}
+	team.ArchivedAt = nil
+	return team, nil
+}
+
+func (s *TeamService) DeleteTeam(ctx context.Context, name string, policy TeamDeletePolicy) (*DeleteResult, error) {
+	if policy.Members == "" {
+		policy.Members = MembersReject
+	}
+	if policy.PullRequests == "" {
+		policy.PullRequests = PullRequestsReject
+	}
+	switch policy.Members {
+	case MembersReject, MembersDelete:
+	case MembersMove:
+		if policy.TargetTeamName == "" {
+			return nil, InvalidPolicyError{Reason: "target_team_name is required to move members"}
+		}
+	default:
+		return nil, InvalidPolicyError{Reason: "members must be reject, move or delete"}
+	}
+	if policy.PullRequests != PullRequestsReject && policy.PullRequests != PullRequestsDelete {
+		return nil, InvalidPolicyError{Reason: "pull_requests must be reject or delete"}
+	}
+
+	result := &DeleteResult{}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.GetTeam(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if len(team.Members) > 0 {
+			switch policy.Members {
+			case MembersReject:
+				return TeamNotEmptyError{}
+			case MembersMove:
+				if err := s.moveMembers(ctx, team, policy.TargetTeamName); err != nil {
+					return err
+				}
+				result.MovedMembers = len(team.Members)
+			case MembersDelete:
+				deleted, flagged, err := s.deleteMembers(ctx, team, policy.PullRequests)
+				if err != nil {
+					return err
+				}
+				result.Flagged = flagged
+				result.DeletedMembers = deleted
+			}
+		}
+
+		return s.teamRepo.Delete(ctx, team.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *TeamService) moveMembers(ctx context.Context, team *domain.Team, targetName string) error {
+	if targetName == team.Name {
+		return InvalidPolicyError{Reason: "target team must differ from the deleted team"}
+	}
+	target, err := s.teamRepo.GetByName(ctx, targetName)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return TeamNotFoundError{}
+	}
+	if target.IsArchived() {
+		return TeamArchivedError{}
+	}
+	return s.userRepo.MoveTeamMembers(ctx, team.ID, target.ID)
+}
+
+// deleteMembers deletes users whose primary team is team. The team's PRs,
+// merged ones included, are rejected or deleted per policy: their review
+// history goes with the team. PRs the users filed under other teams are
+// never deleted here, so they block the deletion. Open PRs elsewhere that
+// lose a reviewer are flagged.
+func (s *TeamService) deleteMembers(ctx context.Context, team *domain.Team, prPolicy string) (int, []string, error) {
+	var memberIDs []string
+	for _, m := range team.Members {
+		if m.TeamID == team.ID {
+			memberIDs = append(memberIDs, m.ID)
+		}
+	}
+
+	elsewhere, err := s.prRepo.CountByAuthorsOutsideTeam(ctx, memberIDs, team.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if elsewhere > 0 {
+		return 0, nil, PullRequestsInOtherTeamsError{}
+	}
+	authored, err := s.prRepo.CountByTeam(ctx, team.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if authored > 0 {
+		if prPolicy == PullRequestsReject {
+			return 0, nil, TeamHasPullRequestsError{}
+		}
+		if err := s.prRepo.DeleteByTeam(ctx, team.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+	openPRs, err := s.prRepo.GetOpenPRsWithReviewers(ctx, memberIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var flagged []string
+	for _, pr := range openPRs {
+		if err := s.prRepo.SetNeedsAttention(ctx, pr.ID, "reviewer team "+team.Name+" deleted"); err != nil {
+			return 0, nil, err
+		}
+		flagged = append(flagged, pr.ID)
+	}
+
+	if err := s.userRepo.DeleteUsers(ctx, memberIDs); err != nil {
+		return 0, nil, err
+	}
+	return len(memberIDs), flagged, nil
+}
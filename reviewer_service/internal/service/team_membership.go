@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+)
+
+type NotTeamMemberError struct{}
+
+func (e NotTeamMemberError) Error() string { return "user is not a member of the team" }
+
+type PrimaryMembershipError struct{}
+
+func (e PrimaryMembershipError) Error() string { return "cannot remove the user's primary team" }
+
+// AddMember adds userID to teamName as a secondary member with the given
+// role (RoleMember when empty). Reviewers for the team's PRs are then drawn
+// from it as well.
+func (s *TeamService) AddMember(ctx context.Context, teamName, userID, role string) (*domain.Membership, error) {
+	if role == "" {
+		role = RoleMember
+	}
+	if !ValidRole(role) {
+		return nil, InvalidRoleError{}
+	}
+
+	var membership *domain.Membership
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.GetTeam(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		if team.IsArchived() {
+			return TeamArchivedError{}
+		}
+		if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+
+		member, err := s.userRepo.IsMember(ctx, userID, team.ID)
+		if err != nil {
+			return err
+		}
+		if member {
+			return AlreadyInTeamError{}
+		}
+		if err := s.userRepo.AddMembership(ctx, userID, team.ID, role); err != nil {
+			return err
+		}
+
+		membership, err = s.findMembership(ctx, userID, team.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// RemoveMember drops a secondary membership. The primary team can only be
+// changed by moving the user.
+func (s *TeamService) RemoveMember(ctx context.Context, teamName, userID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.GetTeam(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		membership, err := s.findMembership(ctx, userID, team.ID)
+		if err != nil {
+			return err
+		}
+		if membership.IsPrimary {
+			return PrimaryMembershipError{}
+		}
+		return s.userRepo.RemoveMembership(ctx, userID, team.ID)
+	})
+}
+
+// SetRole changes the user's role within teamName.
+func (s *TeamService) SetRole(ctx context.Context, teamName, userID, role string) (*domain.Membership, error) {
+	if !ValidRole(role) {
+		return nil, InvalidRoleError{}
+	}
+
+	var membership *domain.Membership
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.GetTeam(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		membership, err = s.findMembership(ctx, userID, team.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.userRepo.SetRole(ctx, userID, team.ID, role); err != nil {
+			return err
+		}
+		membership.Role = role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+func (s *TeamService) findMembership(ctx context.Context, userID string, teamID int64) (*domain.Membership, error) {
+	memberships, err := s.userRepo.GetMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range memberships {
+		if memberships[i].TeamID == teamID {
+			return &memberships[i], nil
+		}
+	}
+	return nil, NotTeamMemberError{}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"reviewer_service/internal/domain"
 	"reviewer_service/internal/repository"
 )
@@ -10,10 +13,11 @@ type TeamService struct {
 	userRepo  repository.UserRepository
 	prRepo    repository.PullRequestRepository
 	prService *PullRequestService
+	tx        repository.TxManager
 }
 
-func NewTeamService(teamRepo repository.TeamRepository, userRepo repository.UserRepository, prRepo repository.PullRequestRepository, prService *PullRequestService) *TeamService {
-	return &TeamService{teamRepo: teamRepo, userRepo: userRepo, prRepo: prRepo, prService: prService}
+func NewTeamService(teamRepo repository.TeamRepository, userRepo repository.UserRepository, prRepo repository.PullRequestRepository, prService *PullRequestService, tx repository.TxManager) *TeamService {
+	return &TeamService{teamRepo: teamRepo, userRepo: userRepo, prRepo: prRepo, prService: prService, tx: tx}
 }
 
 type TeamExistsError struct{}
@@ -24,38 +28,119 @@ type TeamNotFoundError struct{}
 
 func (e TeamNotFoundError) Error() string { return "team not found" }
 
-func (s *TeamService) AddTeam(name string, members []domain.User) (*domain.Team, error) {
-	exists, err := s.teamRepo.Exists(name)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, TeamExistsError{}
-	}
+type TeamArchivedError struct{}
 
-	teamID, err := s.teamRepo.Create(name)
-	if err != nil {
-		return nil, err
-	}
+func (e TeamArchivedError) Error() string { return "team is archived" }
+
+type TeamNotEmptyError struct{}
+
+func (e TeamNotEmptyError) Error() string { return "team still has members" }
+
+type TeamHasPullRequestsError struct{}
+
+func (e TeamHasPullRequestsError) Error() string { return "team members authored pull requests" }
 
+type PullRequestsInOtherTeamsError struct{}
+
+func (e PullRequestsInOtherTeamsError) Error() string {
+	return "team members authored pull requests of other teams"
+}
+
+type InvalidPolicyError struct {
+	Reason string
+}
+
+func (e InvalidPolicyError) Error() string { return "invalid policy: " + e.Reason }
+
+// AddTeam creates a team, optionally under parentName, and makes it the
+// primary team of the listed members.
+func (s *TeamService) AddTeam(ctx context.Context, name, parentName string, members []domain.User) (*domain.Team, error) {
 	for i := range members {
-		members[i].TeamID = teamID
+		if members[i].Role == "" {
+			members[i].Role = RoleMember
+		}
+		if !ValidRole(members[i].Role) {
+			return nil, InvalidRoleError{}
+		}
 	}
 
-	err = s.userRepo.UpsertMany(members)
+	var teamID int64
+	var parent *domain.Team
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.teamRepo.Exists(ctx, name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return TeamExistsError{}
+		}
+
+		teamID, err = s.teamRepo.Create(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if parentName != "" {
+			parent, err = s.resolveParent(ctx, teamID, parentName)
+			if err != nil {
+				return err
+			}
+			if err := s.teamRepo.SetParent(ctx, teamID, &parent.ID); err != nil {
+				return err
+			}
+		}
+
+		// Existing users listed here are moved into the new team; keep a
+		// history record so the move is not silent.
+		var moves []*domain.TeamMove
+		for i := range members {
+			prev, err := s.userRepo.GetUserByID(ctx, members[i].ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if prev != nil {
+				fromTeamID := prev.TeamID
+				moves = append(moves, &domain.TeamMove{
+					UserID:            prev.ID,
+					FromTeamID:        &fromTeamID,
+					FromTeamName:      prev.TeamName,
+					ToTeamID:          teamID,
+					ToTeamName:        name,
+					Source:            MoveSourceTeamAdd,
+					OpenReviewsPolicy: OpenReviewsKeep,
+				})
+			}
+			members[i].TeamID = teamID
+		}
+
+		if err := s.userRepo.UpsertMany(ctx, members); err != nil {
+			return err
+		}
+		for _, move := range moves {
+			if err := s.userRepo.RecordTeamMove(ctx, move); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &domain.Team{
+	team := &domain.Team{
 		ID:      teamID,
 		Name:    name,
 		Members: members,
-	}, nil
+	}
+	if parent != nil {
+		team.ParentID = &parent.ID
+		team.ParentName = parent.Name
+	}
+	return team, nil
 }
 
-func (s *TeamService) DeactivateUsersAndReassign(teamName string, userIDs []string) error {
-	exists, err := s.teamRepo.Exists(teamName)
+func (s *TeamService) DeactivateUsersAndReassign(ctx context.Context, teamName string, userIDs []string) error {
+	exists, err := s.teamRepo.Exists(ctx, teamName)
 	if err != nil {
 		return err
 	}
@@ -63,13 +148,13 @@ func (s *TeamService) DeactivateUsersAndReassign(teamName string, userIDs []stri
 		return TeamNotFoundError{}
 	}
 
-	openPRs, err := s.prRepo.GetOpenPRsWithReviewers(userIDs)
+	openPRs, err := s.prRepo.GetOpenPRsWithReviewers(ctx, userIDs)
 	if err != nil {
 		return err
 	}
 
 	for _, pr := range openPRs {
-		reviewers, err := s.prRepo.GetReviewers(pr.ID)
+		reviewers, err := s.prRepo.GetReviewers(ctx, pr.ID)
 		if err != nil {
 			return err
 		}
@@ -77,9 +162,9 @@ func (s *TeamService) DeactivateUsersAndReassign(teamName string, userIDs []stri
 		for _, reviewerID := range reviewers {
 			for _, id := range userIDs {
 				if id == reviewerID {
-					_, _, err := s.prService.ReassignReviewer(pr.ID, reviewerID)
+					_, _, err := s.prService.ReassignReviewer(ctx, pr.ID, reviewerID)
 					if err != nil {
-						if err.Error() != "no active replacement candidate in team" {
+						if _, ok := err.(AssignmentRuleError); !ok && err.Error() != "no active replacement candidate in team" {
 							return err
 						}
 					}
@@ -89,11 +174,11 @@ func (s *TeamService) DeactivateUsersAndReassign(teamName string, userIDs []stri
 		}
 	}
 
-	return s.userRepo.DeactivateUsers(userIDs)
+	return s.userRepo.DeactivateUsers(ctx, userIDs)
 }
 
-func (s *TeamService) GetTeam(teamName string) (*domain.Team, error) {
-	team, err := s.teamRepo.GetByName(teamName)
+func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*domain.Team, error) {
+	team, err := s.teamRepo.GetByName(ctx, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -1,22 +1,172 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"reviewer_service/internal/domain"
 	"reviewer_service/internal/repository"
 )
 
+const (
+	OpenReviewsKeep     = "keep"
+	OpenReviewsReassign = "reassign"
+
+	MoveSourceMove    = "move"
+	MoveSourceTeamAdd = "team_add"
+)
+
 type UserService struct {
-	userRepo repository.UserRepository
-	teamRepo repository.TeamRepository
+	userRepo  repository.UserRepository
+	teamRepo  repository.TeamRepository
+	prRepo    repository.PullRequestRepository
+	prService *PullRequestService
+	tx        repository.TxManager
+}
+
+func NewUserService(userRepo repository.UserRepository, teamRepo repository.TeamRepository, prRepo repository.PullRequestRepository, prService *PullRequestService, tx repository.TxManager) *UserService {
+	return &UserService{userRepo: userRepo, teamRepo: teamRepo, prRepo: prRepo, prService: prService, tx: tx}
+}
+
+type UserNotFoundError struct{}
+
+func (e UserNotFoundError) Error() string { return "user not found" }
+
+type AlreadyInTeamError struct{}
+
+func (e AlreadyInTeamError) Error() string { return "user already belongs to this team" }
+
+type MoveResult struct {
+	User       *domain.User
+	Move       *domain.TeamMove
+	Reassigned []ReviewerChange
+	// Flagged lists open PRs whose review could not be handed over within
+	// the old team; the user stays assigned and the PR needs attention.
+	Flagged []string
+}
+
+func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	if err := s.userRepo.SetIsActive(ctx, userID, isActive); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserByID(ctx, userID)
 }
 
-func NewUserService(userRepo repository.UserRepository, teamRepo repository.TeamRepository) *UserService {
-	return &UserService{userRepo: userRepo, teamRepo: teamRepo}
+// MoveUser changes the user's primary team; secondary memberships are kept.
+// With OpenReviewsReassign their open reviews on the old team's PRs are
+// handed to other members of that team; with OpenReviewsKeep they stay on
+// those PRs.
+func (s *UserService) MoveUser(ctx context.Context, userID, teamName, openReviews string) (*MoveResult, error) {
+	if openReviews == "" {
+		openReviews = OpenReviewsKeep
+	}
+	if openReviews != OpenReviewsKeep && openReviews != OpenReviewsReassign {
+		return nil, InvalidPolicyError{Reason: "open_reviews must be keep or reassign"}
+	}
+
+	result := &MoveResult{}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+
+		target, err := s.teamRepo.GetByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return TeamNotFoundError{}
+		}
+		if target.IsArchived() {
+			return TeamArchivedError{}
+		}
+		if target.ID == user.TeamID {
+			return AlreadyInTeamError{}
+		}
+
+		if openReviews == OpenReviewsReassign {
+			if err := s.handOverReviews(ctx, user, target.Name, result); err != nil {
+				return err
+			}
+		}
+
+		if err := s.userRepo.SetPrimaryTeam(ctx, user.ID, target.ID); err != nil {
+			return err
+		}
+
+		fromTeamID := user.TeamID
+		move := &domain.TeamMove{
+			UserID:            user.ID,
+			FromTeamID:        &fromTeamID,
+			FromTeamName:      user.TeamName,
+			ToTeamID:          target.ID,
+			ToTeamName:        target.Name,
+			Source:            MoveSourceMove,
+			OpenReviewsPolicy: openReviews,
+			ReassignedReviews: len(result.Reassigned),
+		}
+		if err := s.userRepo.RecordTeamMove(ctx, move); err != nil {
+			return err
+		}
+
+		user.TeamID = target.ID
+		user.TeamName = target.Name
+		result.User = user
+		result.Move = move
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *UserService) handOverReviews(ctx context.Context, user *domain.User, targetName string, result *MoveResult) error {
+	openPRs, err := s.prRepo.GetOpenPRsWithReviewers(ctx, []string{user.ID})
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range openPRs {
+		// Reviews for other teams the user belongs to are unaffected.
+		if pr.TeamID != 0 && pr.TeamID != user.TeamID {
+			continue
+		}
+		reviewers, err := s.prRepo.GetReviewers(ctx, pr.ID)
+		if err != nil {
+			return err
+		}
+
+		newID, err := s.prService.replaceWithinTeam(ctx, pr, user.ID, user.TeamID, reviewers)
+		if err != nil {
+			return err
+		}
+		if newID == "" {
+			if err := s.prRepo.SetNeedsAttention(ctx, pr.ID, "reviewer "+user.ID+" moved to team "+targetName); err != nil {
+				return err
+			}
+			result.Flagged = append(result.Flagged, pr.ID)
+			continue
+		}
+		result.Reassigned = append(result.Reassigned, ReviewerChange{
+			PullRequestID: pr.ID,
+			OldReviewerID: user.ID,
+			NewReviewerID: newID,
+		})
+	}
+	return nil
 }
 
-func (s *UserService) SetIsActive(userID string, isActive bool) (*domain.User, error) {
-	if err := s.userRepo.SetIsActive(userID, isActive); err != nil {
+func (s *UserService) GetTeamHistory(ctx context.Context, userID string) ([]domain.TeamMove, error) {
+	if _, err := s.userRepo.GetTeamIDByUserID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
 		return nil, err
 	}
-	return s.userRepo.GetUserByID(userID)
+	return s.userRepo.GetTeamHistory(ctx, userID)
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reviewer_service/internal/domain"
+	"time"
+)
+
+type InvalidScheduleError struct {
+	Reason string
+}
+
+func (e InvalidScheduleError) Error() string { return e.Reason }
+
+func validateSchedule(schedule domain.WorkSchedule) error {
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil || schedule.Timezone == "" {
+		return InvalidScheduleError{Reason: "timezone must be an IANA name such as Europe/Moscow"}
+	}
+	if schedule.StartMinute < 0 || schedule.EndMinute > 24*60 || schedule.StartMinute >= schedule.EndMinute {
+		return InvalidScheduleError{Reason: "work_start must be before work_end on the same day"}
+	}
+	if len(schedule.Weekdays) == 0 {
+		return InvalidScheduleError{Reason: "at least one work day is required"}
+	}
+	return nil
+}
+
+// SetWorkSchedule replaces the user's timezone and working hours.
+func (s *UserService) SetWorkSchedule(ctx context.Context, userID string, schedule domain.WorkSchedule) (*domain.User, error) {
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	var user *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return UserNotFoundError{}
+			}
+			return err
+		}
+		if err := s.userRepo.SetWorkSchedule(ctx, userID, schedule); err != nil {
+			return err
+		}
+		var err error
+		user, err = s.userRepo.GetUserByID(ctx, userID)
+		return err
+	})
+	return user, err
+}
+
+func (s *UserService) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFoundError{}
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetReviewClocks returns a clock per assigned reviewer, started when they
+// were assigned and stopped when the PR was merged. The team's review SLA
+// overrides the configured one.
+func (s *PullRequestService) GetReviewClocks(ctx context.Context, prID string) (*domain.PullRequest, []domain.ReviewClock, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, AuthorNotFoundError{}
+		}
+		return nil, nil, err
+	}
+	assignments, err := s.prRepo.GetAssignments(ctx, prID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sla := s.opts.ReviewSLA
+	if pr.TeamID != 0 {
+		team, err := s.teamRepo.GetByID(ctx, pr.TeamID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if team.ReviewSLA != nil {
+			sla = *team.ReviewSLA
+		}
+	}
+
+	now := time.Now()
+	stopped := now
+	if pr.MergedAt != nil {
+		stopped = *pr.MergedAt
+	}
+
+	clocks := make([]domain.ReviewClock, 0, len(assignments))
+	for _, a := range assignments {
+		reviewer, err := s.userRepo.GetUserByID(ctx, a.ReviewerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		schedule := reviewer.Schedule
+
+		clock := domain.ReviewClock{
+			ReviewerID:     a.ReviewerID,
+			StartedAt:      a.AssignedAt,
+			Elapsed:        schedule.BusinessDuration(a.AssignedAt, stopped),
+			InWorkingHours: schedule.InHours(now),
+		}
+		if sla > 0 {
+			due := schedule.AddBusiness(a.AssignedAt, sla)
+			clock.DueAt = &due
+			clock.Overdue = pr.Status != StatusMerged && clock.Elapsed >= sla
+		}
+		clocks = append(clocks, clock)
+	}
+	return pr, clocks, nil
+}
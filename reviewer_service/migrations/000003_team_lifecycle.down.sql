@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_pull_requests_needs_attention;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS attention_reason,
+    DROP COLUMN IF EXISTS needs_attention;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS archived_at;
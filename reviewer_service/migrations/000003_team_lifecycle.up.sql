@@ -0,0 +1,7 @@
+ALTER TABLE teams ADD COLUMN archived_at TIMESTAMPTZ;
+
+ALTER TABLE pull_requests
+    ADD COLUMN needs_attention BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN attention_reason TEXT;
+
+CREATE INDEX idx_pull_requests_needs_attention ON pull_requests(needs_attention) WHERE needs_attention;
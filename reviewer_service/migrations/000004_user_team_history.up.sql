@@ -0,0 +1,14 @@
+CREATE TABLE user_team_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_team_id INT REFERENCES teams(id) ON DELETE SET NULL,
+    from_team_name TEXT,
+    to_team_id INT REFERENCES teams(id) ON DELETE SET NULL,
+    to_team_name TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('move', 'team_add')),
+    open_reviews_policy TEXT NOT NULL CHECK (open_reviews_policy IN ('keep', 'reassign')),
+    reassigned_reviews INT NOT NULL DEFAULT 0,
+    moved_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_team_history_user_id ON user_team_history(user_id, moved_at);
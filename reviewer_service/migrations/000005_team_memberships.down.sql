@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN team_id INT REFERENCES teams(id) ON DELETE CASCADE;
+
+UPDATE users u
+SET team_id = m.team_id
+FROM team_memberships m
+WHERE m.user_id = u.id AND m.is_primary;
+
+-- Secondary memberships cannot be represented by users.team_id and are lost.
+DELETE FROM users WHERE team_id IS NULL;
+
+ALTER TABLE users ALTER COLUMN team_id SET NOT NULL;
+CREATE INDEX idx_users_team_id ON users(team_id);
+
+DROP INDEX idx_pull_requests_team_id;
+ALTER TABLE pull_requests DROP COLUMN team_id;
+
+DROP TABLE team_memberships;
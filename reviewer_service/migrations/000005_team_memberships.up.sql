@@ -0,0 +1,25 @@
+CREATE TABLE team_memberships (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, team_id)
+);
+
+CREATE UNIQUE INDEX idx_team_memberships_primary ON team_memberships(user_id) WHERE is_primary;
+CREATE INDEX idx_team_memberships_team_id ON team_memberships(team_id);
+
+INSERT INTO team_memberships (user_id, team_id, is_primary)
+SELECT id, team_id, true FROM users;
+
+ALTER TABLE pull_requests ADD COLUMN team_id INT REFERENCES teams(id) ON DELETE SET NULL;
+
+UPDATE pull_requests pr
+SET team_id = m.team_id
+FROM team_memberships m
+WHERE m.user_id = pr.author_id AND m.is_primary;
+
+CREATE INDEX idx_pull_requests_team_id ON pull_requests(team_id);
+
+DROP INDEX idx_users_team_id;
+ALTER TABLE users DROP COLUMN team_id;
@@ -0,0 +1,5 @@
+ALTER TABLE teams ADD COLUMN parent_id INT REFERENCES teams(id) ON DELETE SET NULL;
+
+ALTER TABLE teams ADD CONSTRAINT teams_parent_not_self CHECK (parent_id IS NULL OR parent_id != id);
+
+CREATE INDEX idx_teams_parent_id ON teams(parent_id);
@@ -0,0 +1 @@
+ALTER TABLE team_memberships DROP COLUMN role;
@@ -0,0 +1,2 @@
+ALTER TABLE team_memberships
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('lead', 'senior', 'member', 'junior'));
@@ -0,0 +1,19 @@
+CREATE TABLE user_absences (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('vacation', 'sick_leave', 'part_time')),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    -- Bit d (0 = Sunday) marks a recurring day off; 0 means every day in the window.
+    weekdays INT NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'ics')),
+    external_uid TEXT,
+    handed_over_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_user_absences_user_id ON user_absences(user_id, starts_at);
+CREATE UNIQUE INDEX idx_user_absences_external_uid ON user_absences(user_id, external_uid) WHERE external_uid IS NOT NULL;
+CREATE INDEX idx_user_absences_pending_handover ON user_absences(starts_at) WHERE handed_over_at IS NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP CONSTRAINT users_work_hours_check,
+    DROP COLUMN work_days,
+    DROP COLUMN work_end,
+    DROP COLUMN work_start,
+    DROP COLUMN timezone;
@@ -0,0 +1,7 @@
+-- work_days is a bitmask of weekdays, bit 0 = Sunday; 62 is Monday to Friday.
+ALTER TABLE users
+    ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN work_start TIME NOT NULL DEFAULT '09:00',
+    ADD COLUMN work_end TIME NOT NULL DEFAULT '18:00',
+    ADD COLUMN work_days INT NOT NULL DEFAULT 62,
+    ADD CONSTRAINT users_work_hours_check CHECK (work_start < work_end);
@@ -0,0 +1,2 @@
+DROP TABLE pull_request_files;
+DROP TABLE code_owner_rules;
@@ -0,0 +1,14 @@
+-- Rules are evaluated CODEOWNERS-style: the last matching position wins.
+CREATE TABLE code_owner_rules (
+    team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    pattern TEXT NOT NULL,
+    owner_ids TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (team_id, position)
+);
+
+CREATE TABLE pull_request_files (
+    pr_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    PRIMARY KEY (pr_id, path)
+);
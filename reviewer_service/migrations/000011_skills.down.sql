@@ -0,0 +1,2 @@
+DROP TABLE pull_request_skills;
+DROP TABLE user_skills;
@@ -0,0 +1,13 @@
+CREATE TABLE user_skills (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    skill TEXT NOT NULL,
+    PRIMARY KEY (user_id, skill)
+);
+
+CREATE INDEX idx_user_skills_skill ON user_skills(skill);
+
+CREATE TABLE pull_request_skills (
+    pr_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    skill TEXT NOT NULL,
+    PRIMARY KEY (pr_id, skill)
+);
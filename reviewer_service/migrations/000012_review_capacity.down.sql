@@ -0,0 +1,3 @@
+DROP TABLE review_queue;
+ALTER TABLE teams DROP COLUMN max_open_reviews;
+ALTER TABLE users DROP COLUMN max_open_reviews;
@@ -0,0 +1,13 @@
+-- NULL inherits the cap (user -> team -> config); 0 means unlimited.
+ALTER TABLE users ADD COLUMN max_open_reviews INT CHECK (max_open_reviews >= 0);
+ALTER TABLE teams ADD COLUMN max_open_reviews INT CHECK (max_open_reviews >= 0);
+
+-- PRs waiting for reviewers because every candidate was at capacity.
+CREATE TABLE review_queue (
+    pr_id TEXT PRIMARY KEY REFERENCES pull_requests(id) ON DELETE CASCADE,
+    slots INT NOT NULL CHECK (slots > 0),
+    priority INT NOT NULL DEFAULT 0,
+    enqueued_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_queue_order ON review_queue(priority DESC, enqueued_at);
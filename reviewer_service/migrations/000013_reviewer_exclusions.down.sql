@@ -0,0 +1,2 @@
+DROP TABLE pull_request_co_authors;
+DROP TABLE reviewer_exclusions;
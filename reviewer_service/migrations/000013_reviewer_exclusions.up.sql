@@ -0,0 +1,19 @@
+-- excluded_user_id never reviews PRs authored or co-authored by user_id;
+-- a mutual rule also applies the other way round.
+CREATE TABLE reviewer_exclusions (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    excluded_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mutual BOOLEAN NOT NULL DEFAULT false,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, excluded_user_id),
+    CHECK (user_id <> excluded_user_id)
+);
+
+CREATE INDEX idx_reviewer_exclusions_excluded ON reviewer_exclusions(excluded_user_id);
+
+CREATE TABLE pull_request_co_authors (
+    pr_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (pr_id, user_id)
+);
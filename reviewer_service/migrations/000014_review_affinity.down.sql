@@ -0,0 +1,2 @@
+DROP INDEX idx_pull_requests_author_created;
+ALTER TABLE pull_requests DROP COLUMN feature;
@@ -0,0 +1,5 @@
+-- feature is a branch name or feature tag shared by follow-up PRs.
+ALTER TABLE pull_requests ADD COLUMN feature TEXT;
+
+CREATE INDEX idx_pull_requests_feature ON pull_requests(feature) WHERE feature IS NOT NULL;
+CREATE INDEX idx_pull_requests_author_created ON pull_requests(author_id, created_at);
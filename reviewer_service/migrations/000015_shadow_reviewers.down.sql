@@ -0,0 +1,2 @@
+DROP TABLE pr_shadow_reviewers;
+ALTER TABLE users DROP COLUMN shadow_opt_in;
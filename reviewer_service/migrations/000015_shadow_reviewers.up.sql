@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN shadow_opt_in BOOLEAN NOT NULL DEFAULT false;
+
+-- Shadow reviewers learn by following a primary reviewer, the mentor. They
+-- are kept apart from pr_reviewers so they never count as reviewers.
+CREATE TABLE pr_shadow_reviewers (
+    pr_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    reviewer_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentor_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pr_id, reviewer_id)
+);
+
+CREATE INDEX idx_pr_shadow_reviewers_reviewer ON pr_shadow_reviewers(reviewer_id);
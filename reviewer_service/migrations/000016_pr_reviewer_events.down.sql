@@ -0,0 +1 @@
+DROP TABLE pr_reviewer_events;
@@ -0,0 +1,12 @@
+-- Audit trail of manual reviewer changes; actor is whoever made the change.
+CREATE TABLE pr_reviewer_events (
+    id BIGSERIAL PRIMARY KEY,
+    pr_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('add', 'remove', 'reassign')),
+    reviewer_id TEXT NOT NULL,
+    previous_reviewer_id TEXT,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pr_reviewer_events_pr ON pr_reviewer_events(pr_id, created_at);
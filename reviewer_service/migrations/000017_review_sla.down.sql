@@ -0,0 +1,7 @@
+ALTER TABLE teams
+    DROP COLUMN escalation_after_seconds,
+    DROP COLUMN review_sla_seconds;
+ALTER TABLE pr_reviewers
+    DROP COLUMN escalated_at,
+    DROP COLUMN breached_at,
+    DROP COLUMN assigned_at;
@@ -0,0 +1,15 @@
+ALTER TABLE pr_reviewers
+    ADD COLUMN assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN breached_at TIMESTAMPTZ,
+    ADD COLUMN escalated_at TIMESTAMPTZ;
+
+-- Existing assignments are dated from their PR's creation.
+UPDATE pr_reviewers prr
+SET assigned_at = pr.created_at
+FROM pull_requests pr
+WHERE pr.id = prr.pr_id AND pr.created_at IS NOT NULL;
+
+-- Per-team overrides of sla.review_time and sla.escalation.after.
+ALTER TABLE teams
+    ADD COLUMN review_sla_seconds BIGINT CHECK (review_sla_seconds >= 0),
+    ADD COLUMN escalation_after_seconds BIGINT CHECK (escalation_after_seconds >= 0);
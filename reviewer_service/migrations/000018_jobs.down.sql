@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS delayed_jobs;
+DROP TABLE IF EXISTS scheduled_jobs;
@@ -0,0 +1,27 @@
+-- State of periodic background jobs, shared by all replicas.
+CREATE TABLE scheduled_jobs (
+    name TEXT PRIMARY KEY,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_started_at TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    last_success_at TIMESTAMPTZ,
+    last_error TEXT
+);
+
+-- One-off jobs to run at or after run_at. A claimed job is leased until
+-- locked_until so a replica that dies mid-run does not lose it.
+CREATE TABLE delayed_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    payload BYTEA,
+    run_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_delayed_jobs_due ON delayed_jobs(run_at) WHERE status = 'pending';
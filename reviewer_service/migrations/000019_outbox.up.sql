@@ -0,0 +1,17 @@
+-- Events about PR and reviewer changes, written in the same transaction as
+-- the change and published by the outbox relay. pr_id is not a foreign key
+-- so events of deleted PRs are still delivered.
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    pr_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events(pr_id, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published ON outbox_events(published_at) WHERE published_at IS NOT NULL;
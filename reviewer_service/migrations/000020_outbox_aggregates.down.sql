@@ -0,0 +1,6 @@
+DELETE FROM outbox_events WHERE aggregate_type != 'pull_request';
+
+DROP INDEX idx_outbox_events_pending;
+ALTER TABLE outbox_events DROP COLUMN aggregate_type;
+ALTER TABLE outbox_events RENAME COLUMN aggregate_id TO pr_id;
+CREATE INDEX idx_outbox_events_pending ON outbox_events(pr_id, id) WHERE published_at IS NULL;
@@ -0,0 +1,8 @@
+-- Outbox events now also describe users and teams. Events are ordered per
+-- (aggregate_type, aggregate_id); existing rows are PR events.
+ALTER TABLE outbox_events RENAME COLUMN pr_id TO aggregate_id;
+ALTER TABLE outbox_events ADD COLUMN aggregate_type TEXT NOT NULL DEFAULT 'pull_request';
+ALTER TABLE outbox_events ALTER COLUMN aggregate_type DROP DEFAULT;
+
+DROP INDEX idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events(aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN last_digest_at;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,10 @@
+-- Users without a row get notify.default_mode and no Slack contact.
+CREATE TABLE notification_preferences (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    mode TEXT NOT NULL CHECK (mode IN ('instant', 'digest', 'off')),
+    slack_webhook_url TEXT,
+    slack_member_id TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users ADD COLUMN last_digest_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN unsubscribe_token,
+    DROP COLUMN email_unsubscribed,
+    DROP COLUMN email;
@@ -0,0 +1,6 @@
+-- unsubscribe_token identifies the user in the unsubscribe link of emails;
+-- it is regenerated whenever the email changes.
+ALTER TABLE users
+    ADD COLUMN email TEXT,
+    ADD COLUMN email_unsubscribed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN unsubscribe_token TEXT UNIQUE;
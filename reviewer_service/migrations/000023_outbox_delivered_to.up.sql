@@ -0,0 +1,3 @@
+-- delivered_to lists the sinks that accepted the event, so a retry only goes
+-- to the sinks that failed.
+ALTER TABLE outbox_events ADD COLUMN delivered_to TEXT[] NOT NULL DEFAULT '{}';